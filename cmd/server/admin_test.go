@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/repository/memory"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// testAdminToken is long enough to pass configuration validation
+const testAdminToken = "0123456789abcdef-admin"
+
+// newAdminRouter returns a router serving the admin API for token, and the
+// game service it controls
+func newAdminRouter(t *testing.T, token string) (*gin.Engine, domain.GameService) {
+	t.Helper()
+
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gameService := service.NewGameService(memory.NewGameRepository(0, 0), nil, cfg.Game, cfg.Scheduler, logger)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerAdminAPI(r, config.AdminConfig{Token: token}, gameService, logger)
+	return r, gameService
+}
+
+// adminRequest sends a request to the admin API with an Authorization
+// header, unless it is empty
+func adminRequest(r *gin.Engine, method, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminAPIRequiresToken(t *testing.T) {
+	r, _ := newAdminRouter(t, testAdminToken)
+
+	for _, tc := range []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer not-the-admin-token", http.StatusUnauthorized},
+		{"token prefix", "Bearer " + testAdminToken[:8], http.StatusUnauthorized},
+		{"token without scheme", testAdminToken, http.StatusUnauthorized},
+		{"basic scheme", "Basic " + testAdminToken, http.StatusUnauthorized},
+		{"admin token", "Bearer " + testAdminToken, http.StatusOK},
+	} {
+		w := adminRequest(r, http.MethodGet, "/admin/status", tc.authorization)
+		if w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, w.Code, tc.want)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 without WWW-Authenticate", tc.name)
+		}
+	}
+}
+
+func TestAdminAPIDisabledWithoutToken(t *testing.T) {
+	r, _ := newAdminRouter(t, "")
+
+	for _, path := range []string{"/admin", "/admin/status", "/admin/sessions"} {
+		if w := adminRequest(r, http.MethodGet, path, "Bearer "); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want 404", path, w.Code)
+		}
+	}
+	if w := adminRequest(r, http.MethodPost, "/admin/drain", "Bearer "); w.Code != http.StatusNotFound {
+		t.Errorf("drain: got %d, want 404", w.Code)
+	}
+}
+
+func TestAdminAPIDrain(t *testing.T) {
+	r, gameService := newAdminRouter(t, testAdminToken)
+	ctx := context.Background()
+	auth := "Bearer " + testAdminToken
+
+	drain := func(method string, want bool) {
+		t.Helper()
+		w := adminRequest(r, method, "/admin/drain", auth)
+		var status domain.AdminStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s /admin/drain: got %d %s", method, w.Code, w.Body.String())
+		}
+		if status.Draining != want {
+			t.Fatalf("%s /admin/drain: got draining %t, want %t", method, status.Draining, want)
+		}
+	}
+
+	drain(http.MethodPost, true)
+	if _, err := gameService.CreateGame(ctx, "a", domain.GameOptions{}); !errors.Is(err, domain.ErrDraining) {
+		t.Fatalf("CreateGame while draining: got %v, want %v", err, domain.ErrDraining)
+	}
+
+	drain(http.MethodDelete, false)
+	if _, err := gameService.CreateGame(ctx, "a", domain.GameOptions{}); err != nil {
+		t.Fatalf("CreateGame after the drain: %v", err)
+	}
+}
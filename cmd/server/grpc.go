@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	grpchandler "github.com/siddarth/go-app/internal/handler/grpc"
+	"github.com/siddarth/go-app/internal/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// grpcServer serves the game service over gRPC on its own port
+type grpcServer struct {
+	server   *grpc.Server
+	games    *grpchandler.GameServer
+	listener net.Listener
+	logger   *slog.Logger
+}
+
+// grpcRoutes maps gRPC methods to the HTTP routes whose rate limit rules,
+// and buckets, they share
+var grpcRoutes = map[string]string{
+	gamev1.GameService_Start_FullMethodName: "POST /api/game/start",
+}
+
+// newGRPCServer listens on the gRPC port, so that a port in use is reported
+// at startup, and registers the game service with the same recovery,
+// logging, tracing and rate limits as the HTTP server
+func newGRPCServer(cfg *config.Config, gameService domain.GameService, rateLimiter *middleware.RateLimiter, logger *slog.Logger) (*grpcServer, error) {
+	listener, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on grpc port: %w", err)
+	}
+	return newGRPCServerOn(listener, cfg, gameService, rateLimiter, logger), nil
+}
+
+// newGRPCServerOn registers the game service on a server that accepts
+// connections from listener
+func newGRPCServerOn(listener net.Listener, cfg *config.Config, gameService domain.GameService, rateLimiter *middleware.RateLimiter, logger *slog.Logger) *grpcServer {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			middleware.UnaryRecovery(logger),
+			middleware.UnaryLogging(logger),
+			middleware.UnaryTracing(cfg.Observability.ServiceName),
+			rateLimiter.UnaryRateLimit(grpcRoutes),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.StreamRecovery(logger),
+			middleware.StreamLogging(logger),
+			middleware.StreamTracing(cfg.Observability.ServiceName),
+			rateLimiter.StreamRateLimit(grpcRoutes),
+		),
+	)
+
+	games := grpchandler.NewGameServer(gameService, cfg.GRPC, logger)
+	games.Register(server)
+	// Lets tools such as grpcurl discover the service
+	reflection.Register(server)
+
+	return &grpcServer{
+		server:   server,
+		games:    games,
+		listener: listener,
+		logger:   logger,
+	}
+}
+
+// serve accepts connections until the server is shut down
+func (s *grpcServer) serve() error {
+	s.logger.Info("grpc server listening", "addr", s.listener.Addr().String())
+	return s.server.Serve(s.listener)
+}
+
+// shutdown ends watch streams and waits for calls in flight to finish,
+// closing remaining connections once ctx is done
+func (s *grpcServer) shutdown(ctx context.Context) {
+	s.games.Stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		s.logger.Info("grpc server shutdown complete")
+	case <-ctx.Done():
+		s.logger.Error("forcing grpc server shutdown", "error", ctx.Err())
+		s.server.Stop()
+	}
+}
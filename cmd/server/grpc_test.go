@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/middleware"
+	"github.com/siddarth/go-app/internal/repository/memory"
+	"github.com/siddarth/go-app/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startGRPC serves the game service over an in-memory connection with the
+// default configuration, changed by tune, and returns a client for it
+// along with the service behind it
+func startGRPC(t *testing.T, tune func(cfg *config.Config)) (gamev1.GameServiceClient, domain.GameService) {
+	t.Helper()
+
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	if tune != nil {
+		tune(cfg)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gameService := service.NewGameService(memory.NewGameRepository(0, 0), nil, cfg.Game, cfg.Scheduler, logger)
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, logger)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := newGRPCServerOn(listener, cfg, gameService, rateLimiter, logger)
+	go srv.serve()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.shutdown(ctx)
+	})
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return gamev1.NewGameServiceClient(conn), gameService
+}
+
+// wantCode fails the test unless err is a status error with code
+func wantCode(t *testing.T, what string, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Errorf("%s: got %s (%v), want %s", what, got, err, code)
+	}
+}
+
+func TestGRPCStartMoveAndGetState(t *testing.T) {
+	client, _ := startGRPC(t, nil)
+	ctx := context.Background()
+
+	started, err := client.Start(ctx, &gamev1.StartRequest{SessionId: "a", Name: "pac", MaxPlayers: 2})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if started.GetSessionId() != "a" || started.GetPlayerToken() == "" || started.GetSpectatorToken() == "" {
+		t.Fatalf("Start: got %+v", started)
+	}
+
+	_, err = client.Move(ctx, &gamev1.MoveRequest{SessionId: "a", PlayerToken: started.GetPlayerToken(), Direction: gamev1.Direction_DIRECTION_LEFT})
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	state, err := client.GetState(ctx, &gamev1.GetStateRequest{SessionId: "a"})
+	if err != nil {
+		t.Fatalf("GetState: %v", err)
+	}
+	players := state.GetPlayers()
+	if len(players) != 1 || players[0].GetName() != "pac" || state.GetMaxPlayers() != 2 {
+		t.Errorf("GetState: got players %v and max players %d", players, state.GetMaxPlayers())
+	}
+	if state.GetDotsLeft() == 0 || state.GetGameOver() || len(state.GetBoard()) == 0 {
+		t.Errorf("GetState: got a finished or empty game: %+v", state)
+	}
+
+	_, err = client.Move(ctx, &gamev1.MoveRequest{SessionId: "a", PlayerToken: started.GetPlayerToken()})
+	wantCode(t, "Move without a direction", err, codes.InvalidArgument)
+	_, err = client.Move(ctx, &gamev1.MoveRequest{SessionId: "a", PlayerToken: "nope", Direction: gamev1.Direction_DIRECTION_UP})
+	wantCode(t, "Move with an unknown token", err, codes.PermissionDenied)
+	_, err = client.GetState(ctx, &gamev1.GetStateRequest{SessionId: "missing"})
+	wantCode(t, "GetState of a missing game", err, codes.NotFound)
+	_, err = client.Start(ctx, &gamev1.StartRequest{SessionId: "a"})
+	wantCode(t, "Start of a running session", err, codes.AlreadyExists)
+}
+
+func TestGRPCDeleteRequiresHost(t *testing.T) {
+	client, gameService := startGRPC(t, nil)
+	ctx := context.Background()
+
+	started, err := client.Start(ctx, &gamev1.StartRequest{SessionId: "a", MaxPlayers: 2})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	guest, err := gameService.JoinGame(ctx, "a", "guest")
+	if err != nil {
+		t.Fatalf("JoinGame: %v", err)
+	}
+
+	_, err = client.Delete(ctx, &gamev1.DeleteRequest{SessionId: "a"})
+	wantCode(t, "Delete without a token", err, codes.PermissionDenied)
+	_, err = client.Delete(ctx, &gamev1.DeleteRequest{SessionId: "a", PlayerToken: guest.Token})
+	wantCode(t, "Delete by a guest", err, codes.PermissionDenied)
+	if _, err := client.GetState(ctx, &gamev1.GetStateRequest{SessionId: "a"}); err != nil {
+		t.Fatalf("game is gone after rejected deletes: %v", err)
+	}
+
+	if _, err := client.Delete(ctx, &gamev1.DeleteRequest{SessionId: "a", PlayerToken: started.GetPlayerToken()}); err != nil {
+		t.Fatalf("Delete by the host: %v", err)
+	}
+	_, err = client.GetState(ctx, &gamev1.GetStateRequest{SessionId: "a"})
+	wantCode(t, "GetState after Delete", err, codes.NotFound)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	client, _ := startGRPC(t, func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Routes["POST /api/game/start"] = &config.RateLimitRule{Rate: 0.5, Burst: 1}
+	})
+	ctx := context.Background()
+
+	if _, err := client.Start(ctx, &gamev1.StartRequest{SessionId: "a"}); err != nil {
+		t.Fatalf("first Start: %v", err)
+	}
+
+	var header metadata.MD
+	_, err := client.Start(ctx, &gamev1.StartRequest{SessionId: "b"}, grpc.Header(&header))
+	wantCode(t, "Start over the limit", err, codes.ResourceExhausted)
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("retry-after header: got %v, want [2]", got)
+	}
+
+	// Calls without a route limit of their own are not held back
+	if _, err := client.GetState(ctx, &gamev1.GetStateRequest{SessionId: "a"}); err != nil {
+		t.Errorf("GetState: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/health"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// newHealthRegistry registers the checks behind /livez and /readyz.
+// reporter may be nil when the game service does not report on itself.
+func newHealthRegistry(cfg config.HealthConfig, reporter service.HealthReporter) (*health.Registry, error) {
+	registry := health.NewRegistry(cfg.CheckTimeout)
+
+	var errs []error
+	register := func(name string, probes health.Probe, fn health.CheckFunc) {
+		errs = append(errs, registry.Register(name, probes, fn))
+	}
+
+	// This many goroutines means the server is overloaded, by streams or
+	// games, and should get no more traffic until the load falls. Restarting
+	// it would end every game it runs.
+	register("goroutines", health.Readiness, func(ctx context.Context) (string, error) {
+		n := runtime.NumGoroutine()
+		detail := fmt.Sprintf("%d goroutines", n)
+		if n > cfg.MaxGoroutines {
+			return detail, fmt.Errorf("more than %d goroutines", cfg.MaxGoroutines)
+		}
+		return detail, nil
+	})
+
+	if reporter != nil {
+		// Ticks that stop altogether mean a game loop hangs, which only a
+		// restart fixes
+		register("tick_progress", health.Liveness, func(ctx context.Context) (string, error) {
+			stall := reporter.TickStall()
+			detail := fmt.Sprintf("last ticks ran %s ago", stall.Round(time.Millisecond))
+			if stall > cfg.MaxTickStall {
+				return detail, fmt.Errorf("game ticks made no progress for more than %s", cfg.MaxTickStall)
+			}
+			return detail, nil
+		})
+
+		register("tick_lag", health.Readiness, func(ctx context.Context) (string, error) {
+			lag := reporter.TickLag()
+			if lag > cfg.MaxTickLag {
+				return lag.String(), fmt.Errorf("game ticks are more than %s late", cfg.MaxTickLag)
+			}
+			return lag.String(), nil
+		})
+
+		// Draining servers, including those shutting down, should get no
+		// new players
+		register("draining", health.Readiness, func(ctx context.Context) (string, error) {
+			if reporter.Draining() {
+				return "refusing new games", domain.ErrDraining
+			}
+			return "accepting new games", nil
+		})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to register health checks: %w", err)
+	}
+	return registry, nil
+}
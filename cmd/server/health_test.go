@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/health"
+	"github.com/siddarth/go-app/internal/repository/memory"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// fakeReporter reports fixed tick stats
+type fakeReporter struct {
+	draining bool
+	lag      time.Duration
+	stall    time.Duration
+}
+
+func (r fakeReporter) Draining() bool           { return r.draining }
+func (r fakeReporter) TickLag() time.Duration   { return r.lag }
+func (r fakeReporter) TickStall() time.Duration { return r.stall }
+
+// failing returns the names of the failing checks of a report
+func failing(report health.Report) []string {
+	var names []string
+	for _, res := range report.Checks {
+		if res.Status != health.StatusOK {
+			names = append(names, res.Name)
+		}
+	}
+	return names
+}
+
+func testHealthConfig() config.HealthConfig {
+	return config.HealthConfig{
+		CheckTimeout:  time.Second,
+		MaxTickLag:    100 * time.Millisecond,
+		MaxTickStall:  time.Second,
+		MaxGoroutines: 10000,
+	}
+}
+
+func TestHealthRegistryChecks(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		reporter      fakeReporter
+		wantLiveness  []string
+		wantReadiness []string
+	}{
+		{name: "healthy"},
+		{name: "lagging", reporter: fakeReporter{lag: time.Second}, wantReadiness: []string{"tick_lag"}},
+		{name: "draining", reporter: fakeReporter{draining: true}, wantReadiness: []string{"draining"}},
+		{name: "stalled", reporter: fakeReporter{stall: time.Minute}, wantLiveness: []string{"tick_progress"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry, err := newHealthRegistry(testHealthConfig(), tc.reporter)
+			if err != nil {
+				t.Fatalf("newHealthRegistry: %v", err)
+			}
+
+			ctx := context.Background()
+			if got := failing(registry.Run(ctx, health.Liveness)); !slices.Equal(got, tc.wantLiveness) {
+				t.Errorf("failing liveness checks: got %v, want %v", got, tc.wantLiveness)
+			}
+			if got := failing(registry.Run(ctx, health.Readiness)); !slices.Equal(got, tc.wantReadiness) {
+				t.Errorf("failing readiness checks: got %v, want %v", got, tc.wantReadiness)
+			}
+		})
+	}
+}
+
+func TestHealthRegistryWithoutReporter(t *testing.T) {
+	cfg := testHealthConfig()
+	cfg.MaxGoroutines = 1
+
+	registry, err := newHealthRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("newHealthRegistry: %v", err)
+	}
+	if got := failing(registry.Run(context.Background(), health.Readiness)); !slices.Equal(got, []string{"goroutines"}) {
+		t.Errorf("failing readiness checks: got %v, want [goroutines]", got)
+	}
+}
+
+func TestDrainingFailsReadiness(t *testing.T) {
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gameService := service.NewGameService(memory.NewGameRepository(0, 0), nil, cfg.Game, cfg.Scheduler, logger)
+	admin := gameService.(domain.GameAdmin)
+
+	registry, err := newHealthRegistry(cfg.Health, gameService.(service.HealthReporter))
+	if err != nil {
+		t.Fatalf("newHealthRegistry: %v", err)
+	}
+	ctx := context.Background()
+
+	admin.SetDraining(ctx, true)
+	if got := failing(registry.Run(ctx, health.Readiness)); !slices.Equal(got, []string{"draining"}) {
+		t.Errorf("failing readiness checks while draining: got %v, want [draining]", got)
+	}
+	if report := registry.Run(ctx, health.Liveness); !report.OK() {
+		t.Errorf("draining failed liveness: %+v", report)
+	}
+	if _, err := gameService.CreateGame(ctx, "a", domain.GameOptions{}); !errors.Is(err, domain.ErrDraining) {
+		t.Errorf("CreateGame while draining: got %v, want %v", err, domain.ErrDraining)
+	}
+
+	admin.SetDraining(ctx, false)
+	if report := registry.Run(ctx, health.Readiness); !report.OK() {
+		t.Errorf("readiness after the drain ended: %+v", report)
+	}
+}
+
+// drainRecorder records the draining states it is set to
+type drainRecorder struct {
+	domain.GameAdmin
+	states []bool
+}
+
+func (a *drainRecorder) SetDraining(ctx context.Context, draining bool) {
+	a.states = append(a.states, draining)
+}
+
+func TestWaitForDrain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	t.Run("waits out the delay", func(t *testing.T) {
+		admin := &drainRecorder{}
+		start := time.Now()
+		waitForDrain(ctx, admin, 50*time.Millisecond, make(chan os.Signal), logger)
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("returned after %s, before the delay", elapsed)
+		}
+		if !slices.Equal(admin.states, []bool{true}) {
+			t.Errorf("draining states: got %v, want [true]", admin.states)
+		}
+	})
+
+	t.Run("second signal skips the delay", func(t *testing.T) {
+		admin := &drainRecorder{}
+		signals := make(chan os.Signal, 1)
+		signals <- syscall.SIGTERM
+		start := time.Now()
+		waitForDrain(ctx, admin, time.Minute, signals, logger)
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("waited %s despite a second signal", elapsed)
+		}
+		if !slices.Equal(admin.states, []bool{true}) {
+			t.Errorf("draining states: got %v, want [true]", admin.states)
+		}
+	})
+
+	t.Run("no delay or admin", func(t *testing.T) {
+		waitForDrain(ctx, nil, 0, make(chan os.Signal), logger)
+	})
+}
@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/api/openapi"
 	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
 	httphandler "github.com/siddarth/go-app/internal/handler/http"
 	"github.com/siddarth/go-app/internal/middleware"
+	"github.com/siddarth/go-app/internal/repository/file"
 	"github.com/siddarth/go-app/internal/repository/memory"
 	"github.com/siddarth/go-app/internal/service"
 	"github.com/siddarth/go-app/pkg/observability"
@@ -20,22 +27,35 @@ import (
 
 func main() {
 	// Run application
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatalf("Application failed: %v", err)
 	}
 }
 
-func run() error {
+func run(args []string) error {
 	ctx := context.Background()
 
+	// Parse command-line flags
+	opts, err := config.ParseFlags("server", args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(opts)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if opts.PrintConfig {
+		return cfg.Print(os.Stdout)
+	}
+
 	// Initialize logger
-	logger := observability.NewLogger(cfg.Logging)
+	logger, logLevel := observability.NewLogger(cfg.Logging)
 	logger.Info("starting pacman game server",
 		"service", cfg.Observability.ServiceName,
 		"version", cfg.Observability.ServiceVersion,
@@ -54,22 +74,86 @@ func run() error {
 	}()
 
 	// Initialize dependencies
-	gameRepo := memory.NewGameRepository()
-	gameService := service.NewGameService(gameRepo, logger)
+	gameRepo := memory.NewGameRepository(cfg.Repository.Shards, cfg.Repository.GameTTL)
+	var checkpoints domain.CheckpointStore
+	if cfg.Server.CheckpointPath != "" {
+		checkpoints = file.NewCheckpointStore(cfg.Server.CheckpointPath)
+	}
+	gameService := service.NewGameService(gameRepo, checkpoints, cfg.Game, cfg.Scheduler, logger)
 	gameHandler := httphandler.NewGameHandler(gameService, logger)
+	roomRepo := memory.NewRoomRepository()
+	roomService := service.NewRoomService(roomRepo, gameService, cfg.Rooms, logger)
+	roomHandler := httphandler.NewRoomHandler(roomService, logger)
+
+	// Pick up the games checkpointed by the previous shutdown. A bad
+	// checkpoint is left in place for inspection rather than stopping
+	// the server.
+	lifecycle, _ := gameService.(service.Lifecycle)
+	if lifecycle != nil {
+		if err := lifecycle.Resume(ctx); err != nil {
+			logger.Error("failed to resume checkpointed games", "error", err)
+		}
+	}
+
+	// Register the checks behind the liveness and readiness probes
+	healthReporter, _ := gameService.(service.HealthReporter)
+	healthRegistry, err := newHealthRegistry(cfg.Health, healthReporter)
+	if err != nil {
+		return err
+	}
+
+	// Reset finished rooms, close idle ones and drop expired games in the
+	// background
+	cleanupCtx, stopCleanup := context.WithCancel(ctx)
+	defer stopCleanup()
+	if cleaner, ok := roomService.(service.RoomCleaner); ok {
+		go cleaner.RunCleanup(cleanupCtx)
+	}
+	expiryHandler, _ := gameService.(service.ExpiryHandler)
+	var onExpired func(*domain.Game)
+	if expiryHandler != nil {
+		onExpired = expiryHandler.GameExpired
+	}
+	go gameRepo.RunExpiry(cleanupCtx, onExpired)
+
+	// Load the API spec requests are validated against
+	apiSpec, err := openapi.Load()
+	if err != nil {
+		return err
+	}
+	requestValidator, err := middleware.NewRequestValidator(apiSpec, logger)
+	if err != nil {
+		return err
+	}
 
 	// Setup Gin router
 	gin.SetMode(cfg.Server.Mode)
 	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	// Register middleware
 	r.Use(middleware.Recovery(logger))
 	r.Use(middleware.Logging(logger))
-	r.Use(middleware.CORS())
+	r.Use(middleware.CORS(cfg.CORS))
 	r.Use(middleware.Tracing(cfg.Observability.ServiceName))
 
+	// Probes are registered ahead of rate limiting and request validation,
+	// which must never fail them
+	httphandler.NewHealthHandler(healthRegistry, logger).RegisterRoutes(r)
+
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, logger)
+	r.Use(rateLimiter.Handler())
+	r.Use(requestValidator.Handler())
+
 	// Register routes
 	gameHandler.RegisterRoutes(r)
+	roomHandler.RegisterRoutes(r)
+	httphandler.NewOpenAPIHandler(openapi.Spec).RegisterRoutes(r)
+
+	gameAdmin, _ := gameService.(domain.GameAdmin)
+	registerAdminAPI(r, cfg.Admin, gameService, logger)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -78,9 +162,12 @@ func run() error {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
+	// Ending room cleanup also ends room event streams, which would
+	// otherwise hold up a graceful shutdown
+	srv.RegisterOnShutdown(stopCleanup)
 
 	// Start server in goroutine
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, 2)
 	go func() {
 		logger.Info("server listening",
 			"port", cfg.Server.Port,
@@ -92,35 +179,114 @@ func run() error {
 		serverErrors <- srv.ListenAndServe()
 	}()
 
+	// Serve gRPC on its own port
+	var grpcSrv *grpcServer
+	if cfg.GRPC.Enabled {
+		grpcSrv, err = newGRPCServer(cfg, gameService, rateLimiter, logger)
+		if err != nil {
+			return err
+		}
+		go func() {
+			serverErrors <- grpcSrv.serve()
+		}()
+	}
+
 	// Listen for shutdown signals
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
+	// Reload safe settings on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	configUpdater, _ := gameService.(service.ConfigUpdater)
+	reloader := &reloader{
+		opts:        opts,
+		current:     cfg,
+		logger:      logger,
+		logLevel:    logLevel,
+		rateLimiter: rateLimiter,
+		gameService: configUpdater,
+	}
+
 	// Block until shutdown signal or server error
-	select {
-	case err := <-serverErrors:
-		if err != nil && err != http.ErrServerClosed {
-			return fmt.Errorf("server error: %w", err)
-		}
-	case sig := <-shutdown:
-		logger.Info("shutdown signal received", "signal", sig.String())
-
-		// Create shutdown context with timeout
-		shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
-		defer cancel()
-
-		// Attempt graceful shutdown
-		logger.Info("shutting down server gracefully")
-		if err := srv.Shutdown(shutdownCtx); err != nil {
-			// Force close if graceful shutdown fails
-			logger.Error("forcing server shutdown", "error", err)
-			if err := srv.Close(); err != nil {
-				return fmt.Errorf("failed to close server: %w", err)
+	for {
+		select {
+		case <-reload:
+			logger.Info("reload signal received")
+			reloader.reload()
+			continue
+		case err := <-serverErrors:
+			if err != nil && err != http.ErrServerClosed {
+				return fmt.Errorf("server error: %w", err)
+			}
+		case sig := <-shutdown:
+			logger.Info("shutdown signal received", "signal", sig.String())
+			waitForDrain(ctx, gameAdmin, cfg.Server.DrainDelay, shutdown, logger)
+
+			// Create shutdown context with timeout
+			shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+			defer cancel()
+
+			// Attempt graceful shutdown. Games are stopped and saved first,
+			// while clients can still see that the server is going away.
+			logger.Info("shutting down server gracefully")
+			if lifecycle != nil {
+				if err := lifecycle.Shutdown(shutdownCtx); err != nil {
+					logger.Error("failed to stop games cleanly", "error", err)
+				}
 			}
+			if grpcSrv != nil {
+				grpcSrv.shutdown(shutdownCtx)
+			}
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				// Force close if graceful shutdown fails
+				logger.Error("forcing server shutdown", "error", err)
+				if err := srv.Close(); err != nil {
+					return fmt.Errorf("failed to close server: %w", err)
+				}
+			}
+
+			logger.Info("server shutdown complete")
 		}
 
-		logger.Info("server shutdown complete")
+		return nil
+	}
+}
+
+// registerAdminAPI serves the admin API on r, but only when operators have
+// a token to use it and the game service has operator controls
+func registerAdminAPI(r *gin.Engine, cfg config.AdminConfig, gameService domain.GameService, logger *slog.Logger) {
+	gameAdmin, ok := gameService.(domain.GameAdmin)
+	switch {
+	case cfg.Token == "":
+		logger.Info("admin API disabled, set ADMIN_TOKEN to enable it")
+	case !ok:
+		logger.Warn("admin API unavailable, game service has no operator controls")
+	default:
+		httphandler.NewAdminHandler(gameAdmin, gameService, cfg.Token, logger).RegisterRoutes(r)
+	}
+}
+
+// waitForDrain fails the readiness probe by draining the server, then keeps
+// serving for delay so that load balancers stop routing to it before its
+// listeners close. admin may be nil when the game service cannot drain. A
+// second signal ends the wait early.
+func waitForDrain(ctx context.Context, admin domain.GameAdmin, delay time.Duration, signals <-chan os.Signal, logger *slog.Logger) {
+	if admin != nil {
+		admin.SetDraining(ctx, true)
+	}
+	if delay <= 0 {
+		return
 	}
 
-	return nil
+	logger.Info("draining before shutdown", "delay", delay.String())
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case sig := <-signals:
+		logger.Warn("second shutdown signal received, skipping drain delay", "signal", sig.String())
+	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/middleware"
+	"github.com/siddarth/go-app/internal/service"
+	"github.com/siddarth/go-app/pkg/observability"
+)
+
+// reloader re-reads configuration and applies the settings that are safe
+// to change while the server is running
+type reloader struct {
+	opts        *config.Options
+	current     *config.Config
+	logger      *slog.Logger
+	logLevel    *slog.LevelVar
+	rateLimiter *middleware.RateLimiter
+	gameService service.ConfigUpdater
+}
+
+// reload loads configuration again and applies reloadable changes. Invalid
+// configuration is logged and the running configuration is kept.
+func (r *reloader) reload() {
+	next, err := config.Load(r.opts)
+	if err != nil {
+		r.logger.Error("configuration reload failed, keeping current configuration", "error", err)
+		return
+	}
+
+	reloadable, restart := config.Changes(r.current, next)
+	if len(restart) > 0 {
+		r.logger.Warn("configuration changes require a restart and were ignored", "keys", restart)
+	}
+	if len(reloadable) == 0 {
+		r.logger.Info("configuration reloaded, no changes to apply")
+		return
+	}
+
+	r.logLevel.Set(observability.ParseLevel(next.Logging.Level))
+	r.rateLimiter.Update(next.RateLimit)
+	if r.gameService != nil {
+		r.gameService.UpdateConfig(next.Game)
+	}
+
+	// Only the applied sections move forward, so ignored keys are reported
+	// again on the next reload
+	r.current.Logging.Level = next.Logging.Level
+	r.current.RateLimit = next.RateLimit
+	r.current.Game = next.Game
+
+	r.logger.Info("configuration reloaded", "keys", reloadable)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/middleware"
+)
+
+// recordingUpdater records the game configuration it is given
+type recordingUpdater struct {
+	updates []config.GameConfig
+}
+
+func (u *recordingUpdater) UpdateConfig(cfg config.GameConfig) {
+	u.updates = append(u.updates, cfg)
+}
+
+func TestReloaderAppliesReloadableChanges(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("SERVER_PORT", "")
+	t.Setenv("RATE_LIMIT_ENABLED", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	writeConfig("rate_limit:\n  enabled: true\n")
+	opts := &config.Options{ConfigFile: path}
+	cfg, err := config.Load(opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var logs bytes.Buffer
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, logger)
+	updater := &recordingUpdater{}
+	r := &reloader{
+		opts:        opts,
+		current:     cfg,
+		logger:      logger,
+		logLevel:    logLevel,
+		rateLimiter: rateLimiter,
+		gameService: updater,
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(rateLimiter.Handler())
+	router.GET("/api/v1/games/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/games/a", nil))
+		return w.Code
+	}
+
+	writeConfig(`
+server:
+  port: "9999"
+logging:
+  level: debug
+rate_limit:
+  enabled: true
+  per_ip:
+    rate: 0.01
+    burst: 1
+game:
+  max_concurrent_games: 5
+`)
+	r.reload()
+
+	if logLevel.Level() != slog.LevelDebug {
+		t.Errorf("log level: got %s, want debug", logLevel.Level())
+	}
+	if get() != http.StatusOK || get() != http.StatusTooManyRequests {
+		t.Error("rate limiter did not pick up the new per-IP limit")
+	}
+	if len(updater.updates) != 1 || updater.updates[0].MaxConcurrentGames != 5 {
+		t.Errorf("game service updates: got %+v, want one with max 5 games", updater.updates)
+	}
+	// The port needs a restart, so it is reported and left alone
+	if cfg.Server.Port != "8080" || cfg.Game.MaxConcurrentGames != 5 {
+		t.Errorf("current configuration: got port %s and max games %d", cfg.Server.Port, cfg.Game.MaxConcurrentGames)
+	}
+	if !strings.Contains(logs.String(), "require a restart") || !strings.Contains(logs.String(), "server.port") {
+		t.Errorf("restart-only change was not reported:\n%s", logs.String())
+	}
+
+	// Invalid configuration keeps everything as it was
+	writeConfig("logging:\n  level: info\nrate_limit:\n  per_ip:\n    rate: -1\n")
+	r.reload()
+
+	if logLevel.Level() != slog.LevelDebug || cfg.Logging.Level != "debug" || len(updater.updates) != 1 {
+		t.Error("invalid configuration was applied")
+	}
+	if !strings.Contains(logs.String(), "configuration reload failed") {
+		t.Errorf("failed reload was not reported:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,286 @@
+// Command simulate plays many headless games with autopilot strategies and
+// reports how each rules preset plays, so difficulty can be tuned without
+// playing by hand
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/siddarth/go-app/internal/autopilot"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		log.Fatalf("Simulation failed: %v", err)
+	}
+}
+
+// options holds the command-line options of a simulation
+type options struct {
+	games      int
+	seed       int64
+	presets    []string
+	strategies []string
+	maze       string
+	players    int
+	maxTicks   int
+	workers    int
+	format     string
+	out        string
+	heatmap    string
+	config     config.Options
+}
+
+// scenario is one combination of rules and strategy to simulate
+type scenario struct {
+	preset   string
+	rules    domain.GameRules
+	strategy string
+}
+
+// job asks a worker to play game number index of a scenario
+type job struct {
+	scenario int
+	index    int
+}
+
+// outcome is the result of one simulated game
+type outcome struct {
+	scenario int
+	won      bool
+	lost     bool
+	score    int
+	// survival sums, over all players, the ticks each stayed in the game
+	survival int
+	deaths   []domain.Position
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	cfg, err := config.Load(&opts.config)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	scenarios, err := buildScenarios(cfg.Game, opts)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	rep := newReport(opts, scenarios)
+	for o := range simulate(scenarios, opts) {
+		rep.add(o)
+	}
+	fmt.Fprintf(stderr, "simulated %d games in %s on %d workers\n",
+		opts.games*len(scenarios), time.Since(started).Round(time.Millisecond), opts.workers)
+
+	w := stdout
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := rep.write(w, opts.format); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if opts.heatmap != "" {
+		f, err := os.Create(opts.heatmap)
+		if err != nil {
+			return fmt.Errorf("failed to create heatmap: %w", err)
+		}
+		defer f.Close()
+		if err := rep.writeHeatmapCSV(f); err != nil {
+			return fmt.Errorf("failed to write heatmap: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// parseFlags parses command-line arguments into options
+func parseFlags(args []string) (*options, error) {
+	opts := &options{config: config.Options{ConfigFile: os.Getenv("CONFIG_FILE")}}
+	var presets, strategies string
+
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.IntVar(&opts.games, "games", 1000, "games to play per preset and strategy")
+	fs.Int64Var(&opts.seed, "seed", 1, "seed of the first game; game i of every scenario uses seed+i")
+	fs.StringVar(&presets, "presets", "", "comma-separated rules presets (default: the configured default preset)")
+	fs.StringVar(&strategies, "strategies", autopilot.StrategyAvoid, "comma-separated autopilot strategies: "+strings.Join(autopilot.Names(), ", "))
+	fs.StringVar(&opts.maze, "maze", service.DefaultMaze, "maze to play on")
+	fs.IntVar(&opts.players, "players", 1, fmt.Sprintf("players per game, 1-%d, all using the same strategy", domain.MaxPlayers))
+	fs.IntVar(&opts.maxTicks, "max-ticks", 5000, "ticks after which an unfinished game counts as a timeout")
+	fs.IntVar(&opts.workers, "workers", runtime.NumCPU(), "games played in parallel")
+	fs.StringVar(&opts.format, "format", "json", "report format: json or csv")
+	fs.StringVar(&opts.out, "out", "", "write the report to this file instead of standard output")
+	fs.StringVar(&opts.heatmap, "heatmap", "", "also write death heatmaps to this CSV file")
+	fs.StringVar(&opts.config.ConfigFile, "config", opts.config.ConfigFile, "path to a YAML or TOML configuration file defining presets")
+	fs.Func("set", "override a setting as key=value, e.g. -set game.presets.hard.lives=2 (repeatable)", func(v string) error {
+		if !strings.Contains(v, "=") {
+			return fmt.Errorf("expected key=value, got %q", v)
+		}
+		opts.config.Overrides = append(opts.config.Overrides, v)
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	switch {
+	case opts.games < 1:
+		return nil, errors.New("games must be at least 1")
+	case opts.maxTicks < 1:
+		return nil, errors.New("max-ticks must be at least 1")
+	case opts.workers < 1:
+		return nil, errors.New("workers must be at least 1")
+	case opts.format != "json" && opts.format != "csv":
+		return nil, fmt.Errorf("unknown format %q, expected json or csv", opts.format)
+	}
+
+	opts.presets = splitList(presets)
+	opts.strategies = splitList(strategies)
+	return opts, nil
+}
+
+// buildScenarios pairs every requested preset with every requested strategy,
+// checking that games can be created for each
+func buildScenarios(cfg config.GameConfig, opts *options) ([]scenario, error) {
+	presets := opts.presets
+	if len(presets) == 0 {
+		presets = []string{cfg.DefaultPreset}
+	}
+	if len(opts.strategies) == 0 {
+		return nil, errors.New("at least one strategy is required")
+	}
+
+	var scenarios []scenario
+	for _, name := range presets {
+		rules, ok := cfg.Presets[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownPreset, name)
+		}
+		resolved := *rules
+		resolved.Preset = name
+
+		for _, strategy := range opts.strategies {
+			s := scenario{preset: name, rules: resolved, strategy: strategy}
+			if _, err := newGame(service.NewEngine(opts.seed), s, opts); err != nil {
+				return nil, err
+			}
+			scenarios = append(scenarios, s)
+		}
+	}
+	return scenarios, nil
+}
+
+// simulate plays opts.games games of every scenario on opts.workers
+// goroutines and streams their outcomes
+func simulate(scenarios []scenario, opts *options) <-chan outcome {
+	jobs := make(chan job)
+	outcomes := make(chan outcome, opts.workers)
+
+	go func() {
+		defer close(jobs)
+		for s := range scenarios {
+			for i := 0; i < opts.games; i++ {
+				jobs <- job{scenario: s, index: i}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				outcomes <- play(scenarios[j.scenario], j, opts)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	return outcomes
+}
+
+// play runs one game to the end or to the tick limit. Every game has its
+// own engine, so the outcome depends only on the seed and not on which
+// worker played it.
+func play(s scenario, j job, opts *options) outcome {
+	engine := service.NewEngine(opts.seed + int64(j.index))
+	// Scenarios were checked when they were built
+	game, _ := newGame(engine, s, opts)
+
+	o := outcome{scenario: j.scenario}
+	ticks := 0
+	for ticks < opts.maxTicks && !game.IsFinished() {
+		ticks++
+		for _, c := range engine.Advance(game) {
+			o.deaths = append(o.deaths, c.Position)
+			if c.LivesLeft <= 0 {
+				o.survival += ticks
+			}
+		}
+	}
+
+	for _, p := range game.Players {
+		if p.IsAlive() {
+			o.survival += ticks
+		}
+	}
+	o.won = game.DotsLeft() == 0
+	o.lost = game.GameOver
+	o.score = game.TotalScore()
+	return o
+}
+
+// newGame creates a game of scenario s with every player on autopilot
+func newGame(engine *service.Engine, s scenario, opts *options) (*domain.Game, error) {
+	roster := make([]domain.Player, opts.players)
+	for i := range roster {
+		roster[i].Autopilot = s.strategy
+	}
+	return engine.NewGame("simulation", s.rules, opts.maze, roster)
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
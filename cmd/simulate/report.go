@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// report summarizes the outcomes of a simulation
+type report struct {
+	Seed             int64             `json:"seed"`
+	GamesPerScenario int               `json:"gamesPerScenario"`
+	MaxTicks         int               `json:"maxTicks"`
+	Scenarios        []*scenarioReport `json:"scenarios"`
+}
+
+// scenarioReport summarizes the games of one scenario
+type scenarioReport struct {
+	Preset           string  `json:"preset"`
+	Strategy         string  `json:"strategy"`
+	Maze             string  `json:"maze"`
+	Players          int     `json:"players"`
+	Games            int     `json:"games"`
+	Wins             int     `json:"wins"`
+	Losses           int     `json:"losses"`
+	Timeouts         int     `json:"timeouts"`
+	WinRate          float64 `json:"winRate"`
+	AvgScore         float64 `json:"avgScore"`
+	AvgSurvivalTicks float64 `json:"avgSurvivalTicks"`
+	Deaths           int     `json:"deaths"`
+	// DeathHeatmap counts the deaths on every cell, indexed by row then
+	// column
+	DeathHeatmap [][]int `json:"deathHeatmap"`
+
+	totalScore    int
+	totalSurvival int
+}
+
+// newReport creates an empty report for scenarios
+func newReport(opts *options, scenarios []scenario) *report {
+	r := &report{
+		Seed:             opts.seed,
+		GamesPerScenario: opts.games,
+		MaxTicks:         opts.maxTicks,
+		Scenarios:        make([]*scenarioReport, len(scenarios)),
+	}
+	for i, s := range scenarios {
+		heatmap := make([][]int, service.GameHeight)
+		for y := range heatmap {
+			heatmap[y] = make([]int, service.GameWidth)
+		}
+		r.Scenarios[i] = &scenarioReport{
+			Preset:       s.preset,
+			Strategy:     s.strategy,
+			Maze:         opts.maze,
+			Players:      opts.players,
+			DeathHeatmap: heatmap,
+		}
+	}
+	return r
+}
+
+// add records the outcome of one game
+func (r *report) add(o outcome) {
+	s := r.Scenarios[o.scenario]
+	s.Games++
+	switch {
+	case o.won:
+		s.Wins++
+	case o.lost:
+		s.Losses++
+	default:
+		s.Timeouts++
+	}
+	s.totalScore += o.score
+	s.totalSurvival += o.survival
+	s.Deaths += len(o.deaths)
+	for _, pos := range o.deaths {
+		s.DeathHeatmap[pos.Y][pos.X]++
+	}
+
+	s.WinRate = float64(s.Wins) / float64(s.Games)
+	s.AvgScore = float64(s.totalScore) / float64(s.Games)
+	s.AvgSurvivalTicks = float64(s.totalSurvival) / float64(s.Games*s.Players)
+}
+
+// write writes the report in the given format. The CSV format has one row
+// per scenario and leaves out the heatmaps.
+func (r *report) write(w io.Writer, format string) error {
+	if format == "csv" {
+		return r.writeCSV(w)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeCSV writes one row per scenario
+func (r *report) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"preset", "strategy", "maze", "players", "games", "wins", "losses", "timeouts",
+		"win_rate", "avg_score", "avg_survival_ticks", "deaths",
+	})
+	for _, s := range r.Scenarios {
+		cw.Write([]string{
+			s.Preset,
+			s.Strategy,
+			s.Maze,
+			strconv.Itoa(s.Players),
+			strconv.Itoa(s.Games),
+			strconv.Itoa(s.Wins),
+			strconv.Itoa(s.Losses),
+			strconv.Itoa(s.Timeouts),
+			formatFloat(s.WinRate),
+			formatFloat(s.AvgScore),
+			formatFloat(s.AvgSurvivalTicks),
+			strconv.Itoa(s.Deaths),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeHeatmapCSV writes one row per scenario and cell where a player died
+func (r *report) writeHeatmapCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"preset", "strategy", "x", "y", "deaths"})
+	for _, s := range r.Scenarios {
+		for y, row := range s.DeathHeatmap {
+			for x, deaths := range row {
+				if deaths == 0 {
+					continue
+				}
+				cw.Write([]string{s.Preset, s.Strategy, strconv.Itoa(x), strconv.Itoa(y), strconv.Itoa(deaths)})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatFloat formats a rate or average for CSV output
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// requestTimeout bounds every request to the server
+const requestTimeout = 5 * time.Second
+
+// client talks to a game server over the /api/game endpoints. It plays as
+// one player, or only watches when it holds a spectator token.
+type client struct {
+	baseURL        string
+	http           *http.Client
+	sessionID      string
+	playerID       string
+	playerToken    string
+	spectatorToken string
+	viewerID       string
+	// watching clients only follow a game through its spectator token
+	watching bool
+}
+
+// sessionResponse is the part of start, join and restart responses the
+// client uses
+type sessionResponse struct {
+	SessionID      string           `json:"sessionId"`
+	PlayerID       string           `json:"playerId"`
+	PlayerToken    string           `json:"playerToken"`
+	SpectatorToken string           `json:"spectatorToken"`
+	State          domain.GameState `json:"state"`
+}
+
+// errorResponse is the error body returned by the server
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// newClient creates a client for the server at baseURL
+func newClient(baseURL string) (*client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", baseURL)
+	}
+
+	return &client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		http:     &http.Client{Timeout: requestTimeout},
+		viewerID: fmt.Sprintf("tui-%d", time.Now().UnixNano()),
+	}, nil
+}
+
+// start creates a new game hosted by the client
+func (c *client) start(ctx context.Context, sessionID string, opts startOptions) (*domain.GameState, error) {
+	opts.SessionID = sessionID
+	var resp sessionResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/games", opts, &resp); err != nil {
+		return nil, err
+	}
+	c.adopt(resp)
+	return &resp.State, nil
+}
+
+// join takes a seat in an existing multiplayer game
+func (c *client) join(ctx context.Context, sessionID, name string) (*domain.GameState, error) {
+	var resp sessionResponse
+	path := "/api/game/" + url.PathEscape(sessionID) + "/join"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"name": name}, &resp); err != nil {
+		return nil, err
+	}
+	c.adopt(resp)
+	return &resp.State, nil
+}
+
+// watch follows a game read-only through its spectator token
+func (c *client) watch(spectatorToken string) {
+	c.spectatorToken = spectatorToken
+	c.watching = true
+}
+
+// state fetches the current game state
+func (c *client) state(ctx context.Context) (*domain.GameState, error) {
+	var state domain.GameState
+	path := "/api/v1/games/" + url.PathEscape(c.sessionID)
+	if c.watching {
+		path = "/api/watch/" + url.PathEscape(c.spectatorToken)
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// move changes the direction of the client's player
+func (c *client) move(ctx context.Context, dir domain.Direction) error {
+	path := "/api/v1/games/" + url.PathEscape(c.sessionID) + "/moves"
+	return c.do(ctx, http.MethodPost, path, map[string]string{"direction": dir.String()}, nil)
+}
+
+// restart starts the game again with the same rules and players
+func (c *client) restart(ctx context.Context) (*domain.GameState, error) {
+	var resp sessionResponse
+	if err := c.do(ctx, http.MethodPost, "/api/game/restart", nil, &resp); err != nil {
+		return nil, err
+	}
+	c.adopt(resp)
+	return &resp.State, nil
+}
+
+// adopt keeps the session details of a start, join or restart response
+func (c *client) adopt(resp sessionResponse) {
+	c.sessionID = resp.SessionID
+	if resp.PlayerID != "" {
+		c.playerID = resp.PlayerID
+	}
+	if resp.PlayerToken != "" {
+		c.playerToken = resp.PlayerToken
+	}
+	if resp.SpectatorToken != "" {
+		c.spectatorToken = resp.SpectatorToken
+	}
+}
+
+// do sends a request with the client's session headers and decodes the
+// JSON response into out, turning error responses into errors
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.sessionID != "" {
+		req.Header.Set("X-Session-ID", c.sessionID)
+	}
+	if c.playerToken != "" {
+		req.Header.Set("X-Player-Token", c.playerToken)
+	}
+	req.Header.Set("X-Viewer-ID", c.viewerID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Message == "" {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, e.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
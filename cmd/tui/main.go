@@ -0,0 +1,266 @@
+// Command tui plays the game in a terminal against a local or remote server
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatalf("tui failed: %v", err)
+	}
+}
+
+// options holds the command-line options of the client
+type options struct {
+	server    string
+	sessionID string
+	join      string
+	watch     string
+	poll      time.Duration
+	start     startOptions
+}
+
+// startOptions are the settings of a game the client starts
+type startOptions struct {
+	SessionID  string `json:"sessionId,omitempty"`
+	Preset     string `json:"preset,omitempty"`
+	Maze       string `json:"maze,omitempty"`
+	MaxPlayers int    `json:"maxPlayers,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Versus     bool   `json:"versus,omitempty"`
+}
+
+// key is a key press the client acts on
+type key int
+
+const (
+	keyUp key = iota
+	keyDown
+	keyLeft
+	keyRight
+	keyRestart
+	keyQuit
+)
+
+func run(args []string) error {
+	opts, err := parseFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	c, err := newClient(opts.server)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Take over the terminal before creating a game that could not be
+	// played. Errors are printed after it is restored.
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	state, err := connect(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(hideCursor + clearScreen)
+	defer fmt.Print(showCursor + reset + "\r\n")
+
+	v := view{
+		server:    opts.server,
+		sessionID: c.sessionID,
+		playerID:  c.playerID,
+		watching:  c.watching,
+	}
+	if !c.watching && c.spectatorToken != "" {
+		v.message = "share read-only: -watch " + c.spectatorToken
+	}
+
+	keys := readKeys(os.Stdin)
+	ticker := time.NewTicker(opts.poll)
+	defer ticker.Stop()
+
+	render(os.Stdout, state, v)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok || k == keyQuit {
+				return nil
+			}
+			if c.watching {
+				continue
+			}
+			if k == keyRestart {
+				if s, err := c.restart(ctx); err != nil {
+					v.message = err.Error()
+				} else {
+					state = s
+				}
+			} else if err := c.move(ctx, directionFor(k)); err != nil {
+				v.message = err.Error()
+			}
+			render(os.Stdout, state, v)
+		case <-ticker.C:
+			s, err := c.state(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				v.message = err.Error()
+			} else {
+				state = s
+			}
+			render(os.Stdout, state, v)
+		}
+	}
+}
+
+// parseFlags parses command-line arguments into options
+func parseFlags(args []string) (*options, error) {
+	server := os.Getenv("PACMAN_URL")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	opts := &options{server: server}
+
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	fs.StringVar(&opts.server, "url", opts.server, "server URL, defaulting to the PACMAN_URL environment variable")
+	fs.StringVar(&opts.sessionID, "session", "", "session ID of the game to start (default: generated)")
+	fs.StringVar(&opts.join, "join", "", "join the multiplayer game with this session ID instead of starting one")
+	fs.StringVar(&opts.watch, "watch", "", "watch the game with this spectator token read-only")
+	fs.DurationVar(&opts.poll, "poll", 100*time.Millisecond, "how often to fetch the game state")
+	fs.StringVar(&opts.start.Preset, "preset", "", "rules preset of a new game")
+	fs.StringVar(&opts.start.Maze, "maze", "", "maze of a new game")
+	fs.IntVar(&opts.start.MaxPlayers, "players", 0, fmt.Sprintf("seats of a new game, 1-%d", domain.MaxPlayers))
+	fs.BoolVar(&opts.start.Versus, "versus", false, "let others claim the ghosts of a new game")
+	fs.StringVar(&opts.start.Name, "name", os.Getenv("USER"), "player name")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if opts.join != "" && opts.watch != "" {
+		return nil, errors.New("-join and -watch cannot be used together")
+	}
+	if opts.poll <= 0 {
+		return nil, errors.New("poll must be positive")
+	}
+	if opts.sessionID == "" {
+		opts.sessionID = fmt.Sprintf("tui-%d", time.Now().UnixNano())
+	}
+
+	return opts, nil
+}
+
+// connect starts, joins or watches a game as the options ask and returns
+// its first state
+func connect(ctx context.Context, c *client, opts *options) (*domain.GameState, error) {
+	switch {
+	case opts.watch != "":
+		c.watch(opts.watch)
+		return c.state(ctx)
+	case opts.join != "":
+		return c.join(ctx, opts.join, opts.start.Name)
+	default:
+		return c.start(ctx, opts.sessionID, opts.start)
+	}
+}
+
+// readKeys reads key presses from r until it fails, decoding the escape
+// sequences of the arrow keys
+func readKeys(r io.Reader) <-chan key {
+	keys := make(chan key)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, k := range decodeKeys(buf[:n]) {
+				keys <- k
+			}
+		}
+	}()
+	return keys
+}
+
+// decodeKeys decodes the keys in one read from a raw terminal. Arrow keys
+// arrive as ESC [ A-D, or ESC O A-D in application cursor mode.
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x1b && i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+			switch b[i+2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			case 'C':
+				keys = append(keys, keyRight)
+			case 'D':
+				keys = append(keys, keyLeft)
+			}
+			i += 2
+			continue
+		}
+
+		switch b[i] {
+		case 'w', 'W', 'k':
+			keys = append(keys, keyUp)
+		case 's', 'S', 'j':
+			keys = append(keys, keyDown)
+		case 'a', 'A', 'h':
+			keys = append(keys, keyLeft)
+		case 'd', 'D', 'l':
+			keys = append(keys, keyRight)
+		case 'r', 'R':
+			keys = append(keys, keyRestart)
+		// Raw mode turns Ctrl-C and Ctrl-D into plain bytes
+		case 'q', 'Q', 0x03, 0x04:
+			keys = append(keys, keyQuit)
+		}
+	}
+	return keys
+}
+
+// directionFor returns the direction a movement key steers
+func directionFor(k key) domain.Direction {
+	switch k {
+	case keyUp:
+		return domain.DirectionUp
+	case keyDown:
+		return domain.DirectionDown
+	case keyLeft:
+		return domain.DirectionLeft
+	default:
+		return domain.DirectionRight
+	}
+}
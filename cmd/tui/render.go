@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// ANSI escape sequences used to draw the screen
+const (
+	clearScreen = "\x1b[2J"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	reset       = "\x1b[0m"
+	bold        = "\x1b[1m"
+	dim         = "\x1b[2m"
+	wallStyle   = "\x1b[44m"
+	tunnelStyle = "\x1b[100m"
+	fruitStyle  = "\x1b[1;32m"
+	dotStyle    = "\x1b[33m"
+	ghostStyle  = "\x1b[1;31m"
+	humanGhost  = "\x1b[1;35m"
+)
+
+// playerStyles colors players by seat
+var playerStyles = []string{
+	"\x1b[1;93m",
+	"\x1b[1;96m",
+	"\x1b[1;92m",
+	"\x1b[1;95m",
+}
+
+// view is what the screen shows besides the game state
+type view struct {
+	server    string
+	sessionID string
+	playerID  string
+	watching  bool
+	message   string
+}
+
+// render draws the game state. Lines end in \r\n because the terminal is
+// in raw mode and does not translate newlines.
+func render(w io.Writer, state *domain.GameState, v view) {
+	var b strings.Builder
+	b.WriteString(cursorHome)
+
+	title := "PACMAN"
+	if v.watching {
+		title += " (watching)"
+	}
+	fmt.Fprintf(&b, "%s%s%s  %s%s%s%s\r\n", bold, title, reset, dim, v.server, reset, clearLine)
+	fmt.Fprintf(&b, "Score %d  Dots %d  Preset %s  Maze %s  Watching %d%s\r\n",
+		state.Score, state.DotsLeft, state.Preset, state.Maze, state.Spectators, clearLine)
+	if f := state.Fruit; f != nil {
+		fmt.Fprintf(&b, "%sBonus %s for %d, %d ticks left%s%s\r\n", fruitStyle, f.Type, f.Points, f.RemainingTicks, reset, clearLine)
+	} else {
+		b.WriteString(clearLine + "\r\n")
+	}
+	if state.Announcement != "" {
+		fmt.Fprintf(&b, "%s>> %s%s%s\r\n", bold, state.Announcement, reset, clearLine)
+	}
+
+	for y := 0; y < state.Board.Height(); y++ {
+		for x := 0; x < state.Board.Width(); x++ {
+			b.WriteString(cellAt(state, domain.Position{X: x, Y: y}))
+		}
+		b.WriteString(reset + clearLine + "\r\n")
+	}
+
+	for i, p := range state.Players {
+		name := p.Name
+		if name == "" {
+			name = p.ID
+		}
+		if p.ID == v.playerID {
+			name += " (you)"
+		}
+		if p.Autopilot != "" {
+			name += " [" + p.Autopilot + "]"
+		}
+		fmt.Fprintf(&b, "%s%s%s  score %d  lives %d%s\r\n",
+			playerStyles[i%len(playerStyles)], name, reset, p.Score, p.Lives, clearLine)
+	}
+
+	switch {
+	case state.Won:
+		fmt.Fprintf(&b, "%sYou win!%s%s\r\n", bold, reset, clearLine)
+	case state.GameOver:
+		fmt.Fprintf(&b, "%sGame over%s%s\r\n", bold, reset, clearLine)
+	default:
+		b.WriteString(clearLine + "\r\n")
+	}
+
+	if v.watching {
+		fmt.Fprintf(&b, "%sq quit%s%s\r\n", dim, reset, clearLine)
+	} else {
+		fmt.Fprintf(&b, "%sarrows/WASD move  r restart  q quit  session %s%s%s\r\n", dim, v.sessionID, reset, clearLine)
+	}
+	fmt.Fprintf(&b, "%s%s\r\n", v.message, clearLine)
+
+	// Clear anything left below from a longer previous frame
+	b.WriteString("\x1b[J")
+	io.WriteString(w, b.String())
+}
+
+// cellAt draws one board cell two columns wide, so the board looks square
+func cellAt(state *domain.GameState, pos domain.Position) string {
+	for i, p := range state.Players {
+		if p.Lives > 0 && p.Position.Equals(pos) {
+			return playerStyles[i%len(playerStyles)] + "C " + reset
+		}
+	}
+	for i, g := range state.Ghosts {
+		if g.Equals(pos) {
+			if i < len(state.GhostSeats) && state.GhostSeats[i].Human {
+				return humanGhost + "G " + reset
+			}
+			return ghostStyle + "G " + reset
+		}
+	}
+	if state.Fruit != nil && state.Fruit.Position.Equals(pos) {
+		return fruitStyle + "% " + reset
+	}
+
+	switch state.Board.Tile(pos) {
+	case domain.TileWall:
+		return wallStyle + "  " + reset
+	case domain.TileTunnel:
+		return tunnelStyle + "  " + reset
+	case domain.TileDot:
+		return dotStyle + "· " + reset
+	default:
+		return "  "
+	}
+}
@@ -0,0 +1,194 @@
+// Package autopilot provides strategies that steer players without human
+// input, for demo games, load tests, bots and simulations.
+package autopilot
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// Strategy names accepted by New
+const (
+	// StrategyGreedy heads for the nearest dot, ignoring ghosts
+	StrategyGreedy = "greedy"
+	// StrategyAvoid heads for the nearest dot it can reach without passing
+	// close to a ghost, and flees when there is none
+	StrategyAvoid = "avoid"
+	// StrategyRandom wanders the maze
+	StrategyRandom = "random"
+)
+
+// avoidRadius is how close, in steps, the avoid strategy lets ghosts come
+const avoidRadius = 2
+
+// directions lists the four movement directions in a fixed order so that
+// searches are deterministic
+var directions = []domain.Direction{
+	domain.DirectionUp,
+	domain.DirectionDown,
+	domain.DirectionLeft,
+	domain.DirectionRight,
+}
+
+// Names returns the names of the available strategies
+func Names() []string {
+	return []string{StrategyAvoid, StrategyGreedy, StrategyRandom}
+}
+
+// New returns the controller for the named strategy. Strategies that make
+// random choices draw from rng, which must not be used concurrently.
+func New(name string, rng *rand.Rand) (domain.PlayerController, error) {
+	switch name {
+	case StrategyGreedy:
+		return Greedy{}, nil
+	case StrategyAvoid:
+		return Avoid{Radius: avoidRadius}, nil
+	case StrategyRandom:
+		return &Random{rng: rng}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnknownStrategy, name)
+	}
+}
+
+// All returns a controller for every strategy, keyed by name
+func All(rng *rand.Rand) map[string]domain.PlayerController {
+	controllers := make(map[string]domain.PlayerController)
+	for _, name := range Names() {
+		controllers[name], _ = New(name, rng)
+	}
+	return controllers
+}
+
+// Greedy steers towards the nearest dot or bonus fruit by maze distance
+type Greedy struct{}
+
+// NextDirection implements domain.PlayerController
+func (Greedy) NextDirection(game *domain.Game, player *domain.Player) domain.Direction {
+	dir, _ := firstStep(game, player.Position, nil)
+	return dir
+}
+
+// Avoid steers towards the nearest dot or bonus fruit along a path that keeps more than
+// Radius steps away from every ghost
+type Avoid struct {
+	Radius int
+}
+
+// NextDirection implements domain.PlayerController
+func (a Avoid) NextDirection(game *domain.Game, player *domain.Player) domain.Direction {
+	danger := func(pos domain.Position) bool {
+		return ghostDistance(game, pos) <= a.Radius
+	}
+	if dir, ok := firstStep(game, player.Position, danger); ok {
+		return dir
+	}
+
+	// No safe dot is reachable, so get as far from the ghosts as possible
+	best, bestDistance := domain.DirectionNone, ghostDistance(game, player.Position)
+	for _, d := range directions {
+		next := game.Board.Next(player.Position, d)
+		if !walkable(game, next) {
+			continue
+		}
+		if dist := ghostDistance(game, next); dist > bestDistance {
+			best, bestDistance = d, dist
+		}
+	}
+	return best
+}
+
+// Random wanders the maze, turning at random and rarely reversing
+type Random struct {
+	rng *rand.Rand
+}
+
+// NextDirection implements domain.PlayerController
+func (r *Random) NextDirection(game *domain.Game, player *domain.Player) domain.Direction {
+	current := player.Direction
+	if current.IsValid() && walkable(game, game.Board.Next(player.Position, current)) && r.rng.Intn(4) != 0 {
+		return domain.DirectionNone
+	}
+
+	var options []domain.Direction
+	for _, d := range directions {
+		if d != reverse(current) && walkable(game, game.Board.Next(player.Position, d)) {
+			options = append(options, d)
+		}
+	}
+	if len(options) == 0 {
+		return reverse(current)
+	}
+	return options[r.rng.Intn(len(options))]
+}
+
+// firstStep searches breadth-first from start for the nearest dot or
+// bonus fruit, never entering positions for which blocked returns true,
+// and returns the first step of the shortest path to it
+func firstStep(game *domain.Game, start domain.Position, blocked func(domain.Position) bool) (domain.Direction, bool) {
+	type node struct {
+		pos   domain.Position
+		first domain.Direction
+	}
+
+	visited := map[domain.Position]bool{start: true}
+	queue := []node{{pos: start, first: domain.DirectionNone}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		for _, d := range directions {
+			next := game.Board.Next(n.pos, d)
+			if visited[next] || !walkable(game, next) || (blocked != nil && blocked(next)) {
+				continue
+			}
+			visited[next] = true
+
+			first := n.first
+			if first == domain.DirectionNone {
+				first = d
+			}
+			if game.Board.HasDot(next) || (game.Fruit != nil && game.Fruit.Position.Equals(next)) {
+				return first, true
+			}
+			queue = append(queue, node{pos: next, first: first})
+		}
+	}
+	return domain.DirectionNone, false
+}
+
+// walkable reports whether pos is on the board and not a wall
+func walkable(game *domain.Game, pos domain.Position) bool {
+	return game.IsValidPosition(pos)
+}
+
+// ghostDistance returns the Manhattan distance from pos to the nearest
+// ghost, through tunnels when that is shorter, or math.MaxInt when there
+// are no ghosts
+func ghostDistance(game *domain.Game, pos domain.Position) int {
+	nearest := math.MaxInt
+	for _, g := range game.Ghosts {
+		if d := game.Board.Distance(pos, g.Position); d < nearest {
+			nearest = d
+		}
+	}
+	return nearest
+}
+
+// reverse returns the opposite of d
+func reverse(d domain.Direction) domain.Direction {
+	switch d {
+	case domain.DirectionUp:
+		return domain.DirectionDown
+	case domain.DirectionDown:
+		return domain.DirectionUp
+	case domain.DirectionLeft:
+		return domain.DirectionRight
+	case domain.DirectionRight:
+		return domain.DirectionLeft
+	default:
+		return domain.DirectionNone
+	}
+}
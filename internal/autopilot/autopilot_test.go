@@ -0,0 +1,92 @@
+package autopilot
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// testMaze has a dot two steps away through a wall and another four steps
+// away around it, so the nearest dot by distance is not the nearest by path
+var testMaze = []string{
+	"######",
+	"#  #.#",
+	"#  # #",
+	"#    #",
+	"#.####",
+	"######",
+}
+
+// newGame returns a game on rows without ghosts
+func newGame(rows []string) *domain.Game {
+	return &domain.Game{Board: domain.NewBoard(rows, len(rows[0]), len(rows))}
+}
+
+func TestGreedyNeverWalksIntoWall(t *testing.T) {
+	game := newGame(testMaze)
+	board := &game.Board
+
+	for y := 0; y < board.Height(); y++ {
+		for x := 0; x < board.Width(); x++ {
+			pos := domain.Position{X: x, Y: y}
+			if board.IsWall(pos) {
+				continue
+			}
+			dir := Greedy{}.NextDirection(game, &domain.Player{Position: pos})
+			if !dir.IsValid() {
+				t.Errorf("%v: no direction with dots left", pos)
+				continue
+			}
+			if next := board.Next(pos, dir); board.IsWall(next) {
+				t.Errorf("%v: heads %v into a wall", pos, dir)
+			}
+		}
+	}
+}
+
+func TestGreedyReachesNearestDot(t *testing.T) {
+	game := newGame(testMaze)
+	player := &domain.Player{Position: domain.Position{X: 2, Y: 1}}
+
+	steps := 0
+	for !game.Board.HasDot(player.Position) {
+		if steps++; steps > 10 {
+			t.Fatalf("no dot reached after 10 steps, at %v", player.Position)
+		}
+		dir := Greedy{}.NextDirection(game, player)
+		next := game.Board.Next(player.Position, dir)
+		if game.Board.IsWall(next) {
+			t.Fatalf("step %d: heads %v from %v into a wall", steps, dir, player.Position)
+		}
+		player.Position = next
+	}
+
+	if want := (domain.Position{X: 1, Y: 4}); !player.Position.Equals(want) || steps != 4 {
+		t.Errorf("reached %v in %d steps, want %v in 4", player.Position, steps, want)
+	}
+}
+
+func TestGreedyWithoutDots(t *testing.T) {
+	game := newGame([]string{
+		"####",
+		"#  #",
+		"####",
+	})
+	if dir := (Greedy{}).NextDirection(game, &domain.Player{Position: domain.Position{X: 1, Y: 1}}); dir != domain.DirectionNone {
+		t.Errorf("got %v on a board without dots, want none", dir)
+	}
+}
+
+func TestNew(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, name := range Names() {
+		if _, err := New(name, rng); err != nil {
+			t.Errorf("New(%q): %v", name, err)
+		}
+	}
+	if _, err := New("teleport", rng); !errors.Is(err, domain.ErrUnknownStrategy) {
+		t.Errorf("New of an unknown strategy: got %v, want %v", err, domain.ErrUnknownStrategy)
+	}
+}
@@ -2,16 +2,27 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"strconv"
+	"net"
+	"strings"
 	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
 )
 
 // Config holds application configuration
 type Config struct {
-	Server      ServerConfig
-	Logging     LoggingConfig
+	Server        ServerConfig
+	Logging       LoggingConfig
 	Observability ObservabilityConfig
+	CORS          CORSConfig
+	RateLimit     RateLimitConfig
+	Game          GameConfig
+	Scheduler     SchedulerConfig
+	Repository    RepositoryConfig
+	Rooms         RoomsConfig
+	GRPC          GRPCConfig
+	Admin         AdminConfig
+	Health        HealthConfig
 }
 
 // ServerConfig holds server configuration
@@ -20,7 +31,18 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
-	Mode            string // "debug" or "release"
+	// DrainDelay is how long the server fails its readiness probe while
+	// still serving before it starts shutting down, so that load balancers
+	// see it leave first
+	DrainDelay time.Duration
+	Mode       string // "debug" or "release"
+	// TrustedProxies lists the IPs and CIDR ranges of reverse proxies whose
+	// X-Forwarded-For headers name the client. Without any, the client is
+	// the peer address, so that clients cannot pick their own rate limits.
+	TrustedProxies []string
+	// CheckpointPath is the file unfinished games are saved to on shutdown
+	// and resumed from on startup. Empty lets games end with the process.
+	CheckpointPath string
 }
 
 // LoggingConfig holds logging configuration
@@ -31,43 +53,186 @@ type LoggingConfig struct {
 
 // ObservabilityConfig holds observability configuration
 type ObservabilityConfig struct {
-	ServiceName    string
-	ServiceVersion string
-	Environment    string
-	TracingEnabled bool
+	ServiceName     string
+	ServiceVersion  string
+	Environment     string
+	TracingEnabled  bool
 	TracingEndpoint string
-	MetricsEnabled bool
+	MetricsEnabled  bool
+}
+
+// CORSConfig holds cross-origin resource sharing configuration. An empty
+// AllowedOrigins list disables cross-origin access entirely.
+type CORSConfig struct {
+	// AllowedOrigins may contain "*" for any origin or a single wildcard
+	// subdomain such as "https://*.example.com"
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// RateLimitRule describes a token bucket: Rate tokens are added per second
+// up to a maximum of Burst
+type RateLimitRule struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig holds rate limiting configuration
+type RateLimitConfig struct {
+	Enabled bool
+	PerIP   RateLimitRule
+	// PerSession limits requests to a session by the player token they
+	// present, or by client IP when they present none
+	PerSession RateLimitRule
+	// Routes holds stricter per-IP rules keyed by "METHOD /path"
+	Routes map[string]*RateLimitRule
+}
+
+// GameConfig holds game service configuration
+type GameConfig struct {
+	MaxConcurrentGames int
+	// DefaultPreset names the rules used when a client does not pick a preset
+	DefaultPreset string
+	// Presets holds the named rule sets clients can choose from
+	Presets map[string]*domain.GameRules
+}
+
+// SchedulerConfig holds configuration of the scheduler that runs the
+// ticks of every game loop
+type SchedulerConfig struct {
+	// Shards is the number of goroutines running game ticks; zero uses
+	// one per CPU
+	Shards int
+	// Resolution is the period of the clock shared by all game loops
+	Resolution time.Duration
+	// MaxCatchUp is the most ticks a game that fell behind runs at once;
+	// the ticks it missed beyond that are skipped
+	MaxCatchUp int
+}
+
+// RepositoryConfig holds configuration of the in-memory game repository
+type RepositoryConfig struct {
+	// Shards is the number of independently locked parts games are
+	// spread over
+	Shards int
+	// GameTTL drops games that have not been saved for this long, which
+	// running games are on every tick. Zero keeps games until deleted.
+	GameTTL time.Duration
 }
 
-// Load loads configuration from environment variables
-func Load() (*Config, error) {
-	config := &Config{
+// RoomsConfig holds matchmaking room configuration
+type RoomsConfig struct {
+	MaxRooms int
+	// Countdown is the delay between the host starting a room and the
+	// game loop starting
+	Countdown time.Duration
+	// IdleTimeout closes waiting rooms that have not changed for this long
+	IdleTimeout time.Duration
+}
+
+// GRPCConfig holds configuration of the gRPC server, which listens on its
+// own port next to the HTTP server
+type GRPCConfig struct {
+	Enabled bool
+	Port    string
+	// WatchInterval is how often WatchState streams check for a new state
+	WatchInterval time.Duration
+}
+
+// AdminConfig holds configuration of the operator API under /admin, which
+// is disabled while Token is empty
+type AdminConfig struct {
+	// Token is the bearer token operators authenticate with
+	Token string
+}
+
+// HealthConfig holds the thresholds of the liveness and readiness checks
+type HealthConfig struct {
+	// CheckTimeout fails checks that take longer
+	CheckTimeout time.Duration
+	// MaxTickLag is how far game ticks may fall behind schedule before the
+	// server stops being ready
+	MaxTickLag time.Duration
+	// MaxTickStall is how long game ticks may make no progress at all
+	// before the server is considered stuck and restarted
+	MaxTickStall time.Duration
+	// MaxGoroutines is the goroutine count above which the server is
+	// considered overloaded and no longer ready
+	MaxGoroutines int
+}
+
+// minAdminTokenLength is the shortest admin token accepted, so that the
+// token cannot be guessed
+const minAdminTokenLength = 16
+
+// defaultConfig returns the built-in configuration that files, environment
+// variables and flags are layered on top of
+func defaultConfig() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Port:            getEnv("PORT", "8080"),
-			ReadTimeout:     getDurationEnv("READ_TIMEOUT", 30*time.Second),
-			WriteTimeout:    getDurationEnv("WRITE_TIMEOUT", 30*time.Second),
-			ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 10*time.Second),
-			Mode:            getEnv("GIN_MODE", "release"),
+			Port:            "8080",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			DrainDelay:      5 * time.Second,
+			Mode:            "release",
 		},
 		Logging: LoggingConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Level:  "info",
+			Format: "json",
 		},
 		Observability: ObservabilityConfig{
-			ServiceName:     getEnv("SERVICE_NAME", "pacman-game"),
-			ServiceVersion:  getEnv("SERVICE_VERSION", "1.0.0"),
-			Environment:     getEnv("ENVIRONMENT", "development"),
-			TracingEnabled:  getBoolEnv("TRACING_ENABLED", true),
-			TracingEndpoint: getEnv("TRACING_ENDPOINT", ""),
-			MetricsEnabled:  getBoolEnv("METRICS_ENABLED", true),
+			ServiceName:    "pacman-game",
+			ServiceVersion: "1.0.0",
+			Environment:    "production",
+			TracingEnabled: true,
+			MetricsEnabled: true,
+		},
+		CORS: defaultCORSConfig(),
+		RateLimit: RateLimitConfig{
+			Enabled:    true,
+			PerIP:      RateLimitRule{Rate: 20, Burst: 40},
+			PerSession: RateLimitRule{Rate: 15, Burst: 30},
+			Routes: map[string]*RateLimitRule{
+				"POST /api/game/start":       {Rate: 0.2, Burst: 5},
+				"POST /api/v1/games":         {Rate: 0.2, Burst: 5},
+				"POST /api/game/batch/start": {Rate: 0.05, Burst: 2},
+			},
+		},
+		Game: GameConfig{
+			MaxConcurrentGames: 1000,
+			DefaultPreset:      "normal",
+			Presets:            defaultPresets(),
+		},
+		Scheduler: SchedulerConfig{
+			Resolution: 5 * time.Millisecond,
+			MaxCatchUp: 3,
+		},
+		Repository: RepositoryConfig{
+			Shards:  64,
+			GameTTL: time.Hour,
+		},
+		Rooms: RoomsConfig{
+			MaxRooms:    200,
+			Countdown:   3 * time.Second,
+			IdleTimeout: 10 * time.Minute,
+		},
+		GRPC: GRPCConfig{
+			Enabled:       true,
+			Port:          "9090",
+			WatchInterval: 100 * time.Millisecond,
+		},
+		Health: HealthConfig{
+			CheckTimeout:  time.Second,
+			MaxTickLag:    500 * time.Millisecond,
+			MaxTickStall:  30 * time.Second,
+			MaxGoroutines: 10000,
 		},
 	}
-
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
-
-	return config, nil
 }
 
 // Validate validates the configuration
@@ -88,38 +253,214 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server mode: %s", c.Server.Mode)
 	}
 
+	if err := c.CORS.Validate(); err != nil {
+		return fmt.Errorf("invalid CORS configuration: %w", err)
+	}
+
+	if c.RateLimit.Enabled {
+		if err := c.RateLimit.PerIP.validate("per-IP"); err != nil {
+			return err
+		}
+		if err := c.RateLimit.PerSession.validate("per-session"); err != nil {
+			return err
+		}
+		for route, rule := range c.RateLimit.Routes {
+			if rule == nil {
+				return fmt.Errorf("missing rate limit rule for %s", route)
+			}
+			if err := rule.validate(route); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.Game.MaxConcurrentGames <= 0 {
+		return fmt.Errorf("max concurrent games must be positive: %d", c.Game.MaxConcurrentGames)
+	}
+
+	if _, ok := c.Game.Presets[c.Game.DefaultPreset]; !ok {
+		return fmt.Errorf("default game preset %q is not defined", c.Game.DefaultPreset)
+	}
+
+	if c.Scheduler.Shards < 0 {
+		return fmt.Errorf("scheduler shards cannot be negative: %d", c.Scheduler.Shards)
+	}
+
+	if c.Scheduler.Resolution <= 0 {
+		return fmt.Errorf("scheduler resolution must be positive: %s", c.Scheduler.Resolution)
+	}
+
+	if c.Scheduler.MaxCatchUp <= 0 {
+		return fmt.Errorf("scheduler max catch-up must be positive: %d", c.Scheduler.MaxCatchUp)
+	}
+
+	if c.Repository.Shards <= 0 {
+		return fmt.Errorf("repository shards must be positive: %d", c.Repository.Shards)
+	}
+
+	if c.Repository.GameTTL < 0 {
+		return fmt.Errorf("game TTL cannot be negative: %s", c.Repository.GameTTL)
+	}
+
+	if c.Rooms.MaxRooms <= 0 {
+		return fmt.Errorf("max rooms must be positive: %d", c.Rooms.MaxRooms)
+	}
+
+	if c.Rooms.Countdown < 0 {
+		return fmt.Errorf("room countdown cannot be negative: %s", c.Rooms.Countdown)
+	}
+
+	if c.Rooms.IdleTimeout <= 0 {
+		return fmt.Errorf("room idle timeout must be positive: %s", c.Rooms.IdleTimeout)
+	}
+
+	if c.GRPC.Enabled {
+		if c.GRPC.Port == "" {
+			return fmt.Errorf("grpc port is required when grpc is enabled")
+		}
+		if c.GRPC.Port == c.Server.Port {
+			return fmt.Errorf("grpc port must differ from server port: %s", c.GRPC.Port)
+		}
+		if c.GRPC.WatchInterval <= 0 {
+			return fmt.Errorf("grpc watch interval must be positive: %s", c.GRPC.WatchInterval)
+		}
+	}
+
+	if c.Admin.Token != "" && len(c.Admin.Token) < minAdminTokenLength {
+		return fmt.Errorf("admin token must be at least %d characters", minAdminTokenLength)
+	}
+
+	if c.Server.DrainDelay < 0 {
+		return fmt.Errorf("drain delay must not be negative: %s", c.Server.DrainDelay)
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		return fmt.Errorf("health check timeout must be positive: %s", c.Health.CheckTimeout)
+	}
+
+	if c.Health.MaxTickLag <= 0 {
+		return fmt.Errorf("health max tick lag must be positive: %s", c.Health.MaxTickLag)
+	}
+
+	if c.Health.MaxTickStall <= 0 {
+		return fmt.Errorf("health max tick stall must be positive: %s", c.Health.MaxTickStall)
+	}
+
+	if c.Health.MaxGoroutines <= 0 {
+		return fmt.Errorf("health max goroutines must be positive: %d", c.Health.MaxGoroutines)
+	}
+
+	for name, rules := range c.Game.Presets {
+		if rules == nil {
+			return fmt.Errorf("missing rules for game preset %s", name)
+		}
+		if err := rules.Validate(); err != nil {
+			return fmt.Errorf("invalid game preset %s: %w", name, err)
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("trusted proxy must be an IP address or CIDR range: %s", proxy)
+			}
+		}
+	}
+
 	return nil
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// defaultPresets returns the built-in game rule presets
+func defaultPresets() map[string]*domain.GameRules {
+	return map[string]*domain.GameRules{
+		"easy": {
+			TickInterval:    250 * time.Millisecond,
+			ScorePerDot:     10,
+			GhostCount:      2,
+			GhostAggression: 50,
+			Lives:           3,
+			ScorePerCatch:   100,
+			Level:           1,
+			FruitDots:       []int{50, 115},
+			FruitTicks:      40,
+		},
+		"normal": {
+			TickInterval:    200 * time.Millisecond,
+			ScorePerDot:     10,
+			GhostCount:      3,
+			GhostAggression: 70,
+			Lives:           1,
+			ScorePerCatch:   200,
+			Level:           3,
+			FruitDots:       []int{50, 115},
+			FruitTicks:      50,
+		},
+		"hard": {
+			TickInterval:    150 * time.Millisecond,
+			ScorePerDot:     20,
+			GhostCount:      4,
+			GhostAggression: 85,
+			Lives:           1,
+			ScorePerCatch:   300,
+			Level:           5,
+			FruitDots:       []int{50, 115},
+			FruitTicks:      50,
+		},
 	}
-	return defaultValue
 }
 
-// getBoolEnv gets a boolean environment variable or returns a default value
-func getBoolEnv(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		boolVal, err := strconv.ParseBool(value)
-		if err != nil {
-			return defaultValue
-		}
-		return boolVal
+// defaultCORSConfig returns the CORS defaults. Allowed origins depend on the
+// environment and are filled in by defaultCORSOrigins once it is known.
+func defaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Session-ID", "X-Player-Token", "X-Viewer-ID", "X-Member-Token", "X-Client-ID", "X-Requested-With", "If-None-Match"},
+		ExposedHeaders: []string{"Content-Length", "Retry-After", "ETag", "Location", "Deprecation", "Link"},
+		MaxAge:         12 * time.Hour,
 	}
-	return defaultValue
 }
 
-// getDurationEnv gets a duration environment variable or returns a default value
-func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		duration, err := time.ParseDuration(value)
-		if err != nil {
-			return defaultValue
+// defaultCORSOrigins returns the allowed origins used when none are
+// configured. Development accepts any origin without credentials; every
+// other environment only serves same-origin requests.
+func defaultCORSOrigins(environment string) []string {
+	if environment == "development" {
+		return []string{"*"}
+	}
+	return nil
+}
+
+// Validate validates the CORS configuration
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("wildcard origin cannot be combined with credentials")
+			}
+			continue
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" {
+			return fmt.Errorf("origin must be \"*\" or start with http:// or https://: %s", origin)
 		}
-		return duration
+
+		if strings.Contains(host, "*") && (!strings.HasPrefix(host, "*.") || strings.Count(host, "*") > 1) {
+			return fmt.Errorf("wildcard is only allowed as the leftmost subdomain: %s", origin)
+		}
+	}
+
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age cannot be negative: %s", c.MaxAge)
 	}
-	return defaultValue
+
+	return nil
 }
 
+// validate checks that a rate limit rule admits at least one request
+func (r RateLimitRule) validate(name string) error {
+	if r.Rate <= 0 || r.Burst <= 0 {
+		return fmt.Errorf("invalid %s rate limit: rate and burst must be positive", name)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		origins     []string
+		credentials bool
+		maxAge      time.Duration
+		wantErr     string
+	}{
+		{name: "no origins"},
+		{name: "wildcard", origins: []string{"*"}},
+		{name: "wildcard with credentials", origins: []string{"*"}, credentials: true, wantErr: "wildcard origin cannot be combined with credentials"},
+		{name: "exact origins with credentials", origins: []string{"https://app.example.com", "http://localhost:3000"}, credentials: true},
+		{name: "wildcard subdomain", origins: []string{"https://*.example.com"}},
+		{name: "wildcard subdomain with credentials", origins: []string{"https://*.example.com"}, credentials: true},
+		{name: "missing scheme", origins: []string{"app.example.com"}, wantErr: "must be \"*\" or start with http:// or https://"},
+		{name: "other scheme", origins: []string{"ftp://example.com"}, wantErr: "must be \"*\" or start with http:// or https://"},
+		{name: "missing host", origins: []string{"https://"}, wantErr: "must be \"*\" or start with http:// or https://"},
+		{name: "wildcard inside host", origins: []string{"https://app.*.example.com"}, wantErr: "leftmost subdomain"},
+		{name: "wildcard without dot", origins: []string{"https://*example.com"}, wantErr: "leftmost subdomain"},
+		{name: "two wildcards", origins: []string{"https://*.*.example.com"}, wantErr: "leftmost subdomain"},
+		{name: "negative max age", maxAge: -time.Second, wantErr: "max age cannot be negative"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := CORSConfig{AllowedOrigins: tc.origins, AllowCredentials: tc.credentials, MaxAge: tc.maxAge}
+			err := c.Validate()
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultCORSOrigins(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+
+	for _, tc := range []struct {
+		name        string
+		environment string
+		overrides   []string
+		want        []string
+	}{
+		{name: "unset environment", want: nil},
+		{name: "production", environment: "production", want: nil},
+		{name: "staging", environment: "staging", want: nil},
+		{name: "development", environment: "development", want: []string{"*"}},
+		{
+			name:        "configured origins win in development",
+			environment: "development",
+			overrides:   []string{"cors.allowed_origins=https://app.example.com"},
+			want:        []string{"https://app.example.com"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tc.environment)
+
+			cfg, err := Load(&Options{Overrides: tc.overrides})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !slices.Equal(cfg.CORS.AllowedOrigins, tc.want) {
+				t.Errorf("allowed origins: got %q, want %q", cfg.CORS.AllowedOrigins, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// routesKey is the configuration key prefix for per-route rate limits
+	routesKey = "rate_limit.routes."
+	// presetsKey is the configuration key prefix for game rule presets
+	presetsKey = "game.presets."
+)
+
+// routeEnvPrefixes names the environment variables for built-in route limits
+var routeEnvPrefixes = map[string]string{
+	"POST /api/game/start":       "RATE_LIMIT_START",
+	"POST /api/v1/games":         "RATE_LIMIT_CREATE",
+	"POST /api/game/batch/start": "RATE_LIMIT_BATCH_START",
+}
+
+// Options holds the command-line options that control configuration loading
+type Options struct {
+	// ConfigFile is an optional YAML or TOML file layered under the environment
+	ConfigFile string
+	// PrintConfig requests that the effective configuration be printed
+	PrintConfig bool
+	// Overrides are key=value settings from the command line, applied last
+	Overrides []string
+}
+
+// ParseFlags parses command-line arguments into Options. The config file
+// defaults to the CONFIG_FILE environment variable.
+func ParseFlags(name string, args []string) (*Options, error) {
+	opts := &Options{ConfigFile: os.Getenv("CONFIG_FILE")}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config", opts.ConfigFile, "path to a YAML or TOML configuration file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration and exit")
+	fs.Func("set", "override a setting as key=value, e.g. -set server.port=9000 (repeatable)", func(v string) error {
+		if !strings.Contains(v, "=") {
+			return fmt.Errorf("expected key=value, got %q", v)
+		}
+		opts.Overrides = append(opts.Overrides, v)
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	return opts, nil
+}
+
+// Load builds the configuration from built-in defaults, the optional config
+// file, environment variables and command-line overrides, in increasing
+// order of precedence. Malformed values are reported as errors rather than
+// replaced by defaults.
+func Load(opts *Options) (*Config, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	config := defaultConfig()
+	set := make(map[string]bool)
+
+	if opts.ConfigFile != "" {
+		values, err := readConfigFile(opts.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.apply(values, opts.ConfigFile, set); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range config.fields() {
+		for _, name := range f.env {
+			value := os.Getenv(name)
+			if value == "" {
+				continue
+			}
+			if err := f.set(value); err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			set[f.key] = true
+			break
+		}
+	}
+
+	overrides := make(map[string]any, len(opts.Overrides))
+	for _, o := range opts.Overrides {
+		key, value, _ := strings.Cut(o, "=")
+		overrides[strings.TrimSpace(key)] = value
+	}
+	if err := config.apply(overrides, "command line", set); err != nil {
+		return nil, err
+	}
+
+	if !set["cors.allowed_origins"] {
+		config.CORS.AllowedOrigins = defaultCORSOrigins(config.Observability.Environment)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// Print writes the configuration to w as YAML, in the same layout accepted
+// by configuration files
+func (c *Config) Print(w io.Writer) error {
+	root := make(map[string]any)
+	for _, f := range c.fields() {
+		parts := strings.Split(f.key, ".")
+		if route, ok := mapEntryName(f.key, routesKey); ok {
+			parts = []string{"rate_limit", "routes", route, f.key[strings.LastIndex(f.key, ".")+1:]}
+		}
+
+		node := root
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[part] = child
+			}
+			node = child
+		}
+		value := f.value()
+		if secretKeys[f.key] && value != "" {
+			value = redacted
+		}
+		node[parts[len(parts)-1]] = value
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+// Changes compares two configurations and returns the keys whose values
+// differ, split into those that can be applied to a running server and
+// those that only take effect after a restart
+func Changes(old, next *Config) (reloadable, restart []string) {
+	values := func(c *Config) map[string]string {
+		m := make(map[string]string)
+		for _, f := range c.fields() {
+			m[f.key] = fmt.Sprint(f.value())
+		}
+		return m
+	}
+
+	oldValues, nextValues := values(old), values(next)
+	keys := make(map[string]bool)
+	for k := range oldValues {
+		keys[k] = true
+	}
+	for k := range nextValues {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		if oldValues[k] == nextValues[k] {
+			continue
+		}
+		if IsReloadable(k) {
+			reloadable = append(reloadable, k)
+		} else {
+			restart = append(restart, k)
+		}
+	}
+
+	sort.Strings(reloadable)
+	sort.Strings(restart)
+	return reloadable, restart
+}
+
+// IsReloadable reports whether a configuration key is safe to change
+// without restarting the server
+func IsReloadable(key string) bool {
+	return key == "logging.level" ||
+		strings.HasPrefix(key, "rate_limit.") ||
+		strings.HasPrefix(key, "game.")
+}
+
+// secretKeys lists the settings whose values Print hides
+var secretKeys = map[string]bool{
+	"admin.token": true,
+}
+
+// redacted replaces secret values in printed configuration
+const redacted = "REDACTED"
+
+// field binds a configuration key and its environment variables to a value
+type field struct {
+	key string
+	env []string
+	ptr any
+}
+
+// fields lists every configurable setting of c
+func (c *Config) fields() []field {
+	fields := []field{
+		{"server.port", []string{"SERVER_PORT", "PORT"}, &c.Server.Port},
+		{"server.mode", []string{"SERVER_MODE", "GIN_MODE"}, &c.Server.Mode},
+		{"server.read_timeout", []string{"READ_TIMEOUT"}, &c.Server.ReadTimeout},
+		{"server.write_timeout", []string{"WRITE_TIMEOUT"}, &c.Server.WriteTimeout},
+		{"server.shutdown_timeout", []string{"SHUTDOWN_TIMEOUT"}, &c.Server.ShutdownTimeout},
+		{"server.drain_delay", []string{"SHUTDOWN_DRAIN_DELAY"}, &c.Server.DrainDelay},
+		{"server.trusted_proxies", []string{"TRUSTED_PROXIES"}, &c.Server.TrustedProxies},
+		{"server.checkpoint_path", []string{"CHECKPOINT_PATH"}, &c.Server.CheckpointPath},
+		{"logging.level", []string{"LOG_LEVEL"}, &c.Logging.Level},
+		{"logging.format", []string{"LOG_FORMAT"}, &c.Logging.Format},
+		{"observability.service_name", []string{"SERVICE_NAME"}, &c.Observability.ServiceName},
+		{"observability.service_version", []string{"SERVICE_VERSION"}, &c.Observability.ServiceVersion},
+		{"observability.environment", []string{"ENVIRONMENT"}, &c.Observability.Environment},
+		{"observability.tracing_enabled", []string{"TRACING_ENABLED"}, &c.Observability.TracingEnabled},
+		{"observability.tracing_endpoint", []string{"TRACING_ENDPOINT"}, &c.Observability.TracingEndpoint},
+		{"observability.metrics_enabled", []string{"METRICS_ENABLED"}, &c.Observability.MetricsEnabled},
+		{"cors.allowed_origins", []string{"CORS_ALLOWED_ORIGINS"}, &c.CORS.AllowedOrigins},
+		{"cors.allowed_methods", []string{"CORS_ALLOWED_METHODS"}, &c.CORS.AllowedMethods},
+		{"cors.allowed_headers", []string{"CORS_ALLOWED_HEADERS"}, &c.CORS.AllowedHeaders},
+		{"cors.exposed_headers", []string{"CORS_EXPOSED_HEADERS"}, &c.CORS.ExposedHeaders},
+		{"cors.allow_credentials", []string{"CORS_ALLOW_CREDENTIALS"}, &c.CORS.AllowCredentials},
+		{"cors.max_age", []string{"CORS_MAX_AGE"}, &c.CORS.MaxAge},
+		{"rate_limit.enabled", []string{"RATE_LIMIT_ENABLED"}, &c.RateLimit.Enabled},
+		{"rate_limit.per_ip.rate", []string{"RATE_LIMIT_IP_RPS"}, &c.RateLimit.PerIP.Rate},
+		{"rate_limit.per_ip.burst", []string{"RATE_LIMIT_IP_BURST"}, &c.RateLimit.PerIP.Burst},
+		{"rate_limit.per_session.rate", []string{"RATE_LIMIT_SESSION_RPS"}, &c.RateLimit.PerSession.Rate},
+		{"rate_limit.per_session.burst", []string{"RATE_LIMIT_SESSION_BURST"}, &c.RateLimit.PerSession.Burst},
+		{"game.max_concurrent_games", []string{"MAX_CONCURRENT_GAMES"}, &c.Game.MaxConcurrentGames},
+		{"game.default_preset", []string{"GAME_PRESET"}, &c.Game.DefaultPreset},
+		{"scheduler.shards", []string{"SCHEDULER_SHARDS"}, &c.Scheduler.Shards},
+		{"scheduler.resolution", []string{"SCHEDULER_RESOLUTION"}, &c.Scheduler.Resolution},
+		{"scheduler.max_catch_up", []string{"SCHEDULER_MAX_CATCH_UP"}, &c.Scheduler.MaxCatchUp},
+		{"repository.shards", []string{"REPOSITORY_SHARDS"}, &c.Repository.Shards},
+		{"repository.game_ttl", []string{"GAME_TTL"}, &c.Repository.GameTTL},
+		{"rooms.max_rooms", []string{"MAX_ROOMS"}, &c.Rooms.MaxRooms},
+		{"rooms.countdown", []string{"ROOM_COUNTDOWN"}, &c.Rooms.Countdown},
+		{"rooms.idle_timeout", []string{"ROOM_IDLE_TIMEOUT"}, &c.Rooms.IdleTimeout},
+		{"grpc.enabled", []string{"GRPC_ENABLED"}, &c.GRPC.Enabled},
+		{"grpc.port", []string{"GRPC_PORT"}, &c.GRPC.Port},
+		{"grpc.watch_interval", []string{"GRPC_WATCH_INTERVAL"}, &c.GRPC.WatchInterval},
+		{"admin.token", []string{"ADMIN_TOKEN"}, &c.Admin.Token},
+		{"health.check_timeout", []string{"HEALTH_CHECK_TIMEOUT"}, &c.Health.CheckTimeout},
+		{"health.max_tick_lag", []string{"HEALTH_MAX_TICK_LAG"}, &c.Health.MaxTickLag},
+		{"health.max_tick_stall", []string{"HEALTH_MAX_TICK_STALL"}, &c.Health.MaxTickStall},
+		{"health.max_goroutines", []string{"HEALTH_MAX_GOROUTINES"}, &c.Health.MaxGoroutines},
+	}
+
+	for _, route := range sortedKeys(c.RateLimit.Routes) {
+		rule := c.RateLimit.Routes[route]
+		var rateEnv, burstEnv []string
+		if prefix, ok := routeEnvPrefixes[route]; ok {
+			rateEnv, burstEnv = []string{prefix + "_RPS"}, []string{prefix + "_BURST"}
+		}
+		fields = append(fields,
+			field{routesKey + route + ".rate", rateEnv, &rule.Rate},
+			field{routesKey + route + ".burst", burstEnv, &rule.Burst},
+		)
+	}
+
+	for _, name := range sortedKeys(c.Game.Presets) {
+		rules := c.Game.Presets[name]
+		prefix := presetsKey + name + "."
+		fields = append(fields,
+			field{prefix + "tick_interval", nil, &rules.TickInterval},
+			field{prefix + "score_per_dot", nil, &rules.ScorePerDot},
+			field{prefix + "ghost_count", nil, &rules.GhostCount},
+			field{prefix + "ghost_aggression", nil, &rules.GhostAggression},
+			field{prefix + "lives", nil, &rules.Lives},
+			field{prefix + "score_per_catch", nil, &rules.ScorePerCatch},
+			field{prefix + "level", nil, &rules.Level},
+			field{prefix + "fruit_dots", nil, &rules.FruitDots},
+			field{prefix + "fruit_ticks", nil, &rules.FruitTicks},
+		)
+	}
+
+	return fields
+}
+
+// apply sets each key in values on c, recording it in set. source names
+// where the values came from for error messages.
+func (c *Config) apply(values map[string]any, source string, set map[string]bool) error {
+	// Route rules and presets are keyed by name, so create any new ones
+	// before looking up fields. New presets start from the normal rules.
+	for key := range values {
+		if name, ok := mapEntryName(key, routesKey); ok {
+			if _, exists := c.RateLimit.Routes[name]; !exists {
+				c.RateLimit.Routes[name] = &RateLimitRule{}
+			}
+		}
+		if name, ok := mapEntryName(key, presetsKey); ok {
+			if _, exists := c.Game.Presets[name]; !exists {
+				rules := *defaultPresets()["normal"]
+				c.Game.Presets[name] = &rules
+			}
+		}
+	}
+
+	fields := make(map[string]field)
+	for _, f := range c.fields() {
+		fields[f.key] = f
+	}
+
+	for _, key := range sortedKeys(values) {
+		f, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown configuration key %q in %s", key, source)
+		}
+		if err := f.set(values[key]); err != nil {
+			return fmt.Errorf("invalid value for %s in %s: %w", key, source, err)
+		}
+		set[key] = true
+	}
+
+	return nil
+}
+
+// set assigns raw to the field. Strings are parsed according to the field
+// type; values decoded from config files may also be native types.
+func (f field) set(raw any) error {
+	switch ptr := f.ptr.(type) {
+	case *string:
+		switch v := raw.(type) {
+		case string:
+			*ptr = v
+		case int, int64, uint64, float64:
+			*ptr = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("expected string, got %T", raw)
+		}
+	case *bool:
+		switch v := raw.(type) {
+		case bool:
+			*ptr = v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("expected boolean, got %q", v)
+			}
+			*ptr = b
+		default:
+			return fmt.Errorf("expected boolean, got %T", raw)
+		}
+	case *int:
+		switch v := raw.(type) {
+		case int:
+			*ptr = v
+		case int64:
+			*ptr = int(v)
+		case uint64:
+			*ptr = int(v)
+		case string:
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("expected integer, got %q", v)
+			}
+			*ptr = i
+		default:
+			return fmt.Errorf("expected integer, got %T", raw)
+		}
+	case *float64:
+		switch v := raw.(type) {
+		case float64:
+			*ptr = v
+		case int:
+			*ptr = float64(v)
+		case int64:
+			*ptr = float64(v)
+		case uint64:
+			*ptr = float64(v)
+		case string:
+			fl, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("expected number, got %q", v)
+			}
+			*ptr = fl
+		default:
+			return fmt.Errorf("expected number, got %T", raw)
+		}
+	case *time.Duration:
+		v, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("expected duration string such as \"30s\", got %T", raw)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("expected duration, got %q", v)
+		}
+		*ptr = d
+	case *[]string:
+		switch v := raw.(type) {
+		case string:
+			*ptr = splitList(v)
+		case []any:
+			list := make([]string, 0, len(v))
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("expected list of strings, got %T element", item)
+				}
+				list = append(list, s)
+			}
+			*ptr = list
+		default:
+			return fmt.Errorf("expected list of strings, got %T", raw)
+		}
+	case *[]int:
+		var items []any
+		switch v := raw.(type) {
+		case string:
+			for _, s := range splitList(v) {
+				items = append(items, s)
+			}
+		case []any:
+			items = v
+		default:
+			return fmt.Errorf("expected list of integers, got %T", raw)
+		}
+		list := make([]int, 0, len(items))
+		for _, item := range items {
+			var n int
+			if err := (field{ptr: &n}).set(item); err != nil {
+				return fmt.Errorf("expected list of integers: %w", err)
+			}
+			list = append(list, n)
+		}
+		*ptr = list
+	default:
+		return fmt.Errorf("unsupported field type %T", f.ptr)
+	}
+	return nil
+}
+
+// value returns the field's current value in a form suitable for printing
+func (f field) value() any {
+	switch ptr := f.ptr.(type) {
+	case *string:
+		return *ptr
+	case *bool:
+		return *ptr
+	case *int:
+		return *ptr
+	case *float64:
+		return *ptr
+	case *time.Duration:
+		return ptr.String()
+	case *[]string:
+		if *ptr == nil {
+			return []string{}
+		}
+		return *ptr
+	case *[]int:
+		if *ptr == nil {
+			return []int{}
+		}
+		return *ptr
+	default:
+		return nil
+	}
+}
+
+// readConfigFile reads a YAML or TOML file and flattens it into dotted keys
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q: use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]any)
+	flatten("", raw, values)
+	return values, nil
+}
+
+// flatten copies nested maps from src into dst using dotted keys
+func flatten(prefix string, src map[string]any, dst map[string]any) {
+	for k, v := range src {
+		key := prefix + k
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key+".", nested, dst)
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+// mapEntryName returns the map entry named by a key such as
+// "rate_limit.routes.POST /api/game/start.rate"
+func mapEntryName(key, prefix string) (string, bool) {
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(key, prefix)
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return "", false
+	}
+	return name[:i], true
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
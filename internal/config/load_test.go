@@ -0,0 +1,333 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every environment variable Load reads, so tests see only
+// the settings they make
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, f := range defaultConfig().fields() {
+		for _, name := range f.env {
+			t.Setenv(name, "")
+		}
+	}
+}
+
+// writeFile writes a config file named name into a temporary directory and
+// returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := `
+server:
+  port: "7000"
+logging:
+  level: debug
+game:
+  max_concurrent_games: 50
+`
+	for _, tc := range []struct {
+		name      string
+		file      string
+		env       map[string]string
+		overrides []string
+		wantPort  string
+		wantLevel string
+		wantGames int
+	}{
+		{name: "defaults", wantPort: "8080", wantLevel: "info", wantGames: 1000},
+		{name: "file over defaults", file: file, wantPort: "7000", wantLevel: "debug", wantGames: 50},
+		{
+			name:      "env over file",
+			file:      file,
+			env:       map[string]string{"SERVER_PORT": "8000", "LOG_LEVEL": "warn"},
+			wantPort:  "8000",
+			wantLevel: "warn",
+			wantGames: 50,
+		},
+		{
+			name:      "first env alias wins",
+			env:       map[string]string{"SERVER_PORT": "8000", "PORT": "8001"},
+			wantPort:  "8000",
+			wantLevel: "info",
+			wantGames: 1000,
+		},
+		{
+			name:      "command line over env",
+			file:      file,
+			env:       map[string]string{"SERVER_PORT": "8000", "LOG_LEVEL": "warn"},
+			overrides: []string{"server.port=9000", "game.max_concurrent_games=10"},
+			wantPort:  "9000",
+			wantLevel: "warn",
+			wantGames: 10,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			opts := &Options{Overrides: tc.overrides}
+			if tc.file != "" {
+				opts.ConfigFile = writeFile(t, "config.yaml", tc.file)
+			}
+
+			cfg, err := Load(opts)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Port != tc.wantPort || cfg.Logging.Level != tc.wantLevel || cfg.Game.MaxConcurrentGames != tc.wantGames {
+				t.Errorf("got port %s, level %s, max games %d; want %s, %s, %d",
+					cfg.Server.Port, cfg.Logging.Level, cfg.Game.MaxConcurrentGames,
+					tc.wantPort, tc.wantLevel, tc.wantGames)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsBadSettings(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		file      string
+		content   string
+		env       map[string]string
+		overrides []string
+		wantErr   string
+	}{
+		{
+			name:    "unknown yaml key",
+			file:    "config.yaml",
+			content: "server:\n  prot: 9000\n",
+			wantErr: `unknown configuration key "server.prot"`,
+		},
+		{
+			name:    "unknown toml key",
+			file:    "config.toml",
+			content: "[server]\nprot = 9000\n",
+			wantErr: `unknown configuration key "server.prot"`,
+		},
+		{
+			name:    "unknown toml section",
+			file:    "config.toml",
+			content: "[sever]\nport = \"9000\"\n",
+			wantErr: `unknown configuration key "sever.port"`,
+		},
+		{
+			name:      "unknown override",
+			overrides: []string{"server.prot=9000"},
+			wantErr:   `unknown configuration key "server.prot" in command line`,
+		},
+		{
+			name:    "unsupported format",
+			file:    "config.json",
+			content: "{}",
+			wantErr: "unsupported config file format",
+		},
+		{
+			name:    "wrong type in file",
+			file:    "config.yaml",
+			content: "rate_limit:\n  enabled: sometimes\n",
+			wantErr: "invalid value for rate_limit.enabled",
+		},
+		{
+			name:    "malformed env",
+			env:     map[string]string{"READ_TIMEOUT": "soon"},
+			wantErr: "invalid value for READ_TIMEOUT",
+		},
+		{
+			name:      "invalid result",
+			overrides: []string{"cors.allowed_origins=*", "cors.allow_credentials=true"},
+			wantErr:   "invalid configuration",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			opts := &Options{Overrides: tc.overrides}
+			if tc.file != "" {
+				opts.ConfigFile = writeFile(t, tc.file, tc.content)
+			}
+
+			_, err := Load(opts)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	yamlFile := `
+server:
+  port: "7000"
+  read_timeout: 3s
+rate_limit:
+  routes:
+    "POST /api/custom":
+      rate: 2
+      burst: 4
+    "POST /api/game/start":
+      burst: 9
+game:
+  presets:
+    custom:
+      lives: 7
+      fruit_dots: [10, 20]
+cors:
+  allowed_origins:
+    - https://app.example.com
+`
+	tomlFile := `
+[server]
+port = "7000"
+read_timeout = "3s"
+
+[rate_limit.routes."POST /api/custom"]
+rate = 2
+burst = 4
+
+[rate_limit.routes."POST /api/game/start"]
+burst = 9
+
+[game.presets.custom]
+lives = 7
+fruit_dots = [10, 20]
+
+[cors]
+allowed_origins = ["https://app.example.com"]
+`
+	for _, tc := range []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "config.yaml", yamlFile},
+		{"yml", "config.yml", yamlFile},
+		{"toml", "config.toml", tomlFile},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			cfg, err := Load(&Options{ConfigFile: writeFile(t, tc.file, tc.content)})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Server.Port != "7000" || cfg.Server.ReadTimeout != 3*time.Second {
+				t.Errorf("server: got port %s and read timeout %s", cfg.Server.Port, cfg.Server.ReadTimeout)
+			}
+			if got := cfg.RateLimit.Routes["POST /api/custom"]; got == nil || got.Rate != 2 || got.Burst != 4 {
+				t.Errorf("new route: got %+v, want rate 2 and burst 4", got)
+			}
+			// Routes given in part keep their other defaults
+			start := defaultConfig().RateLimit.Routes["POST /api/game/start"]
+			if got := cfg.RateLimit.Routes["POST /api/game/start"]; got.Rate != start.Rate || got.Burst != 9 {
+				t.Errorf("start route: got %+v, want rate %v and burst 9", got, start.Rate)
+			}
+			// New presets start from the normal rules
+			custom := cfg.Game.Presets["custom"]
+			normal := defaultPresets()["normal"]
+			if custom == nil || custom.Lives != 7 || !slices.Equal(custom.FruitDots, []int{10, 20}) || custom.GhostCount != normal.GhostCount {
+				t.Errorf("custom preset: got %+v", custom)
+			}
+			if !slices.Equal(cfg.CORS.AllowedOrigins, []string{"https://app.example.com"}) {
+				t.Errorf("allowed origins: got %q", cfg.CORS.AllowedOrigins)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	clearEnv(t)
+	const token = "s3cret-admin-token"
+
+	cfg, err := Load(&Options{Overrides: []string{"admin.token=" + token, "rate_limit.routes.POST /api/custom.rate=2", "rate_limit.routes.POST /api/custom.burst=4"}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	if strings.Contains(out.String(), token) || !strings.Contains(out.String(), "token: "+redacted) {
+		t.Fatalf("admin token is not redacted:\n%s", out.String())
+	}
+
+	// Printed configuration loads back to the same settings, secrets aside
+	cfg.Admin.Token = ""
+	printed := strings.Replace(out.String(), "token: "+redacted, `token: ""`, 1)
+	loaded, err := Load(&Options{ConfigFile: writeFile(t, "printed.yaml", printed)})
+	if err != nil {
+		t.Fatalf("Load printed configuration: %v", err)
+	}
+	if reloadable, restart := Changes(cfg, loaded); len(reloadable) > 0 || len(restart) > 0 {
+		t.Errorf("printed configuration differs: %v %v", reloadable, restart)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := defaultConfig()
+	next := defaultConfig()
+	next.Server.Port = "9000"
+	next.Admin.Token = "s3cret"
+	next.Logging.Level = "debug"
+	next.RateLimit.PerIP.Burst = 1
+	next.RateLimit.Routes["POST /api/custom"] = &RateLimitRule{Rate: 1, Burst: 1}
+	next.Game.Presets["easy"].Lives = 9
+
+	reloadable, restart := Changes(old, next)
+
+	wantReloadable := []string{
+		"game.presets.easy.lives",
+		"logging.level",
+		"rate_limit.per_ip.burst",
+		"rate_limit.routes.POST /api/custom.burst",
+		"rate_limit.routes.POST /api/custom.rate",
+	}
+	if !slices.Equal(reloadable, wantReloadable) {
+		t.Errorf("reloadable: got %q, want %q", reloadable, wantReloadable)
+	}
+	if want := []string{"admin.token", "server.port"}; !slices.Equal(restart, want) {
+		t.Errorf("restart: got %q, want %q", restart, want)
+	}
+
+	if reloadable, restart := Changes(old, defaultConfig()); len(reloadable) > 0 || len(restart) > 0 {
+		t.Errorf("equal configurations differ: %v %v", reloadable, restart)
+	}
+}
+
+func TestIsReloadable(t *testing.T) {
+	for key, want := range map[string]bool{
+		"logging.level":                      true,
+		"logging.format":                     false,
+		"rate_limit.enabled":                 true,
+		"rate_limit.routes.POST /x.rate":     true,
+		"game.max_concurrent_games":          true,
+		"game.presets.hard.ghost_aggression": true,
+		"server.port":                        false,
+		"cors.allowed_origins":               false,
+		"scheduler.shards":                   false,
+		"admin.token":                        false,
+	} {
+		if got := IsReloadable(key); got != want {
+			t.Errorf("IsReloadable(%q): got %t, want %t", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SessionInfo describes a game session to operators
+type SessionInfo struct {
+	SessionID  string `json:"sessionId"`
+	Preset     string `json:"preset"`
+	Maze       string `json:"maze"`
+	Owner      string `json:"owner,omitempty"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"maxPlayers"`
+	Score      int    `json:"score"`
+	DotsLeft   int    `json:"dotsLeft"`
+	Spectators int    `json:"spectators"`
+	Versus     bool   `json:"versus"`
+	Headless   bool   `json:"headless"`
+	Finished   bool   `json:"finished"`
+	// LoopRunning reports whether the game is being advanced by a game loop
+	LoopRunning bool `json:"loopRunning"`
+	// AgeSeconds is how long ago the game was created
+	AgeSeconds int64     `json:"ageSeconds"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	// ExpiresAt is when the repository drops the game unless it is saved
+	// again, if it ever does
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// SessionDetail is everything operators can see about a game session
+type SessionDetail struct {
+	SessionInfo
+	// SpectatorToken lets operators watch the game like any spectator
+	SpectatorToken string    `json:"spectatorToken"`
+	State          GameState `json:"state"`
+}
+
+// Info describes the game to operators. loopRunning comes from the service
+// running the game, which the game itself does not know about.
+func (g *Game) Info(loopRunning bool, now time.Time) SessionInfo {
+	return SessionInfo{
+		SessionID:   g.ID,
+		Preset:      g.Rules.Preset,
+		Maze:        g.Maze,
+		Owner:       g.Owner,
+		Players:     len(g.Players),
+		MaxPlayers:  g.MaxPlayers,
+		Score:       g.TotalScore(),
+		DotsLeft:    g.DotsLeft(),
+		Spectators:  len(g.Spectators),
+		Versus:      g.Versus,
+		Headless:    g.Headless,
+		Finished:    g.IsFinished(),
+		LoopRunning: loopRunning,
+		AgeSeconds:  int64(now.Sub(g.CreatedAt).Seconds()),
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
+
+// Announcement is a server message shown to every client until it expires
+type Announcement struct {
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AdminStatus summarizes the games of a server for operators
+type AdminStatus struct {
+	// Draining servers refuse new games and let running ones finish
+	Draining     bool `json:"draining"`
+	Sessions     int  `json:"sessions"`
+	RunningLoops int  `json:"runningLoops"`
+	// HeadlessGames counts the unfinished headless games, which share the
+	// concurrent game limit with running loops
+	HeadlessGames      int           `json:"headlessGames"`
+	MaxConcurrentGames int           `json:"maxConcurrentGames"`
+	Ticks              TickStats     `json:"ticks"`
+	Announcement       *Announcement `json:"announcement,omitempty"`
+}
+
+// TickStats describes how the game loops keep up with their schedule
+type TickStats struct {
+	// Shards is the number of goroutines running game ticks
+	Shards int `json:"shards"`
+	// Ticks and Skipped count the game ticks run, and dropped because a
+	// game fell too far behind, since the server started
+	Ticks   uint64 `json:"ticks"`
+	Skipped uint64 `json:"skipped"`
+	// LagMS is the longest delay of a recent tick behind schedule
+	LagMS int64 `json:"lagMs"`
+}
+
+// GameAdmin defines the operator controls of the games on a server
+type GameAdmin interface {
+	// Status summarizes the games of the server
+	Status(ctx context.Context) AdminStatus
+
+	// ListSessions lists every stored game, oldest first
+	ListSessions(ctx context.Context) ([]SessionInfo, error)
+
+	// InspectSession returns everything operators can see about a game
+	InspectSession(ctx context.Context, sessionID string) (*SessionDetail, error)
+
+	// StopSession stops the game loop of a session, freezing the game
+	// where it is. The game stays stored until it is deleted.
+	StopSession(ctx context.Context, sessionID string) error
+
+	// Broadcast shows message to every client until ttl has passed,
+	// replacing any earlier message. An empty message clears it.
+	Broadcast(ctx context.Context, message string, ttl time.Duration) (*Announcement, error)
+
+	// SetDraining makes the server refuse new games while draining is true
+	SetDraining(ctx context.Context, draining bool)
+}
@@ -0,0 +1,407 @@
+package domain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidBoard is returned when decoding a malformed board
+var ErrInvalidBoard = errors.New("invalid board")
+
+// Tile is the content of one board cell
+type Tile uint8
+
+const (
+	TileEmpty Tile = iota
+	TileWall
+	TileDot
+	// TileTunnel is an empty tile that, on the edge of the board, leads
+	// around to the opposite edge
+	TileTunnel
+	// TileFruitSpawn is the empty tile where bonus fruit appears
+	TileFruitSpawn
+)
+
+// Symbol returns the character that shows the tile in mazes and API
+// responses
+func (t Tile) Symbol() byte {
+	switch t {
+	case TileWall:
+		return '#'
+	case TileDot:
+		return '.'
+	case TileTunnel:
+		return '='
+	case TileFruitSpawn:
+		return '*'
+	default:
+		return ' '
+	}
+}
+
+// ParseTile converts a maze character to a tile
+func ParseTile(symbol byte) (Tile, bool) {
+	switch symbol {
+	case '#':
+		return TileWall, true
+	case '.':
+		return TileDot, true
+	case '=':
+		return TileTunnel, true
+	case '*':
+		return TileFruitSpawn, true
+	case ' ':
+		return TileEmpty, true
+	default:
+		return TileEmpty, false
+	}
+}
+
+// Board is a rectangular grid of tiles stored one byte per cell, row by
+// row. The layout never changes once built and is shared by copies of the
+// board; the dots still on it are tracked in a bitset of their own.
+type Board struct {
+	width  int
+	height int
+	// layout holds the tiles the board was built with
+	layout []Tile
+	// dots has a bit set for every cell that still holds a dot
+	dots  []uint64
+	count int
+	// fruitSpawn is the cell of the first fruit spawn tile, or -1
+	fruitSpawn int
+	// tunnels holds the mouths of the tunnels on the edges of the board
+	tunnels []tunnel
+}
+
+// tunnel is the mouth of a tunnel on an edge of the board, and the
+// direction that leads through it to the opposite edge
+type tunnel struct {
+	mouth Position
+	dir   Direction
+}
+
+// NewBoard builds a width by height board from maze rows. Cells the rows
+// do not cover are walls.
+func NewBoard(rows []string, width, height int) Board {
+	b := newBoard(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := TileWall
+			if y < len(rows) && x < len(rows[y]) {
+				tile, _ = ParseTile(rows[y][x])
+			}
+			b.set(y*width+x, tile)
+		}
+	}
+	return b
+}
+
+// newBoard returns an empty width by height board to build on
+func newBoard(width, height int) Board {
+	return Board{
+		width:      width,
+		height:     height,
+		layout:     make([]Tile, width*height),
+		dots:       make([]uint64, (width*height+63)/64),
+		fruitSpawn: -1,
+	}
+}
+
+// set places tile at cell i of a board being built
+func (b *Board) set(i int, tile Tile) {
+	b.layout[i] = tile
+	switch tile {
+	case TileDot:
+		b.dots[i/64] |= 1 << (i % 64)
+		b.count++
+	case TileFruitSpawn:
+		if b.fruitSpawn < 0 {
+			b.fruitSpawn = i
+		}
+	case TileTunnel:
+		pos := Position{X: i % b.width, Y: i / b.width}
+		if pos.X == 0 {
+			b.tunnels = append(b.tunnels, tunnel{mouth: pos, dir: DirectionLeft})
+		}
+		if pos.X == b.width-1 {
+			b.tunnels = append(b.tunnels, tunnel{mouth: pos, dir: DirectionRight})
+		}
+		if pos.Y == 0 {
+			b.tunnels = append(b.tunnels, tunnel{mouth: pos, dir: DirectionUp})
+		}
+		if pos.Y == b.height-1 {
+			b.tunnels = append(b.tunnels, tunnel{mouth: pos, dir: DirectionDown})
+		}
+	}
+}
+
+// Width returns the number of columns
+func (b *Board) Width() int {
+	return b.width
+}
+
+// Height returns the number of rows
+func (b *Board) Height() int {
+	return b.height
+}
+
+// Contains reports whether pos is on the board
+func (b *Board) Contains(pos Position) bool {
+	return pos.X >= 0 && pos.X < b.width && pos.Y >= 0 && pos.Y < b.height
+}
+
+// Tile returns the tile at pos. Positions off the board are walls.
+func (b *Board) Tile(pos Position) Tile {
+	if !b.Contains(pos) {
+		return TileWall
+	}
+	return b.tile(pos.Y*b.width + pos.X)
+}
+
+// tile returns the current tile of cell i
+func (b *Board) tile(i int) Tile {
+	tile := b.layout[i]
+	if tile == TileDot && b.dots[i/64]&(1<<(i%64)) == 0 {
+		return TileEmpty
+	}
+	return tile
+}
+
+// Next returns the position one step from pos in dir. Stepping off the
+// board from a tunnel tile on its edge comes back in on the opposite edge.
+func (b *Board) Next(pos Position, dir Direction) Position {
+	next := pos.Move(dir)
+	if !b.Contains(next) && b.Tile(pos) == TileTunnel {
+		next.X = (next.X + b.width) % b.width
+		next.Y = (next.Y + b.height) % b.height
+	}
+	return next
+}
+
+// Delta returns the offset to head along from one position towards
+// another: straight to it, or to the mouth of a tunnel when going through
+// the tunnel is shorter, and one step through it once there. It guides
+// ghosts; paths should follow Next.
+func (b *Board) Delta(from, to Position) (dx, dy int) {
+	t, ok := b.shortcut(from, to)
+	switch {
+	case !ok:
+		return to.X - from.X, to.Y - from.Y
+	case from == t.mouth:
+		step := from.Move(t.dir)
+		return step.X - from.X, step.Y - from.Y
+	default:
+		return t.mouth.X - from.X, t.mouth.Y - from.Y
+	}
+}
+
+// Distance returns the Manhattan distance between two positions, or the
+// distance through a tunnel when that is shorter: to its mouth, one step
+// through, and on from its far end
+func (b *Board) Distance(from, to Position) int {
+	t, ok := b.shortcut(from, to)
+	if !ok {
+		return manhattan(from, to)
+	}
+	return b.distanceThrough(t, from, to)
+}
+
+// shortcut returns the tunnel whose route from one position to another is
+// shortest, and false when going straight is no longer than any
+func (b *Board) shortcut(from, to Position) (tunnel, bool) {
+	var best tunnel
+	shortest, found := manhattan(from, to), false
+	for _, t := range b.tunnels {
+		if d := b.distanceThrough(t, from, to); d < shortest {
+			best, shortest, found = t, d, true
+		}
+	}
+	return best, found
+}
+
+// distanceThrough returns the length of the route from one position to
+// another through t, or math.MaxInt when t leads into a wall
+func (b *Board) distanceThrough(t tunnel, from, to Position) int {
+	exit := b.Next(t.mouth, t.dir)
+	if b.IsWall(exit) {
+		return math.MaxInt
+	}
+	return manhattan(from, t.mouth) + 1 + manhattan(exit, to)
+}
+
+// manhattan returns the Manhattan distance between two positions
+func manhattan(from, to Position) int {
+	return Abs(to.X-from.X) + Abs(to.Y-from.Y)
+}
+
+// Abs returns the absolute value of x
+func Abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// IsWall reports whether pos is a wall or off the board
+func (b *Board) IsWall(pos Position) bool {
+	return b.Tile(pos) == TileWall
+}
+
+// HasDot reports whether a dot is still at pos
+func (b *Board) HasDot(pos Position) bool {
+	return b.Tile(pos) == TileDot
+}
+
+// EatDot removes the dot at pos and reports whether there was one
+func (b *Board) EatDot(pos Position) bool {
+	if !b.HasDot(pos) {
+		return false
+	}
+	i := pos.Y*b.width + pos.X
+	b.dots[i/64] &^= 1 << (i % 64)
+	b.count--
+	return true
+}
+
+// Dots returns the number of dots left
+func (b *Board) Dots() int {
+	return b.count
+}
+
+// FruitSpawn returns where bonus fruit appears, and false when the board
+// has no fruit spawn tile
+func (b *Board) FruitSpawn() (Position, bool) {
+	if b.fruitSpawn < 0 {
+		return Position{}, false
+	}
+	return Position{X: b.fruitSpawn % b.width, Y: b.fruitSpawn / b.width}, true
+}
+
+// Clone returns a copy of the board whose dots change independently. The
+// layout is shared, so only the dot bitset is copied.
+func (b *Board) Clone() Board {
+	clone := *b
+	clone.dots = append([]uint64(nil), b.dots...)
+	return clone
+}
+
+// AppendRow appends the symbols of row y to dst, e.g. "#..#"
+func (b *Board) AppendRow(dst []byte, y int) []byte {
+	for i := y * b.width; i < (y+1)*b.width; i++ {
+		dst = append(dst, b.tile(i).Symbol())
+	}
+	return dst
+}
+
+// jsonSize is the length of the board's JSON encoding
+func (b *Board) jsonSize() int {
+	if b.height == 0 {
+		return 2
+	}
+	// Every cell is a quoted character and a separator, and every row
+	// adds its brackets
+	return 1 + b.height*(4*b.width+2)
+}
+
+// AppendJSON appends the board to dst as a JSON array of rows, each an
+// array of one-character strings, without allocating beyond dst
+func (b *Board) AppendJSON(dst []byte) []byte {
+	dst = append(dst, '[')
+	for y := 0; y < b.height; y++ {
+		if y > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '[')
+		for i := y * b.width; i < (y+1)*b.width; i++ {
+			if i > y*b.width {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, '"', b.tile(i).Symbol(), '"')
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, ']')
+}
+
+// MarshalJSON encodes the board as rows of one-character strings
+func (b Board) MarshalJSON() ([]byte, error) {
+	return b.AppendJSON(make([]byte, 0, b.jsonSize())), nil
+}
+
+// UnmarshalJSON decodes a board encoded by MarshalJSON
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var rows [][]string
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBoard, err)
+	}
+
+	width := 0
+	if len(rows) > 0 {
+		width = len(rows[0])
+	}
+	board := newBoard(width, len(rows))
+	for y, row := range rows {
+		if len(row) != width {
+			return fmt.Errorf("%w: row %d has %d cells, expected %d", ErrInvalidBoard, y, len(row), width)
+		}
+		for x, cell := range row {
+			tile, ok := TileEmpty, false
+			if len(cell) == 1 {
+				tile, ok = ParseTile(cell[0])
+			}
+			if !ok {
+				return fmt.Errorf("%w: unknown tile %q at %d,%d", ErrInvalidBoard, cell, x, y)
+			}
+			board.set(y*width+x, tile)
+		}
+	}
+	*b = board
+	return nil
+}
+
+// binaryHeaderSize is the length of the width and height that start the
+// binary encoding
+const binaryHeaderSize = 4
+
+// AppendBinary appends the board to dst as its width and height, each a
+// big-endian uint16, followed by one tile per cell, row by row
+func (b *Board) AppendBinary(dst []byte) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, uint16(b.width))
+	dst = binary.BigEndian.AppendUint16(dst, uint16(b.height))
+	for i := range b.layout {
+		dst = append(dst, byte(b.tile(i)))
+	}
+	return dst
+}
+
+// MarshalBinary encodes the board in its binary form
+func (b Board) MarshalBinary() ([]byte, error) {
+	return b.AppendBinary(make([]byte, 0, binaryHeaderSize+len(b.layout))), nil
+}
+
+// UnmarshalBinary decodes a board encoded by MarshalBinary
+func (b *Board) UnmarshalBinary(data []byte) error {
+	if len(data) < binaryHeaderSize {
+		return fmt.Errorf("%w: %d bytes is too short", ErrInvalidBoard, len(data))
+	}
+	width := int(binary.BigEndian.Uint16(data))
+	height := int(binary.BigEndian.Uint16(data[2:]))
+	cells := data[binaryHeaderSize:]
+	if len(cells) != width*height {
+		return fmt.Errorf("%w: %d cells for a %dx%d board", ErrInvalidBoard, len(cells), width, height)
+	}
+
+	board := newBoard(width, height)
+	for i, cell := range cells {
+		if Tile(cell) > TileFruitSpawn {
+			return fmt.Errorf("%w: unknown tile %d", ErrInvalidBoard, cell)
+		}
+		board.set(i, Tile(cell))
+	}
+	*b = board
+	return nil
+}
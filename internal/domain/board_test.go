@@ -0,0 +1,246 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// testMaze is a small maze with every kind of tile and tunnels across the
+// left and right edges
+var testMaze = []string{
+	"#######",
+	"#..*..#",
+	"=.# #.=",
+	"#.....#",
+	"#######",
+}
+
+// newTestBoard builds the board of testMaze
+func newTestBoard() Board {
+	return NewBoard(testMaze, 7, 5)
+}
+
+// boardRows returns the symbols of every row of b
+func boardRows(b *Board) []string {
+	rows := make([]string, b.Height())
+	for y := range rows {
+		rows[y] = string(b.AppendRow(nil, y))
+	}
+	return rows
+}
+
+func TestBoardDots(t *testing.T) {
+	b := newTestBoard()
+	if got := b.Dots(); got != 11 {
+		t.Fatalf("Dots: got %d, want 11", got)
+	}
+
+	pos := Position{X: 1, Y: 1}
+	if !b.EatDot(pos) {
+		t.Fatal("EatDot found no dot")
+	}
+	if b.EatDot(pos) {
+		t.Fatal("EatDot ate a dot twice")
+	}
+	if b.EatDot(Position{X: 3, Y: 2}) || b.EatDot(Position{X: 0, Y: 0}) || b.EatDot(Position{X: -1, Y: 9}) {
+		t.Fatal("EatDot ate a dot off an empty tile, a wall or the board")
+	}
+	if got := b.Dots(); got != 10 {
+		t.Fatalf("Dots after eating one: got %d, want 10", got)
+	}
+	if b.Tile(pos) != TileEmpty {
+		t.Fatalf("eaten dot left tile %v", b.Tile(pos))
+	}
+	if spawn, ok := b.FruitSpawn(); !ok || spawn != (Position{X: 3, Y: 1}) {
+		t.Fatalf("FruitSpawn: got %v %v, want 3,1", spawn, ok)
+	}
+}
+
+func TestBoardDotsAcrossBitsetWords(t *testing.T) {
+	// 130 dots span three words of the bitset
+	row := make([]byte, 130)
+	for i := range row {
+		row[i] = '.'
+	}
+	b := NewBoard([]string{string(row)}, len(row), 1)
+	if got := b.Dots(); got != 130 {
+		t.Fatalf("Dots: got %d, want 130", got)
+	}
+	for _, x := range []int{0, 63, 64, 127, 128, 129} {
+		if !b.EatDot(Position{X: x}) {
+			t.Fatalf("EatDot(%d) found no dot", x)
+		}
+		if b.HasDot(Position{X: x}) {
+			t.Fatalf("dot at %d left after eating it", x)
+		}
+	}
+	if got := b.Dots(); got != 124 {
+		t.Fatalf("Dots after eating six: got %d, want 124", got)
+	}
+	if !b.HasDot(Position{X: 65}) {
+		t.Fatal("eating dots removed a neighbour")
+	}
+}
+
+func TestBoardCloneIsIndependent(t *testing.T) {
+	b := newTestBoard()
+	clone := b.Clone()
+
+	clone.EatDot(Position{X: 1, Y: 1})
+	if !b.HasDot(Position{X: 1, Y: 1}) || b.Dots() != 11 {
+		t.Fatal("eating a dot of the clone changed the original")
+	}
+
+	b.EatDot(Position{X: 2, Y: 1})
+	if !clone.HasDot(Position{X: 2, Y: 1}) || clone.Dots() != 10 {
+		t.Fatal("eating a dot of the original changed the clone")
+	}
+}
+
+func TestBoardJSONRoundTrip(t *testing.T) {
+	b := newTestBoard()
+	b.EatDot(Position{X: 1, Y: 3})
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var rows [][]string
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("board JSON is not rows of strings: %v", err)
+	}
+	if len(rows) != 5 || len(rows[2]) != 7 || rows[2][0] != "=" || rows[1][3] != "*" || rows[3][1] != " " {
+		t.Fatalf("unexpected board JSON: %s", data)
+	}
+
+	var decoded Board
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	assertSameBoard(t, &decoded, &b)
+}
+
+func TestBoardBinaryRoundTrip(t *testing.T) {
+	b := newTestBoard()
+	b.EatDot(Position{X: 5, Y: 2})
+
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	if len(data) != binaryHeaderSize+7*5 {
+		t.Fatalf("binary board is %d bytes, want %d", len(data), binaryHeaderSize+7*5)
+	}
+
+	var decoded Board
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	assertSameBoard(t, &decoded, &b)
+}
+
+// assertSameBoard fails the test unless got has the tiles, dots, fruit
+// spawn and tunnels of want
+func assertSameBoard(t *testing.T, got, want *Board) {
+	t.Helper()
+
+	if got.Width() != want.Width() || got.Height() != want.Height() {
+		t.Fatalf("decoded a %dx%d board, want %dx%d", got.Width(), got.Height(), want.Width(), want.Height())
+	}
+	gotRows, wantRows := boardRows(got), boardRows(want)
+	for y := range wantRows {
+		if gotRows[y] != wantRows[y] {
+			t.Fatalf("row %d: got %q, want %q", y, gotRows[y], wantRows[y])
+		}
+	}
+	if got.Dots() != want.Dots() {
+		t.Fatalf("decoded %d dots, want %d", got.Dots(), want.Dots())
+	}
+	gotSpawn, gotOK := got.FruitSpawn()
+	wantSpawn, wantOK := want.FruitSpawn()
+	if gotSpawn != wantSpawn || gotOK != wantOK {
+		t.Fatalf("decoded fruit spawn %v %v, want %v %v", gotSpawn, gotOK, wantSpawn, wantOK)
+	}
+	if next := got.Next(Position{X: 0, Y: 2}, DirectionLeft); next != (Position{X: 6, Y: 2}) {
+		t.Fatalf("decoded tunnel leads to %v, want 6,2", next)
+	}
+}
+
+func TestBoardRejectsMalformedJSON(t *testing.T) {
+	cases := map[string]string{
+		"not rows":     `"#.#"`,
+		"ragged rows":  `[["#","#"],["#"]]`,
+		"unknown tile": `[["#","x"]]`,
+		"long cell":    `[["#",".."]]`,
+		"empty cell":   `[["#",""]]`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			var b Board
+			if err := json.Unmarshal([]byte(data), &b); !errors.Is(err, ErrInvalidBoard) {
+				t.Fatalf("Unmarshal(%s): got %v, want %v", data, err, ErrInvalidBoard)
+			}
+		})
+	}
+}
+
+func TestBoardRejectsMalformedBinary(t *testing.T) {
+	cases := map[string][]byte{
+		"short header":  {0, 2},
+		"missing cells": {0, 2, 0, 2, 1, 1, 1},
+		"extra cells":   {0, 1, 0, 1, 1, 1},
+		"unknown tile":  {0, 1, 0, 1, byte(TileFruitSpawn) + 1},
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			var b Board
+			if err := b.UnmarshalBinary(data); !errors.Is(err, ErrInvalidBoard) {
+				t.Fatalf("UnmarshalBinary(%v): got %v, want %v", data, err, ErrInvalidBoard)
+			}
+		})
+	}
+}
+
+func TestBoardDistanceThroughTunnels(t *testing.T) {
+	// The tunnel on row 7 leads between the left and right edges; row 1
+	// is far from it
+	b := NewBoard([]string{
+		"####################",
+		"#..................#",
+		"#..................#",
+		"#..................#",
+		"#..................#",
+		"#..................#",
+		"#..................#",
+		"=..................=",
+		"#..................#",
+		"###################=",
+	}, 20, 10)
+
+	cases := []struct {
+		name     string
+		from, to Position
+		dx, dy   int
+		distance int
+	}{
+		{"far from the tunnel", Position{X: 3, Y: 1}, Position{X: 16, Y: 1}, 13, 0, 13},
+		{"short way across", Position{X: 5, Y: 7}, Position{X: 8, Y: 7}, 3, 0, 3},
+		{"to the mouth", Position{X: 2, Y: 7}, Position{X: 17, Y: 7}, -2, 0, 5},
+		{"through the mouth", Position{X: 0, Y: 7}, Position{X: 17, Y: 7}, -1, 0, 3},
+		{"from another row", Position{X: 1, Y: 6}, Position{X: 18, Y: 8}, -1, 1, 5},
+		{"back the other way", Position{X: 18, Y: 8}, Position{X: 1, Y: 6}, 1, -1, 5},
+		// The tunnel on the bottom edge leads into a wall
+		{"tunnel into a wall", Position{X: 19, Y: 8}, Position{X: 19, Y: 1}, 0, -7, 7},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if dx, dy := b.Delta(tc.from, tc.to); dx != tc.dx || dy != tc.dy {
+				t.Errorf("Delta: got %d,%d, want %d,%d", dx, dy, tc.dx, tc.dy)
+			}
+			if d := b.Distance(tc.from, tc.to); d != tc.distance {
+				t.Errorf("Distance: got %d, want %d", d, tc.distance)
+			}
+		})
+	}
+}
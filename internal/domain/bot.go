@@ -0,0 +1,24 @@
+package domain
+
+// StepAction is one bot action in a batch of headless game steps
+type StepAction struct {
+	SessionID   string
+	PlayerToken string
+	Direction   Direction
+}
+
+// StepResult is what a bot observes after a headless game step
+type StepResult struct {
+	State GameState `json:"state"`
+	// Reward is the change in the acting player's score during the step
+	Reward int `json:"reward"`
+	// Done is set once the game has finished or the acting player has been
+	// eliminated
+	Done bool `json:"done"`
+}
+
+// StepOutcome is the result of one action of a batch, or why it failed
+type StepOutcome struct {
+	Result *StepResult
+	Err    error
+}
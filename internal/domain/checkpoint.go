@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+// CheckpointedGame is a game saved while the server shut down
+type CheckpointedGame struct {
+	Game *Game
+	// Running games had a game loop, which starts again when they resume
+	Running bool
+}
+
+// CheckpointStore keeps games across server restarts
+type CheckpointStore interface {
+	// Save replaces the stored checkpoint with games
+	Save(ctx context.Context, games []CheckpointedGame) error
+
+	// Load returns the checkpointed games, or none when there is no
+	// checkpoint
+	Load(ctx context.Context) ([]CheckpointedGame, error)
+
+	// Clear removes the checkpoint once its games have resumed
+	Clear(ctx context.Context) error
+}
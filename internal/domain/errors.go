@@ -0,0 +1,96 @@
+package domain
+
+import "errors"
+
+// Sentinel errors returned by repositories and services. Callers should
+// compare against them with errors.Is, as they are usually wrapped with
+// additional context.
+var (
+	// ErrGameNotFound is returned when no game exists for a session ID
+	ErrGameNotFound = errors.New("game not found")
+
+	// ErrGameOver is returned when an action targets a finished game
+	ErrGameOver = errors.New("game is over")
+
+	// ErrInvalidDirection is returned when a move direction is not recognised
+	ErrInvalidDirection = errors.New("invalid direction")
+
+	// ErrInvalidSessionID is returned when a session ID is missing or malformed
+	ErrInvalidSessionID = errors.New("invalid session ID")
+
+	// ErrSessionConflict is returned when a session already has an active game
+	ErrSessionConflict = errors.New("session already has an active game")
+
+	// ErrUnknownPreset is returned when a game asks for rules that do not exist
+	ErrUnknownPreset = errors.New("unknown rules preset")
+
+	// ErrUnknownMaze is returned when a game asks for a maze that does not exist
+	ErrUnknownMaze = errors.New("unknown maze")
+
+	// ErrUnknownStrategy is returned when asking for an autopilot strategy
+	// that does not exist
+	ErrUnknownStrategy = errors.New("unknown autopilot strategy")
+
+	// ErrGameFull is returned when joining a game that has no free player slot
+	ErrGameFull = errors.New("game is full")
+
+	// ErrInvalidPlayerToken is returned when a player token does not match
+	// any player of the game
+	ErrInvalidPlayerToken = errors.New("invalid player token")
+
+	// ErrNotGameHost is returned when a player other than the host uses a
+	// host control
+	ErrNotGameHost = errors.New("only the game host can do that")
+
+	// ErrPlayerEliminated is returned when an eliminated player tries to move
+	ErrPlayerEliminated = errors.New("player has no lives left")
+
+	// ErrNoGhostSeat is returned when claiming a ghost in a game that is not
+	// a versus game or whose ghosts are all claimed
+	ErrNoGhostSeat = errors.New("no ghost seat available")
+
+	// ErrNotHeadless is returned when stepping a game that runs on a timer
+	ErrNotHeadless = errors.New("game is not headless")
+
+	// ErrRoomNotFound is returned when no room exists for a room ID
+	ErrRoomNotFound = errors.New("room not found")
+
+	// ErrRoomFull is returned when joining a room that has no free seat
+	ErrRoomFull = errors.New("room is full")
+
+	// ErrRoomStarted is returned when an action needs a room that is still
+	// waiting for its game to start
+	ErrRoomStarted = errors.New("room has already started")
+
+	// ErrRoomNotReady is returned when starting a room whose members are
+	// not all ready
+	ErrRoomNotReady = errors.New("not all room members are ready")
+
+	// ErrInvalidMemberToken is returned when a member token does not match
+	// any member of the room
+	ErrInvalidMemberToken = errors.New("invalid member token")
+
+	// ErrNotRoomHost is returned when a member other than the host uses a
+	// host control
+	ErrNotRoomHost = errors.New("only the room host can do that")
+
+	// ErrTooManyRooms is returned when the server is at its room capacity
+	ErrTooManyRooms = errors.New("too many open rooms")
+
+	// ErrInvalidGameOptions is returned when game options are out of range
+	ErrInvalidGameOptions = errors.New("invalid game options")
+
+	// ErrTooManyGames is returned when the server is at its game capacity
+	ErrTooManyGames = errors.New("too many active games")
+
+	// ErrDraining is returned when starting a game on a server that is
+	// draining for shutdown
+	ErrDraining = errors.New("server is draining and not accepting new games")
+
+	// ErrInvalidAnnouncement is returned when a broadcast message is too
+	// long or its lifetime is out of range
+	ErrInvalidAnnouncement = errors.New("invalid announcement")
+
+	// ErrInvalidGame is returned when a game cannot be persisted as given
+	ErrInvalidGame = errors.New("invalid game")
+)
@@ -0,0 +1,52 @@
+package domain
+
+// fruitTable lists the bonus fruit of each level, as in the arcade game.
+// Levels past the end get the last entry.
+var fruitTable = []struct {
+	kind   string
+	points int
+}{
+	{"cherry", 100},
+	{"strawberry", 300},
+	{"orange", 500},
+	{"orange", 500},
+	{"apple", 700},
+	{"apple", 700},
+	{"melon", 1000},
+	{"melon", 1000},
+	{"galaxian", 2000},
+	{"galaxian", 2000},
+	{"bell", 3000},
+	{"bell", 3000},
+	{"key", 5000},
+}
+
+// Fruit is a bonus item waiting on the board to be collected
+type Fruit struct {
+	Type     string   `json:"type"`
+	Position Position `json:"position"`
+	Points   int      `json:"points"`
+	// RemainingTicks is how many more ticks the fruit stays on the board
+	RemainingTicks int `json:"remainingTicks"`
+}
+
+// CollectedFruit records a bonus item a player collected
+type CollectedFruit struct {
+	Type     string `json:"type"`
+	Points   int    `json:"points"`
+	PlayerID string `json:"playerId"`
+	// DotsLeft is the number of dots that were left when it was collected
+	DotsLeft int `json:"dotsLeft"`
+}
+
+// NewFruit returns the bonus fruit of level at pos, staying for ticks
+// ticks. Levels below one get the first fruit.
+func NewFruit(level int, pos Position, ticks int) *Fruit {
+	i := min(max(level, 1), len(fruitTable)) - 1
+	return &Fruit{
+		Type:           fruitTable[i].kind,
+		Position:       pos,
+		Points:         fruitTable[i].points,
+		RemainingTicks: ticks,
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"maps"
 	"time"
 )
 
@@ -32,6 +33,11 @@ func (d Direction) String() string {
 	}
 }
 
+// IsValid reports whether d is one of the four movement directions
+func (d Direction) IsValid() bool {
+	return d >= DirectionUp && d <= DirectionRight
+}
+
 // ParseDirection converts string to Direction
 func ParseDirection(s string) (Direction, bool) {
 	switch s {
@@ -75,107 +81,313 @@ func (p Position) Equals(other Position) bool {
 	return p.X == other.X && p.Y == other.Y
 }
 
-// Ghost represents a ghost entity in the game
+// Ghost represents a ghost entity in the game. Ghosts are driven by the
+// server unless a participant has claimed them in a versus game.
 type Ghost struct {
+	// ID identifies the ghost within its game, e.g. "g1"
+	ID        string
 	Position  Position
 	Direction Direction
+	// Token authenticates the participant steering this ghost. An empty
+	// token means the ghost is controlled by the server.
+	Token string
+	Name  string
+	// Score counts points earned by catching players
+	Score int
+	// TunnelWait is set on the ticks a ghost sits out in a tunnel, where
+	// ghosts move at half speed
+	TunnelWait bool
+}
+
+// IsHuman reports whether a participant has claimed the ghost
+func (g *Ghost) IsHuman() bool {
+	return g.Token != ""
+}
+
+// GhostState represents the serializable state of a ghost seat
+type GhostState struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	Position Position `json:"position"`
+	Human    bool     `json:"human"`
+	Score    int      `json:"score"`
 }
 
 // Game represents the core game entity
 type Game struct {
 	ID         string
-	Board      [][]rune
-	Player     Position
-	Ghosts     []Ghost
-	Score      int
-	DotsLeft   int
-	GameOver   bool
-	PlayerDir  Direction
+	Board      Board
+	Players    []Player
+	MaxPlayers int
+	// Versus games let participants claim ghosts
+	Versus   bool
+	Ghosts   []Ghost
+	GameOver bool
+	Rules    GameRules
+	// DotsEaten counts the dots collected, for the bonus fruit thresholds
+	DotsEaten int
+	// FruitsSpawned counts the bonus fruit thresholds passed so far
+	FruitsSpawned int
+	// Fruit is the bonus fruit on the board, if any
+	Fruit *Fruit
+	// FruitCollected records the bonus fruit collected, oldest first
+	FruitCollected []CollectedFruit
+	// Maze names the board layout the game was created with
+	Maze string
+	// Headless games have no game loop and advance one tick per Step
+	Headless bool
+	// SpectatorToken grants read-only access to the game
+	SpectatorToken string
+	// Owner identifies the client that created the game, if it said
+	Owner string
+	// Spectators maps viewer IDs to when they last fetched the game
+	Spectators map[string]time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
 // GameState represents the serializable game state for API responses
 type GameState struct {
-	Board    [][]string `json:"board"`
-	Player   Position   `json:"player"`
-	Ghosts   []Position `json:"ghosts"`
-	Score    int        `json:"score"`
-	DotsLeft int        `json:"dotsLeft"`
-	GameOver bool       `json:"gameOver"`
-	Won      bool       `json:"won"`
+	// Board is a snapshot of the game's board, encoded as rows of
+	// one-character cells
+	Board Board `json:"board"`
+	// Player is the host's position, kept for single-player clients
+	Player     Position      `json:"player"`
+	Players    []PlayerState `json:"players"`
+	MaxPlayers int           `json:"maxPlayers"`
+	Ghosts     []Position    `json:"ghosts"`
+	// GhostSeats describes every ghost in versus games
+	GhostSeats []GhostState `json:"ghostSeats,omitempty"`
+	// Score is the combined score of all players
+	Score    int      `json:"score"`
+	DotsLeft int      `json:"dotsLeft"`
+	GameOver bool     `json:"gameOver"`
+	Won      bool     `json:"won"`
+	Winners  []string `json:"winners,omitempty"`
+	// WinningSide is "players" or "ghosts" once a versus game has finished
+	WinningSide string `json:"winningSide,omitempty"`
+	Preset      string `json:"preset"`
+	Maze        string `json:"maze"`
+	Headless    bool   `json:"headless,omitempty"`
+	Spectators  int    `json:"spectators"`
+	// Announcement is a message from the server operators, if any
+	Announcement string `json:"announcement,omitempty"`
+	// Fruit is the bonus fruit waiting to be collected, if any
+	Fruit *Fruit `json:"fruit,omitempty"`
+	// FruitCollected lists the bonus fruit collected so far
+	FruitCollected []CollectedFruit `json:"fruitCollected,omitempty"`
 }
 
-// ToGameState converts Game to GameState
-func (g *Game) ToGameState(width, height int) GameState {
-	board := make([][]string, height)
-	for i := 0; i < height; i++ {
-		board[i] = make([]string, width)
-		for j := 0; j < width; j++ {
-			board[i][j] = string(g.Board[i][j])
-		}
-	}
+// DotsLeft returns the number of dots still on the board
+func (g *Game) DotsLeft() int {
+	return g.Board.Dots()
+}
 
+// ToGameState converts Game to GameState
+func (g *Game) ToGameState() GameState {
 	ghostPositions := make([]Position, len(g.Ghosts))
 	for i, ghost := range g.Ghosts {
 		ghostPositions[i] = ghost.Position
 	}
 
+	players := make([]PlayerState, len(g.Players))
+	for i, p := range g.Players {
+		players[i] = PlayerState{
+			ID:        p.ID,
+			Name:      p.Name,
+			Position:  p.Position,
+			Score:     p.Score,
+			Lives:     p.Lives,
+			Autopilot: p.Autopilot,
+		}
+	}
+
+	var ghostSeats []GhostState
+	if g.Versus {
+		ghostSeats = make([]GhostState, len(g.Ghosts))
+		for i, ghost := range g.Ghosts {
+			ghostSeats[i] = GhostState{
+				ID:       ghost.ID,
+				Name:     ghost.Name,
+				Position: ghost.Position,
+				Human:    ghost.IsHuman(),
+				Score:    ghost.Score,
+			}
+		}
+	}
+
+	var fruit *Fruit
+	if g.Fruit != nil {
+		f := *g.Fruit
+		fruit = &f
+	}
+
 	return GameState{
-		Board:    board,
-		Player:   g.Player,
-		Ghosts:   ghostPositions,
-		Score:    g.Score,
-		DotsLeft: g.DotsLeft,
-		GameOver: g.GameOver,
-		Won:      g.DotsLeft == 0,
+		Board:       g.Board.Clone(),
+		Player:      g.Host().Position,
+		Players:     players,
+		MaxPlayers:  g.MaxPlayers,
+		Ghosts:      ghostPositions,
+		GhostSeats:  ghostSeats,
+		Score:       g.TotalScore(),
+		DotsLeft:    g.DotsLeft(),
+		GameOver:    g.GameOver,
+		Won:         g.DotsLeft() == 0,
+		Winners:     g.Winners(),
+		WinningSide: g.WinningSide(),
+		Preset:      g.Rules.Preset,
+		Maze:        g.Maze,
+		Headless:    g.Headless,
+		Spectators:  len(g.Spectators),
+		Fruit:       fruit,
+		// Collected fruit is only ever appended to, so the state can share
+		// the records
+		FruitCollected: g.FruitCollected[:len(g.FruitCollected):len(g.FruitCollected)],
 	}
 }
 
-// IsValidPosition checks if a position is valid and not a wall
-func (g *Game) IsValidPosition(pos Position, width, height int) bool {
-	if pos.X < 0 || pos.X >= width || pos.Y < 0 || pos.Y >= height {
-		return false
+// Clone returns a copy of the game that can be changed without affecting
+// the original
+func (g *Game) Clone() *Game {
+	clone := *g
+	clone.Board = g.Board.Clone()
+	clone.Players = append([]Player(nil), g.Players...)
+	clone.Ghosts = append([]Ghost(nil), g.Ghosts...)
+	if g.Fruit != nil {
+		fruit := *g.Fruit
+		clone.Fruit = &fruit
+	}
+	// Collected fruit is only ever appended to, so the copies can share
+	// the records
+	clone.FruitCollected = g.FruitCollected[:len(g.FruitCollected):len(g.FruitCollected)]
+	clone.Spectators = maps.Clone(g.Spectators)
+	return &clone
+}
+
+// IsFinished reports whether the game has been lost or won
+func (g *Game) IsFinished() bool {
+	return g.GameOver || g.DotsLeft() == 0
+}
+
+// WatchedBy records that viewerID fetched the game at now
+func (g *Game) WatchedBy(viewerID string, now time.Time) {
+	if g.Spectators == nil {
+		g.Spectators = make(map[string]time.Time)
 	}
-	return g.Board[pos.Y][pos.X] != '#'
+	g.Spectators[viewerID] = now
+}
+
+// PruneSpectators forgets viewers that have not fetched the game since cutoff
+func (g *Game) PruneSpectators(cutoff time.Time) {
+	for id, seen := range g.Spectators {
+		if seen.Before(cutoff) {
+			delete(g.Spectators, id)
+		}
+	}
+}
+
+// IsValidPosition checks if a position is valid and not a wall
+func (g *Game) IsValidPosition(pos Position) bool {
+	return !g.Board.IsWall(pos)
 }
 
 // GameService defines the interface for game business logic
 type GameService interface {
 	// CreateGame creates a new game session
-	CreateGame(ctx context.Context, sessionID string) (*Game, error)
-	
+	CreateGame(ctx context.Context, sessionID string, opts GameOptions) (*Game, error)
+
 	// GetGame retrieves a game by session ID
 	GetGame(ctx context.Context, sessionID string) (*Game, error)
-	
-	// SetPlayerDirection sets the player's movement direction
-	SetPlayerDirection(ctx context.Context, sessionID string, dir Direction) error
-	
+
+	// JoinGame adds a player to an existing game and returns it, including
+	// the token that authenticates its moves
+	JoinGame(ctx context.Context, sessionID string, name string) (*Player, error)
+
+	// ClaimGhost hands control of a server-driven ghost in a versus game to
+	// a participant and returns it, including its token
+	ClaimGhost(ctx context.Context, sessionID string, name string) (*Ghost, error)
+
+	// ListVersusLobby lists running versus games with unclaimed ghosts
+	ListVersusLobby(ctx context.Context) ([]GameSummary, error)
+
+	// ListOwnedGames lists the games created by owner, oldest first
+	ListOwnedGames(ctx context.Context, owner string) ([]GameSummary, error)
+
+	// WatchGame retrieves the state of the game granted by spectatorToken
+	// and counts viewerID as one of its spectators
+	WatchGame(ctx context.Context, spectatorToken string, viewerID string) (*GameState, error)
+
+	// ListLiveGames lists running games, highest score first
+	ListLiveGames(ctx context.Context) ([]LiveGame, error)
+
+	// SetPlayerDirection sets the movement direction of the player or
+	// claimed ghost authenticated by playerToken. Single-player games that
+	// are not versus games also accept an empty token for the host.
+	SetPlayerDirection(ctx context.Context, sessionID string, playerToken string, dir Direction) error
+
+	// SetAutopilot hands the player authenticated by playerToken to the named
+	// autopilot strategy, or back to human input when strategy is empty
+	SetAutopilot(ctx context.Context, sessionID string, playerToken string, strategy string) error
+
+	// Step applies a bot's action to a headless game and advances it by
+	// one tick. DirectionNone keeps the current direction.
+	Step(ctx context.Context, sessionID string, playerToken string, dir Direction) (*StepResult, error)
+
+	// StepMany applies several bot actions, each to its own headless game,
+	// returning one outcome per action in order
+	StepMany(ctx context.Context, actions []StepAction) []StepOutcome
+
 	// GetGameState retrieves the current game state
 	GetGameState(ctx context.Context, sessionID string) (*GameState, error)
-	
-	// RestartGame restarts a game session
-	RestartGame(ctx context.Context, sessionID string) (*Game, error)
-	
+
+	// RestartGame restarts a game session with the same rules. The host
+	// must present its player token.
+	RestartGame(ctx context.Context, sessionID string, playerToken string) (*Game, error)
+
 	// DeleteGame removes a game session
 	DeleteGame(ctx context.Context, sessionID string) error
-	
+
+	// DeleteGameAsHost removes a game session for its host, who must
+	// present its player token
+	DeleteGameAsHost(ctx context.Context, sessionID string, playerToken string) error
+
 	// StartGameLoop starts the game loop for a session
 	StartGameLoop(ctx context.Context, sessionID string) error
 }
 
 // GameRepository defines the interface for game storage
 type GameRepository interface {
-	// Save persists a game to storage
+	// Save persists a game to storage. Changes the caller makes to game
+	// afterwards are not stored until it is saved again. Saving a deleted
+	// game stores it again, so callers that must not bring back a game
+	// another goroutine deletes hold a lock across loading and saving it.
 	Save(ctx context.Context, game *Game) error
-	
-	// FindByID retrieves a game by ID
+
+	// FindByID retrieves a game by ID. The game is a copy owned by the
+	// caller, which it may change and save.
 	FindByID(ctx context.Context, id string) (*Game, error)
-	
+
 	// Delete removes a game from storage
 	Delete(ctx context.Context, id string) error
-	
+
 	// Exists checks if a game exists
 	Exists(ctx context.Context, id string) bool
+
+	// List returns copies of every stored game
+	List(ctx context.Context) ([]*Game, error)
+
+	// Count returns the number of stored games
+	Count(ctx context.Context) (int, error)
+
+	// Range calls fn with a copy of every stored game until fn returns
+	// false. Games saved or deleted while it runs may not be seen.
+	Range(ctx context.Context, fn func(game *Game) bool) error
 }
 
+// GameExpiry is implemented by repositories that drop games some time
+// after they were last saved
+type GameExpiry interface {
+	// ExpiresAt returns when a stored game expires, and false when it
+	// never does
+	ExpiresAt(ctx context.Context, id string) (time.Time, bool)
+}
@@ -0,0 +1,144 @@
+package domain
+
+// MaxPlayers is the largest number of players that can share a game
+const MaxPlayers = 4
+
+// Player is a Pacman controlled by one participant of a game
+type Player struct {
+	// ID identifies the player within its game, e.g. "p1"
+	ID string
+	// Token authenticates requests that control this player. It is only
+	// handed to the participant that created or joined as this player.
+	Token     string
+	Name      string
+	Position  Position
+	Direction Direction
+	Spawn     Position
+	Score     int
+	Lives     int
+	// Autopilot names the strategy steering the player on every tick. An
+	// empty autopilot leaves the player to human input.
+	Autopilot string
+}
+
+// PlayerController steers a player without human input. It is consulted
+// on every tick, before players move.
+type PlayerController interface {
+	// NextDirection returns the direction player should move in next, or
+	// DirectionNone to keep its current direction
+	NextDirection(game *Game, player *Player) Direction
+}
+
+// IsAlive reports whether the player still has lives left
+func (p *Player) IsAlive() bool {
+	return p.Lives > 0
+}
+
+// PlayerState represents the serializable state of a player
+type PlayerState struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	Position Position `json:"position"`
+	Score    int      `json:"score"`
+	Lives    int      `json:"lives"`
+	// Autopilot names the strategy steering the player, if any
+	Autopilot string `json:"autopilot,omitempty"`
+}
+
+// Host returns the player that created the game
+func (g *Game) Host() *Player {
+	return &g.Players[0]
+}
+
+// PlayerByToken returns the player authenticated by token
+func (g *Game) PlayerByToken(token string) (*Player, bool) {
+	for i := range g.Players {
+		if g.Players[i].Token == token {
+			return &g.Players[i], true
+		}
+	}
+	return nil, false
+}
+
+// TotalScore returns the combined score of all players
+func (g *Game) TotalScore() int {
+	total := 0
+	for _, p := range g.Players {
+		total += p.Score
+	}
+	return total
+}
+
+// GhostByToken returns the claimed ghost controlled with token
+func (g *Game) GhostByToken(token string) (*Ghost, bool) {
+	if token == "" {
+		return nil, false
+	}
+	for i := range g.Ghosts {
+		if g.Ghosts[i].Token == token {
+			return &g.Ghosts[i], true
+		}
+	}
+	return nil, false
+}
+
+// OpenGhostSeats returns the number of ghosts that can still be claimed
+func (g *Game) OpenGhostSeats() int {
+	if !g.Versus {
+		return 0
+	}
+	open := 0
+	for i := range g.Ghosts {
+		if !g.Ghosts[i].IsHuman() {
+			open++
+		}
+	}
+	return open
+}
+
+// WinningSide returns "players" or "ghosts" once a versus game has
+// finished, or an empty string otherwise
+func (g *Game) WinningSide() string {
+	if !g.Versus || !g.IsFinished() {
+		return ""
+	}
+	if g.GameOver {
+		return "ghosts"
+	}
+	return "players"
+}
+
+// Winners returns the IDs of the participants with the highest score once
+// the game is finished, or nil while it is still running. In versus games
+// that the ghosts won, the winners are the ghosts' controllers instead.
+func (g *Game) Winners() []string {
+	if !g.IsFinished() {
+		return nil
+	}
+
+	best := -1
+	var winners []string
+	consider := func(id string, score int) {
+		switch {
+		case score > best:
+			best = score
+			winners = []string{id}
+		case score == best:
+			winners = append(winners, id)
+		}
+	}
+
+	if g.WinningSide() == "ghosts" {
+		for _, ghost := range g.Ghosts {
+			if ghost.IsHuman() {
+				consider(ghost.ID, ghost.Score)
+			}
+		}
+		return winners
+	}
+
+	for _, p := range g.Players {
+		consider(p.ID, p.Score)
+	}
+	return winners
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MinRoomCapacity is the smallest number of members a room can be opened for
+const MinRoomCapacity = 2
+
+// RoomStatus describes where a room is in its lifecycle
+type RoomStatus string
+
+const (
+	// RoomWaiting rooms accept members and wait for the host to start
+	RoomWaiting RoomStatus = "waiting"
+	// RoomCountdown rooms are about to start their game
+	RoomCountdown RoomStatus = "countdown"
+	// RoomPlaying rooms have a running game
+	RoomPlaying RoomStatus = "playing"
+)
+
+// RoomSettings holds the game settings the host of a room controls
+type RoomSettings struct {
+	// Preset names the rules preset. An empty preset selects the default.
+	Preset string `json:"preset,omitempty"`
+	// Maze names the board layout. An empty maze selects the default.
+	Maze string `json:"maze,omitempty"`
+	// Capacity is the number of members the room admits
+	Capacity int `json:"capacity"`
+}
+
+// RoomMember is a participant waiting in a room
+type RoomMember struct {
+	// ID identifies the member within its room, e.g. "m1"
+	ID   string
+	Name string
+	// Token authenticates requests made by this member
+	Token string
+	Ready bool
+	// PlayerToken controls the member's player once the game has started
+	PlayerToken string
+	JoinedAt    time.Time
+}
+
+// Room groups participants before they play a game together. The room ID
+// doubles as the session ID of the game it starts.
+type Room struct {
+	ID       string
+	Name     string
+	HostID   string
+	Settings RoomSettings
+	Members  []RoomMember
+	Status   RoomStatus
+	// Joined counts the members that have ever joined and numbers new ones
+	Joined int
+	// StartsAt is when the countdown ends and the game loop starts
+	StartsAt  time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RoomOptions holds the settings chosen by the host when creating a room
+type RoomOptions struct {
+	Name     string
+	HostName string
+	Settings RoomSettings
+}
+
+// RoomMemberState represents the public state of a room member
+type RoomMemberState struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Ready bool   `json:"ready"`
+	Host  bool   `json:"host"`
+}
+
+// RoomState represents the serializable room state for API responses
+type RoomState struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	HostID   string            `json:"hostId"`
+	Settings RoomSettings      `json:"settings"`
+	Members  []RoomMemberState `json:"members"`
+	Status   RoomStatus        `json:"status"`
+	StartsAt *time.Time        `json:"startsAt,omitempty"`
+	// SessionID is the session of the room's game once the countdown begins
+	SessionID string `json:"sessionId,omitempty"`
+	// PlayerToken is only included for the member the state is built for
+	PlayerToken string    `json:"playerToken,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ToRoomState converts Room to RoomState. When memberToken belongs to a
+// member, their player token is included.
+func (r *Room) ToRoomState(memberToken string) RoomState {
+	members := make([]RoomMemberState, len(r.Members))
+	for i, m := range r.Members {
+		members[i] = RoomMemberState{
+			ID:    m.ID,
+			Name:  m.Name,
+			Ready: m.Ready,
+			Host:  m.ID == r.HostID,
+		}
+	}
+
+	state := RoomState{
+		ID:        r.ID,
+		Name:      r.Name,
+		HostID:    r.HostID,
+		Settings:  r.Settings,
+		Members:   members,
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt,
+	}
+
+	if r.Status == RoomCountdown {
+		startsAt := r.StartsAt
+		state.StartsAt = &startsAt
+	}
+	if r.Status != RoomWaiting {
+		state.SessionID = r.ID
+	}
+	if m, ok := r.MemberByToken(memberToken); ok {
+		state.PlayerToken = m.PlayerToken
+	}
+
+	return state
+}
+
+// MemberByToken returns the member authenticated by token
+func (r *Room) MemberByToken(token string) (*RoomMember, bool) {
+	if token == "" {
+		return nil, false
+	}
+	for i := range r.Members {
+		if r.Members[i].Token == token {
+			return &r.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// Member returns the member with the given ID
+func (r *Room) Member(id string) (*RoomMember, bool) {
+	for i := range r.Members {
+		if r.Members[i].ID == id {
+			return &r.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// IsFull reports whether the room has no free seat
+func (r *Room) IsFull() bool {
+	return len(r.Members) >= r.Settings.Capacity
+}
+
+// AllReady reports whether every member other than the host is ready
+func (r *Room) AllReady() bool {
+	for _, m := range r.Members {
+		if m.ID != r.HostID && !m.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the room that shares no members with it
+func (r *Room) Clone() *Room {
+	clone := *r
+	clone.Members = append([]RoomMember(nil), r.Members...)
+	return &clone
+}
+
+// RoomService defines the interface for matchmaking rooms
+type RoomService interface {
+	// CreateRoom opens a room and returns it with its host member
+	CreateRoom(ctx context.Context, opts RoomOptions) (*Room, *RoomMember, error)
+
+	// ListRooms lists open rooms, oldest first
+	ListRooms(ctx context.Context) ([]Room, error)
+
+	// GetRoom retrieves a room by ID
+	GetRoom(ctx context.Context, roomID string) (*Room, error)
+
+	// JoinRoom adds a member to a waiting room and returns it, including
+	// the token that authenticates its requests
+	JoinRoom(ctx context.Context, roomID string, name string) (*Room, *RoomMember, error)
+
+	// LeaveRoom removes the member authenticated by memberToken. The host
+	// role passes to the longest-waiting member and empty rooms are closed.
+	LeaveRoom(ctx context.Context, roomID string, memberToken string) error
+
+	// SetReady marks the member authenticated by memberToken as ready or not
+	SetReady(ctx context.Context, roomID string, memberToken string, ready bool) (*Room, error)
+
+	// UpdateSettings changes the game settings. Only the host may do so.
+	UpdateSettings(ctx context.Context, roomID string, memberToken string, settings RoomSettings) (*Room, error)
+
+	// StartRoom creates the room's game and begins the countdown to its
+	// game loop. Only the host may start, and only once every other member
+	// is ready.
+	StartRoom(ctx context.Context, roomID string, memberToken string) (*Room, error)
+
+	// Subscribe returns a channel that receives the room after every change.
+	// The channel is closed when ctx is done or the room is closed.
+	Subscribe(ctx context.Context, roomID string) (<-chan Room, error)
+}
+
+// RoomRepository defines the interface for room storage
+type RoomRepository interface {
+	// Save persists a room to storage
+	Save(ctx context.Context, room *Room) error
+
+	// FindByID retrieves a room by ID
+	FindByID(ctx context.Context, id string) (*Room, error)
+
+	// Delete removes a room from storage
+	Delete(ctx context.Context, id string) error
+
+	// List returns every stored room
+	List(ctx context.Context) ([]*Room, error)
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxGhosts is the largest number of ghosts a game can have
+const MaxGhosts = 6
+
+// GameRules holds the tunable gameplay parameters of a game
+type GameRules struct {
+	// Preset is the name of the preset the rules were taken from
+	Preset string
+	// TickInterval is the time between game ticks
+	TickInterval time.Duration
+	// ScorePerDot is the score awarded for collecting a dot
+	ScorePerDot int
+	// GhostCount is the number of ghosts spawned at the start of a game
+	GhostCount int
+	// GhostAggression is the percentage chance that a ghost chases the
+	// player on a given tick instead of moving randomly
+	GhostAggression int
+	// Lives is the number of times each player can be caught
+	Lives int
+	// ScorePerCatch is the score a ghost earns for catching a player
+	ScorePerCatch int
+	// Level picks the bonus fruit and the points it is worth, as levels
+	// did in the arcade game
+	Level int
+	// FruitDots lists, in increasing order, the numbers of dots collected
+	// after which a bonus fruit appears on the maze's fruit spawn tile
+	FruitDots []int
+	// FruitTicks is how many ticks a bonus fruit stays before it disappears
+	FruitTicks int
+}
+
+// Validate checks that the rules describe a playable game
+func (r GameRules) Validate() error {
+	if r.TickInterval < 10*time.Millisecond {
+		return fmt.Errorf("tick interval must be at least 10ms: %s", r.TickInterval)
+	}
+	if r.ScorePerDot < 0 {
+		return fmt.Errorf("score per dot cannot be negative: %d", r.ScorePerDot)
+	}
+	if r.GhostCount < 0 || r.GhostCount > MaxGhosts {
+		return fmt.Errorf("ghost count must be between 0 and %d: %d", MaxGhosts, r.GhostCount)
+	}
+	if r.GhostAggression < 0 || r.GhostAggression > 100 {
+		return fmt.Errorf("ghost aggression must be a percentage: %d", r.GhostAggression)
+	}
+	if r.Lives < 1 {
+		return fmt.Errorf("players need at least one life: %d", r.Lives)
+	}
+	if r.ScorePerCatch < 0 {
+		return fmt.Errorf("score per catch cannot be negative: %d", r.ScorePerCatch)
+	}
+	if r.Level < 1 {
+		return fmt.Errorf("level must be at least 1: %d", r.Level)
+	}
+	for i, dots := range r.FruitDots {
+		if dots < 1 || (i > 0 && dots <= r.FruitDots[i-1]) {
+			return fmt.Errorf("fruit dot counts must be positive and increasing: %v", r.FruitDots)
+		}
+	}
+	if len(r.FruitDots) > 0 && r.FruitTicks < 1 {
+		return fmt.Errorf("fruit must stay for at least one tick: %d", r.FruitTicks)
+	}
+	return nil
+}
+
+// GameOptions holds the settings chosen by a client when creating a game
+type GameOptions struct {
+	// Preset names the rules preset to play with. An empty preset selects
+	// the server default.
+	Preset string
+	// MaxPlayers is the number of players that can join the game, up to
+	// MaxPlayers. Zero means a single-player game.
+	MaxPlayers int
+	// PlayerName is the display name of the host
+	PlayerName string
+	// Versus lets other participants claim the ghosts
+	Versus bool
+	// Maze names the board layout. An empty maze selects the default.
+	Maze string
+	// Headless games do not tick on a timer; bots advance them with Step
+	Headless bool
+	// Autopilot names the strategy that steers the host. An empty autopilot
+	// leaves the host to human input.
+	Autopilot string
+	// Owner identifies the client creating the game, so it can list its
+	// games later. An empty owner leaves the game unlisted.
+	Owner string
+}
+
+// GameSummary is a short public description of a running game
+type GameSummary struct {
+	SessionID      string    `json:"sessionId"`
+	Preset         string    `json:"preset"`
+	Players        int       `json:"players"`
+	MaxPlayers     int       `json:"maxPlayers"`
+	OpenGhostSeats int       `json:"openGhostSeats"`
+	Score          int       `json:"score"`
+	Finished       bool      `json:"finished"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// LiveGame describes a running game to prospective spectators. It carries
+// the spectator token rather than the session ID, which grants control.
+type LiveGame struct {
+	SpectatorToken string    `json:"spectatorToken"`
+	Preset         string    `json:"preset"`
+	Players        []string  `json:"players"`
+	Score          int       `json:"score"`
+	DotsLeft       int       `json:"dotsLeft"`
+	Spectators     int       `json:"spectators"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Summary returns the public summary of the game
+func (g *Game) Summary() GameSummary {
+	return GameSummary{
+		SessionID:      g.ID,
+		Preset:         g.Rules.Preset,
+		Players:        len(g.Players),
+		MaxPlayers:     g.MaxPlayers,
+		OpenGhostSeats: g.OpenGhostSeats(),
+		Score:          g.TotalScore(),
+		Finished:       g.IsFinished(),
+		CreatedAt:      g.CreatedAt,
+	}
+}
+
+// Live returns the public description of the game for spectators
+func (g *Game) Live() LiveGame {
+	players := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		players[i] = p.Name
+		if players[i] == "" {
+			players[i] = p.ID
+		}
+	}
+
+	return LiveGame{
+		SpectatorToken: g.SpectatorToken,
+		Preset:         g.Rules.Preset,
+		Players:        players,
+		Score:          g.TotalScore(),
+		DotsLeft:       g.DotsLeft(),
+		Spectators:     len(g.Spectators),
+		CreatedAt:      g.CreatedAt,
+	}
+}
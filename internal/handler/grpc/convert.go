@@ -0,0 +1,14 @@
+package grpc
+
+import (
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// directions maps protobuf directions to domain directions
+var directions = map[gamev1.Direction]domain.Direction{
+	gamev1.Direction_DIRECTION_UP:    domain.DirectionUp,
+	gamev1.Direction_DIRECTION_DOWN:  domain.DirectionDown,
+	gamev1.Direction_DIRECTION_LEFT:  domain.DirectionLeft,
+	gamev1.Direction_DIRECTION_RIGHT: domain.DirectionRight,
+}
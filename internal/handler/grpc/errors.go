@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/siddarth/go-app/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps domain errors to the gRPC status code returned to
+// clients. Entries are checked in order with errors.Is.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{domain.ErrInvalidSessionID, codes.InvalidArgument},
+	{domain.ErrGameNotFound, codes.NotFound},
+	{domain.ErrSessionConflict, codes.AlreadyExists},
+	{domain.ErrGameOver, codes.FailedPrecondition},
+	{domain.ErrGameFull, codes.FailedPrecondition},
+	{domain.ErrPlayerEliminated, codes.FailedPrecondition},
+	{domain.ErrNoGhostSeat, codes.FailedPrecondition},
+	{domain.ErrNotHeadless, codes.FailedPrecondition},
+	{domain.ErrInvalidPlayerToken, codes.PermissionDenied},
+	{domain.ErrNotGameHost, codes.PermissionDenied},
+	{domain.ErrInvalidGameOptions, codes.InvalidArgument},
+	{domain.ErrInvalidDirection, codes.InvalidArgument},
+	{domain.ErrUnknownPreset, codes.InvalidArgument},
+	{domain.ErrUnknownMaze, codes.InvalidArgument},
+	{domain.ErrUnknownStrategy, codes.InvalidArgument},
+	{domain.ErrTooManyGames, codes.ResourceExhausted},
+	{domain.ErrDraining, codes.Unavailable},
+}
+
+// serviceError converts an error returned by the game service to a gRPC
+// status error. Unknown errors map to Internal with the fallback message so
+// internals are not leaked to clients.
+func serviceError(logger *slog.Logger, fallback string, err error) error {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, e.err.Error())
+		}
+	}
+
+	logger.Error("rpc error",
+		"message", fallback,
+		"error", err,
+	)
+	return status.Error(codes.Internal, fallback)
+}
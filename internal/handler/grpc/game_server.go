@@ -0,0 +1,301 @@
+// Package grpc exposes the game service over gRPC
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/wire"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// GameServer implements the gRPC GameService on top of domain.GameService
+type GameServer struct {
+	gamev1.UnimplementedGameServiceServer
+	gameService   domain.GameService
+	watchInterval time.Duration
+	logger        *slog.Logger
+	tracer        trace.Tracer
+	// stopping is closed by Stop to end WatchState streams
+	stopping chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGameServer creates a new gRPC game server
+func NewGameServer(gameService domain.GameService, cfg config.GRPCConfig, logger *slog.Logger) *GameServer {
+	return &GameServer{
+		gameService:   gameService,
+		watchInterval: cfg.WatchInterval,
+		logger:        logger,
+		tracer:        otel.Tracer("grpc-game-server"),
+		stopping:      make(chan struct{}),
+	}
+}
+
+// Register registers the game service with a gRPC server
+func (s *GameServer) Register(r grpc.ServiceRegistrar) {
+	gamev1.RegisterGameServiceServer(r, s)
+}
+
+// Stop ends all WatchState streams, which would otherwise hold up a
+// graceful shutdown until their games finish
+func (s *GameServer) Stop() {
+	s.stopOnce.Do(func() { close(s.stopping) })
+}
+
+// Start creates a game hosted by the caller and starts its game loop
+func (s *GameServer) Start(ctx context.Context, req *gamev1.StartRequest) (*gamev1.StartResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "Start")
+	defer span.End()
+
+	sessionID := req.GetSessionId()
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano())
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	game, err := s.gameService.CreateGame(ctx, sessionID, domain.GameOptions{
+		Preset:     req.GetPreset(),
+		MaxPlayers: int(req.GetMaxPlayers()),
+		PlayerName: req.GetName(),
+		Versus:     req.GetVersus(),
+		Maze:       req.GetMaze(),
+		Headless:   req.GetHeadless(),
+		Autopilot:  req.GetAutopilot(),
+	})
+	if err != nil {
+		return nil, serviceError(s.logger, "Failed to create game", err)
+	}
+
+	// Read the state before the loop can change the game
+	state := stateOf(game)
+	if err := s.startGameLoop(ctx, game); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "game started",
+		"session_id", sessionID,
+		"transport", "grpc",
+	)
+
+	host := game.Host()
+	return &gamev1.StartResponse{
+		SessionId:      sessionID,
+		PlayerId:       host.ID,
+		PlayerToken:    host.Token,
+		SpectatorToken: game.SpectatorToken,
+		State:          state,
+	}, nil
+}
+
+// GetState returns the current state of a game
+func (s *GameServer) GetState(ctx context.Context, req *gamev1.GetStateRequest) (*gamev1.GameState, error) {
+	ctx, span := s.tracer.Start(ctx, "GetState")
+	defer span.End()
+
+	sessionID, err := requireSession(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	state, err := s.gameService.GetGameState(ctx, sessionID)
+	if err != nil {
+		return nil, serviceError(s.logger, "Failed to get game state", err)
+	}
+
+	return wire.ToProto(state), nil
+}
+
+// Move changes the direction of a player, or of a claimed ghost
+func (s *GameServer) Move(ctx context.Context, req *gamev1.MoveRequest) (*gamev1.MoveResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "Move")
+	defer span.End()
+
+	sessionID, err := requireSession(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	dir, ok := directions[req.GetDirection()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, domain.ErrInvalidDirection.Error())
+	}
+
+	span.SetAttributes(
+		attribute.String("session.id", sessionID),
+		attribute.String("direction", dir.String()),
+	)
+
+	if err := s.gameService.SetPlayerDirection(ctx, sessionID, req.GetPlayerToken(), dir); err != nil {
+		return nil, serviceError(s.logger, "Failed to move player", err)
+	}
+
+	return &gamev1.MoveResponse{}, nil
+}
+
+// Restart starts a game again, keeping its rules and players
+func (s *GameServer) Restart(ctx context.Context, req *gamev1.RestartRequest) (*gamev1.StartResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "Restart")
+	defer span.End()
+
+	sessionID, err := requireSession(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	game, err := s.gameService.RestartGame(ctx, sessionID, req.GetPlayerToken())
+	if err != nil {
+		return nil, serviceError(s.logger, "Failed to restart game", err)
+	}
+
+	// Read the state before the loop can change the game
+	state := stateOf(game)
+	if err := s.startGameLoop(ctx, game); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "game restarted",
+		"session_id", sessionID,
+		"transport", "grpc",
+	)
+
+	return &gamev1.StartResponse{
+		SessionId:      sessionID,
+		SpectatorToken: game.SpectatorToken,
+		State:          state,
+	}, nil
+}
+
+// Delete stops a game and removes it for its host
+func (s *GameServer) Delete(ctx context.Context, req *gamev1.DeleteRequest) (*gamev1.DeleteResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	sessionID, err := requireSession(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if err := s.gameService.DeleteGameAsHost(ctx, sessionID, req.GetPlayerToken()); err != nil {
+		return nil, serviceError(s.logger, "Failed to delete game", err)
+	}
+
+	return &gamev1.DeleteResponse{}, nil
+}
+
+// WatchState sends the state of a game whenever it changes. The stream ends
+// after the state in which the game finishes, or with Unavailable when the
+// server shuts down.
+func (s *GameServer) WatchState(req *gamev1.WatchStateRequest, stream gamev1.GameService_WatchStateServer) error {
+	ctx := stream.Context()
+
+	var fetch func() (*domain.GameState, error)
+	switch {
+	case req.GetSessionId() != "":
+		sessionID := req.GetSessionId()
+		fetch = func() (*domain.GameState, error) {
+			return s.gameService.GetGameState(ctx, sessionID)
+		}
+	case req.GetSpectatorToken() != "":
+		viewerID := req.GetViewerId()
+		if viewerID == "" {
+			if p, ok := peer.FromContext(ctx); ok {
+				viewerID = p.Addr.String()
+			}
+		}
+		token := req.GetSpectatorToken()
+		fetch = func() (*domain.GameState, error) {
+			return s.gameService.WatchGame(ctx, token, viewerID)
+		}
+	default:
+		return status.Error(codes.InvalidArgument, "session ID or spectator token required")
+	}
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var last *gamev1.GameState
+	for {
+		state, err := fetch()
+		if err != nil {
+			return serviceError(s.logger, "Failed to watch game", err)
+		}
+
+		// Only send states that changed since the last one
+		if next := wire.ToProto(state); last == nil || !proto.Equal(last, next) {
+			if err := stream.Send(next); err != nil {
+				return err
+			}
+			last = next
+		}
+		if state.GameOver || state.Won {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server shutting down")
+		case <-ticker.C:
+		}
+	}
+}
+
+// startGameLoop starts the loop of a game that is not headless, deleting
+// the game if the loop cannot start
+func (s *GameServer) startGameLoop(ctx context.Context, game *domain.Game) error {
+	if game.Headless {
+		return nil
+	}
+
+	if err := s.gameService.StartGameLoop(ctx, game.ID); err != nil {
+		s.logger.ErrorContext(ctx, "failed to start game loop",
+			"session_id", game.ID,
+			"error", err,
+		)
+		// Don't keep a game around that will never tick
+		if err := s.gameService.DeleteGame(ctx, game.ID); err != nil {
+			s.logger.WarnContext(ctx, "failed to delete game without loop",
+				"session_id", game.ID,
+				"error", err,
+			)
+		}
+		return serviceError(s.logger, "Failed to start game loop", err)
+	}
+	return nil
+}
+
+// requireSession rejects requests without a session ID
+func requireSession(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", status.Error(codes.InvalidArgument, "session ID required")
+	}
+	return sessionID, nil
+}
+
+// stateOf returns the protobuf state of game
+func stateOf(game *domain.Game) *gamev1.GameState {
+	state := game.ToGameState()
+	return wire.ToProto(&state)
+}
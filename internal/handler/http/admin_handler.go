@@ -0,0 +1,228 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultAnnouncementTTL is how long a broadcast message is shown when the
+// operator does not say
+const defaultAnnouncementTTL = 5 * time.Minute
+
+// AdminHandler handles HTTP requests of server operators. Every route but
+// the admin page needs the admin token.
+type AdminHandler struct {
+	admin       domain.GameAdmin
+	gameService domain.GameService
+	token       string
+	logger      *slog.Logger
+	tracer      trace.Tracer
+}
+
+// NewAdminHandler creates a new admin handler authenticating operators
+// with token
+func NewAdminHandler(admin domain.GameAdmin, gameService domain.GameService, token string, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		admin:       admin,
+		gameService: gameService,
+		token:       token,
+		logger:      logger,
+		tracer:      otel.Tracer("admin-handler"),
+	}
+}
+
+// SessionsResponse lists the game sessions of the server
+type SessionsResponse struct {
+	Sessions []domain.SessionInfo `json:"sessions"`
+}
+
+// BroadcastRequest represents a message to show every client
+type BroadcastRequest struct {
+	Message string `json:"message" binding:"required"`
+	// TTLSeconds is how long the message is shown, five minutes by default
+	TTLSeconds int `json:"ttlSeconds,omitempty"`
+}
+
+// RegisterRoutes registers the admin page and API routes
+func (h *AdminHandler) RegisterRoutes(r *gin.Engine) {
+	// The page holds no data and asks for the token itself
+	r.GET("/admin", h.ServePage)
+
+	admin := r.Group("/admin", middleware.AdminAuth(h.token, h.logger))
+	{
+		admin.GET("/status", h.Status)
+		admin.GET("/sessions", h.ListSessions)
+		admin.GET("/sessions/:id", h.InspectSession)
+		admin.POST("/sessions/:id/stop", h.StopSession)
+		admin.DELETE("/sessions/:id", h.DeleteSession)
+		admin.POST("/broadcast", h.Broadcast)
+		admin.DELETE("/broadcast", h.ClearBroadcast)
+		admin.POST("/drain", h.StartDrain)
+		admin.DELETE("/drain", h.StopDrain)
+	}
+}
+
+// ServePage serves the admin page
+func (h *AdminHandler) ServePage(c *gin.Context) {
+	c.File("./static/admin.html")
+}
+
+// Status handles requests for a summary of the server's games
+func (h *AdminHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.admin.Status(c.Request.Context()))
+}
+
+// ListSessions handles listing every game session, oldest first
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminListSessions")
+	defer span.End()
+
+	sessions, err := h.admin.ListSessions(ctx)
+	if err != nil {
+		h.respondServiceError(c, "Failed to list sessions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SessionsResponse{Sessions: sessions})
+}
+
+// InspectSession handles requests for the full state of a game session
+func (h *AdminHandler) InspectSession(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminInspectSession")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	detail, err := h.admin.InspectSession(ctx, sessionID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to inspect session", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// StopSession handles force-stopping the game loop of a session. The game
+// is kept, frozen, until it is deleted.
+func (h *AdminHandler) StopSession(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminStopSession")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if err := h.admin.StopSession(ctx, sessionID); err != nil {
+		h.respondServiceError(c, "Failed to stop session", err)
+		return
+	}
+
+	detail, err := h.admin.InspectSession(ctx, sessionID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to inspect session", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, detail.SessionInfo)
+}
+
+// DeleteSession handles stopping a game session and removing it
+func (h *AdminHandler) DeleteSession(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminDeleteSession")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if _, err := h.gameService.GetGame(ctx, sessionID); err != nil {
+		h.respondServiceError(c, "Failed to get game", err)
+		return
+	}
+
+	if err := h.gameService.DeleteGame(ctx, sessionID); err != nil {
+		h.respondServiceError(c, "Failed to delete game", err)
+		return
+	}
+
+	h.logger.WarnContext(ctx, "game deleted by operator", "session_id", sessionID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// Broadcast handles showing a message to every client
+func (h *AdminHandler) Broadcast(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminBroadcast")
+	defer span.End()
+
+	var req BroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	ttl := defaultAnnouncementTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	announcement, err := h.admin.Broadcast(ctx, req.Message, ttl)
+	if err != nil {
+		h.respondServiceError(c, "Failed to broadcast message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, announcement)
+}
+
+// ClearBroadcast handles withdrawing the broadcast message
+func (h *AdminHandler) ClearBroadcast(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminClearBroadcast")
+	defer span.End()
+
+	if _, err := h.admin.Broadcast(ctx, "", 0); err != nil {
+		h.respondServiceError(c, "Failed to clear message", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StartDrain handles draining the server: new games are refused while
+// running ones carry on
+func (h *AdminHandler) StartDrain(c *gin.Context) {
+	h.setDraining(c, true)
+}
+
+// StopDrain handles accepting new games again after a drain
+func (h *AdminHandler) StopDrain(c *gin.Context) {
+	h.setDraining(c, false)
+}
+
+// setDraining changes whether the server drains and responds with its
+// status
+func (h *AdminHandler) setDraining(c *gin.Context, draining bool) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "AdminSetDraining")
+	defer span.End()
+
+	h.admin.SetDraining(ctx, draining)
+	c.JSON(http.StatusOK, h.admin.Status(ctx))
+}
+
+// respondServiceError sends an error response for an error returned by the
+// game service
+func (h *AdminHandler) respondServiceError(c *gin.Context, fallback string, err error) {
+	writeServiceError(c, h.logger, fallback, err)
+}
+
+// respondError sends an error response
+func (h *AdminHandler) respondError(c *gin.Context, statusCode int, message string, err error) {
+	writeError(c, h.logger, statusCode, message, err)
+}
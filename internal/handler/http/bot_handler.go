@@ -0,0 +1,211 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxBatchSize caps the episodes started or stepped by one batch request
+const maxBatchSize = 64
+
+// StepRequest represents a bot action. An empty direction keeps going the
+// current way.
+type StepRequest struct {
+	Direction string `json:"direction,omitempty"`
+}
+
+// BatchStartRequest represents a request to start several headless games
+type BatchStartRequest struct {
+	Count  int    `json:"count" binding:"required"`
+	Preset string `json:"preset,omitempty"`
+	Maze   string `json:"maze,omitempty"`
+}
+
+// BatchStartResponse lists the headless games started by a batch
+type BatchStartResponse struct {
+	Episodes []StartGameResponse `json:"episodes"`
+}
+
+// BatchStep is one action of a batch step request
+type BatchStep struct {
+	SessionID   string `json:"sessionId" binding:"required"`
+	PlayerToken string `json:"playerToken,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+}
+
+// BatchStepRequest represents a request to step several headless games
+type BatchStepRequest struct {
+	Steps []BatchStep `json:"steps" binding:"required"`
+}
+
+// BatchStepResult is the outcome of one action of a batch step
+type BatchStepResult struct {
+	SessionID string `json:"sessionId"`
+	*domain.StepResult
+	Error string `json:"error,omitempty"`
+}
+
+// BatchStepResponse lists the outcomes of a batch step, in request order
+type BatchStepResponse struct {
+	Results []BatchStepResult `json:"results"`
+}
+
+// parseAction converts a bot action to a direction, treating an empty
+// action as DirectionNone
+func parseAction(s string) (domain.Direction, error) {
+	if s == "" || s == "none" {
+		return domain.DirectionNone, nil
+	}
+	dir, ok := domain.ParseDirection(s)
+	if !ok {
+		return domain.DirectionNone, fmt.Errorf("%w: %s", domain.ErrInvalidDirection, s)
+	}
+	return dir, nil
+}
+
+// Step handles advancing a headless game by one tick
+func (h *GameHandler) Step(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "Step")
+	defer span.End()
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		h.respondError(c, http.StatusBadRequest, "Session ID required", nil)
+		return
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	// The request body is optional
+	var req StepRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	dir, err := parseAction(req.Direction)
+	if err != nil {
+		h.respondServiceError(c, "Invalid direction", err)
+		return
+	}
+
+	result, err := h.gameService.Step(ctx, sessionID, c.GetHeader("X-Player-Token"), dir)
+	if err != nil {
+		h.respondServiceError(c, "Failed to step game", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BatchStart handles starting several headless games at once
+func (h *GameHandler) BatchStart(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "BatchStart")
+	defer span.End()
+
+	var req BatchStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Count < 1 || req.Count > maxBatchSize {
+		err := fmt.Errorf("%w: count must be between 1 and %d", domain.ErrInvalidGameOptions, maxBatchSize)
+		h.respondServiceError(c, "Invalid batch size", err)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", req.Count))
+
+	prefix := fmt.Sprintf("session-%d", time.Now().UnixNano())
+	episodes := make([]StartGameResponse, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		sessionID := fmt.Sprintf("%s-%d", prefix, i)
+		game, err := h.gameService.CreateGame(ctx, sessionID, domain.GameOptions{
+			Preset:   req.Preset,
+			Maze:     req.Maze,
+			Headless: true,
+		})
+		if err != nil {
+			// Don't leave half a batch behind
+			for _, e := range episodes {
+				if err := h.gameService.DeleteGame(ctx, e.SessionID); err != nil {
+					h.logger.WarnContext(ctx, "failed to delete batch game",
+						"session_id", e.SessionID,
+						"error", err,
+					)
+				}
+			}
+			h.respondServiceError(c, "Failed to create game", err)
+			return
+		}
+
+		episodes = append(episodes, StartGameResponse{
+			SessionID:   sessionID,
+			PlayerID:    game.Host().ID,
+			PlayerToken: game.Host().Token,
+			State:       game.ToGameState(),
+		})
+	}
+
+	h.logger.InfoContext(ctx, "headless games started", "count", req.Count)
+
+	c.JSON(http.StatusOK, BatchStartResponse{Episodes: episodes})
+}
+
+// BatchStep handles stepping several headless games at once. Failed
+// actions are reported per result and do not fail the request.
+func (h *GameHandler) BatchStep(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "BatchStep")
+	defer span.End()
+
+	var req BatchStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Steps) > maxBatchSize {
+		err := fmt.Errorf("%w: at most %d steps per batch", domain.ErrInvalidGameOptions, maxBatchSize)
+		h.respondServiceError(c, "Invalid batch size", err)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", len(req.Steps)))
+
+	results := make([]BatchStepResult, len(req.Steps))
+	actions := make([]domain.StepAction, 0, len(req.Steps))
+	indexes := make([]int, 0, len(req.Steps))
+	for i, step := range req.Steps {
+		results[i].SessionID = step.SessionID
+
+		dir, err := parseAction(step.Direction)
+		if err != nil {
+			_, results[i].Error = statusForError(err, "Invalid direction")
+			continue
+		}
+
+		actions = append(actions, domain.StepAction{
+			SessionID:   step.SessionID,
+			PlayerToken: step.PlayerToken,
+			Direction:   dir,
+		})
+		indexes = append(indexes, i)
+	}
+
+	for j, outcome := range h.gameService.StepMany(ctx, actions) {
+		i := indexes[j]
+		if outcome.Err != nil {
+			_, results[i].Error = statusForError(outcome.Err, "Failed to step game")
+			continue
+		}
+		results[i].StepResult = outcome.Result
+	}
+
+	c.JSON(http.StatusOK, BatchStepResponse{Results: results})
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/middleware"
+)
+
+// errorStatuses maps domain errors to the HTTP status returned to clients.
+// Entries are checked in order with errors.Is.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{domain.ErrInvalidSessionID, http.StatusBadRequest},
+	{domain.ErrGameNotFound, http.StatusNotFound},
+	{domain.ErrRoomNotFound, http.StatusNotFound},
+	{domain.ErrGameOver, http.StatusConflict},
+	{domain.ErrSessionConflict, http.StatusConflict},
+	{domain.ErrGameFull, http.StatusConflict},
+	{domain.ErrPlayerEliminated, http.StatusConflict},
+	{domain.ErrNoGhostSeat, http.StatusConflict},
+	{domain.ErrRoomFull, http.StatusConflict},
+	{domain.ErrRoomStarted, http.StatusConflict},
+	{domain.ErrRoomNotReady, http.StatusConflict},
+	{domain.ErrNotHeadless, http.StatusConflict},
+	{domain.ErrInvalidPlayerToken, http.StatusForbidden},
+	{domain.ErrNotGameHost, http.StatusForbidden},
+	{domain.ErrInvalidMemberToken, http.StatusForbidden},
+	{domain.ErrNotRoomHost, http.StatusForbidden},
+	{domain.ErrInvalidGameOptions, http.StatusUnprocessableEntity},
+	{domain.ErrInvalidDirection, http.StatusUnprocessableEntity},
+	{domain.ErrUnknownPreset, http.StatusUnprocessableEntity},
+	{domain.ErrUnknownMaze, http.StatusUnprocessableEntity},
+	{domain.ErrUnknownStrategy, http.StatusUnprocessableEntity},
+	{domain.ErrInvalidAnnouncement, http.StatusUnprocessableEntity},
+	{domain.ErrTooManyGames, http.StatusTooManyRequests},
+	{domain.ErrTooManyRooms, http.StatusTooManyRequests},
+	{domain.ErrDraining, http.StatusServiceUnavailable},
+}
+
+// capacityRetryAfter is the Retry-After hint sent when the server has no
+// room for another game and the rate limiter is off
+const capacityRetryAfter = "5"
+
+// statusForError returns the HTTP status and client-facing message for err.
+// Unknown errors map to 500 with the fallback message so internals are not
+// leaked to clients.
+func statusForError(err error, fallback string) (int, string) {
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return e.status, e.err.Error()
+		}
+	}
+	return http.StatusInternalServerError, fallback
+}
+
+// respondServiceError sends an error response for an error returned by the
+// game service, choosing the status code from the domain error it wraps
+func (h *GameHandler) respondServiceError(c *gin.Context, fallback string, err error) {
+	writeServiceError(c, h.logger, fallback, err)
+}
+
+// writeServiceError sends an error response for a service error, choosing
+// the status code from the domain error it wraps
+func writeServiceError(c *gin.Context, logger *slog.Logger, fallback string, err error) {
+	status, message := statusForError(err, fallback)
+	if status == http.StatusTooManyRequests {
+		// Retrying is pointless before the rate limiter would let the
+		// request through again
+		retryAfter, ok := middleware.RetryAfter(c)
+		if !ok {
+			retryAfter = capacityRetryAfter
+		}
+		c.Header("Retry-After", retryAfter)
+	}
+	writeError(c, logger, status, message, err)
+}
+
+// writeError sends an error response, logging err when there is one
+func writeError(c *gin.Context, logger *slog.Logger, statusCode int, message string, err error) {
+	if err != nil {
+		logger.Error("handler error",
+			"status", statusCode,
+			"message", message,
+			"error", err,
+		)
+	}
+
+	c.JSON(statusCode, ErrorResponse{
+		Error:   http.StatusText(statusCode),
+		Message: message,
+	})
+}
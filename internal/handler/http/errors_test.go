@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/middleware"
+)
+
+// wantStatuses is the status clients get for each domain error
+var wantStatuses = map[error]int{
+	domain.ErrInvalidSessionID:    http.StatusBadRequest,
+	domain.ErrGameNotFound:        http.StatusNotFound,
+	domain.ErrRoomNotFound:        http.StatusNotFound,
+	domain.ErrGameOver:            http.StatusConflict,
+	domain.ErrSessionConflict:     http.StatusConflict,
+	domain.ErrGameFull:            http.StatusConflict,
+	domain.ErrPlayerEliminated:    http.StatusConflict,
+	domain.ErrNoGhostSeat:         http.StatusConflict,
+	domain.ErrRoomFull:            http.StatusConflict,
+	domain.ErrRoomStarted:         http.StatusConflict,
+	domain.ErrRoomNotReady:        http.StatusConflict,
+	domain.ErrNotHeadless:         http.StatusConflict,
+	domain.ErrInvalidPlayerToken:  http.StatusForbidden,
+	domain.ErrNotGameHost:         http.StatusForbidden,
+	domain.ErrInvalidMemberToken:  http.StatusForbidden,
+	domain.ErrNotRoomHost:         http.StatusForbidden,
+	domain.ErrInvalidGameOptions:  http.StatusUnprocessableEntity,
+	domain.ErrInvalidDirection:    http.StatusUnprocessableEntity,
+	domain.ErrUnknownPreset:       http.StatusUnprocessableEntity,
+	domain.ErrUnknownMaze:         http.StatusUnprocessableEntity,
+	domain.ErrUnknownStrategy:     http.StatusUnprocessableEntity,
+	domain.ErrInvalidAnnouncement: http.StatusUnprocessableEntity,
+	domain.ErrTooManyGames:        http.StatusTooManyRequests,
+	domain.ErrTooManyRooms:        http.StatusTooManyRequests,
+	domain.ErrDraining:            http.StatusServiceUnavailable,
+}
+
+func TestStatusForError(t *testing.T) {
+	for _, e := range errorStatuses {
+		if _, ok := wantStatuses[e.err]; !ok {
+			t.Errorf("%q is mapped to a status but not tested", e.err)
+		}
+	}
+
+	for err, want := range wantStatuses {
+		// Services wrap domain errors with details clients must not see
+		wrapped := fmt.Errorf("failed to do it: %w: secret detail", err)
+		status, message := statusForError(wrapped, "fallback")
+		if status != want || message != err.Error() {
+			t.Errorf("%q: got %d %q, want %d %q", err, status, message, want, err.Error())
+		}
+	}
+
+	status, message := statusForError(errors.New("disk on fire"), "Failed to save game")
+	if status != http.StatusInternalServerError || message != "Failed to save game" {
+		t.Errorf("unknown error: got %d %q, want 500 with the fallback message", status, message)
+	}
+}
+
+func TestWriteServiceErrorRetryAfter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gin.SetMode(gin.TestMode)
+
+	serve := func(r *gin.Engine, err error) *httptest.ResponseRecorder {
+		r.POST("/api/game/start", func(c *gin.Context) {
+			writeServiceError(c, logger, "Failed to start game", err)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/game/start", nil))
+		return w
+	}
+
+	// Without a rate limiter clients are told to come back a little later
+	w := serve(gin.New(), domain.ErrTooManyGames)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != capacityRetryAfter {
+		t.Errorf("without a limiter: got %d with Retry-After %q, want 429 with %q",
+			w.Code, w.Header().Get("Retry-After"), capacityRetryAfter)
+	}
+
+	// With one, no sooner than it would let the request through again
+	limited := gin.New()
+	limited.Use(middleware.NewRateLimiter(config.RateLimitConfig{
+		Enabled:    true,
+		PerIP:      config.RateLimitRule{Rate: 10, Burst: 10},
+		PerSession: config.RateLimitRule{Rate: 10, Burst: 10},
+		Routes: map[string]*config.RateLimitRule{
+			"POST /api/game/start": {Rate: 0.1, Burst: 3},
+		},
+	}, logger).Handler())
+	if w := serve(limited, domain.ErrTooManyGames); w.Header().Get("Retry-After") != "10" {
+		t.Errorf("with a limiter: got Retry-After %q, want 10", w.Header().Get("Retry-After"))
+	}
+
+	if w := serve(gin.New(), domain.ErrGameNotFound); w.Header().Get("Retry-After") != "" {
+		t.Errorf("404 carries Retry-After %q", w.Header().Get("Retry-After"))
+	}
+}
@@ -1,13 +1,14 @@
 package http
 
 import (
-	"fmt"
+	"context"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/middleware"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -31,18 +32,73 @@ func NewGameHandler(gameService domain.GameService, logger *slog.Logger) *GameHa
 
 // StartGameRequest represents the start game request
 type StartGameRequest struct {
-	SessionID string `json:"sessionId,omitempty"`
+	SessionID  string `json:"sessionId,omitempty"`
+	Preset     string `json:"preset,omitempty"`
+	MaxPlayers int    `json:"maxPlayers,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Versus     bool   `json:"versus,omitempty"`
+	Maze       string `json:"maze,omitempty"`
+	// Headless games only advance through /api/game/step
+	Headless bool `json:"headless,omitempty"`
+	// Autopilot names a strategy that plays for the host
+	Autopilot string `json:"autopilot,omitempty"`
 }
 
 // StartGameResponse represents the start game response
 type StartGameResponse struct {
-	SessionID string            `json:"sessionId"`
-	State     domain.GameState  `json:"state"`
+	SessionID   string `json:"sessionId"`
+	PlayerID    string `json:"playerId,omitempty"`
+	PlayerToken string `json:"playerToken,omitempty"`
+	// SpectatorToken can be shared for read-only access via /api/watch/:token
+	SpectatorToken string           `json:"spectatorToken,omitempty"`
+	State          domain.GameState `json:"state"`
+}
+
+// JoinGameRequest represents a request to join a multiplayer game
+type JoinGameRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// JoinGameResponse represents the join game response
+type JoinGameResponse struct {
+	SessionID   string           `json:"sessionId"`
+	PlayerID    string           `json:"playerId"`
+	PlayerToken string           `json:"playerToken"`
+	State       domain.GameState `json:"state"`
+}
+
+// ClaimGhostResponse represents the claim ghost response
+type ClaimGhostResponse struct {
+	SessionID   string           `json:"sessionId"`
+	GhostID     string           `json:"ghostId"`
+	PlayerToken string           `json:"playerToken"`
+	State       domain.GameState `json:"state"`
+}
+
+// LobbyResponse lists versus games with open ghost seats
+type LobbyResponse struct {
+	Games []domain.GameSummary `json:"games"`
+}
+
+// LiveGamesResponse lists running games that can be watched
+type LiveGamesResponse struct {
+	Games []domain.LiveGame `json:"games"`
+}
+
+// AutopilotRequest selects an autopilot strategy. An empty strategy
+// returns control to the player.
+type AutopilotRequest struct {
+	Strategy string `json:"strategy"`
 }
 
 // MoveRequest represents a player move request
 type MoveRequest struct {
-	Direction string `json:"direction" binding:"required,oneof=up down left right"`
+	Direction string `json:"direction" binding:"required"`
+}
+
+// StatusResponse acknowledges a request that returns no data
+type StatusResponse struct {
+	Status string `json:"status"`
 }
 
 // ErrorResponse represents an error response
@@ -69,14 +125,36 @@ func (h *GameHandler) RegisterRoutes(r *gin.Engine) {
 	// Health check
 	r.GET("/health", h.Health)
 
-	// API routes
+	// Versioned game resources
+	games := r.Group("/api/v1/games")
+	{
+		games.POST("", h.CreateGame)
+		games.GET("", h.ListGames)
+		games.GET("/:id", h.GetGame)
+		games.DELETE("/:id", h.DeleteGame)
+		games.POST("/:id/moves", h.CreateMove)
+	}
+
+	// API routes. Those with a versioned replacement are deprecated.
+	deprecated := middleware.Deprecated("/api/v1/games")
 	api := r.Group("/api/game")
 	{
-		api.POST("/start", h.StartGame)
-		api.GET("/state", h.GetGameState)
-		api.POST("/move", h.MovePlayer)
+		api.POST("/start", deprecated, h.StartGame)
+		api.GET("/state", deprecated, h.GetGameState)
+		api.POST("/move", deprecated, h.MovePlayer)
 		api.POST("/restart", h.RestartGame)
+		api.POST("/autopilot", h.SetAutopilot)
+		api.POST("/step", h.Step)
+		api.POST("/batch/start", h.BatchStart)
+		api.POST("/batch/step", h.BatchStep)
+		api.GET("/lobby", h.Lobby)
+		api.POST("/:id/join", h.JoinGame)
+		api.POST("/:id/ghost", h.ClaimGhost)
 	}
+
+	// Read-only spectator routes
+	r.GET("/api/games/live", h.LiveGames)
+	r.GET("/api/watch/:token", h.WatchGame)
 }
 
 // ServeIndex serves the index.html file
@@ -107,45 +185,96 @@ func (h *GameHandler) StartGame(c *gin.Context) {
 	// Get session ID from header or generate new one
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano())
+		sessionID = newSessionID()
 	}
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
-	// Create game
-	game, err := h.gameService.CreateGame(ctx, sessionID)
-	if err != nil {
-		h.logger.ErrorContext(ctx, "failed to create game",
-			"session_id", sessionID,
-			"error", err,
-		)
-		h.respondError(c, http.StatusInternalServerError, "Failed to create game", err)
+	// The request body is optional
+	var req StartGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	response, ok := h.startGame(ctx, c, sessionID, req)
+	if !ok {
 		return
 	}
 
-	// Start game loop
-	if err := h.gameService.StartGameLoop(ctx, sessionID); err != nil {
-		h.logger.ErrorContext(ctx, "failed to start game loop",
+	c.JSON(http.StatusOK, response)
+}
+
+// startGame creates a game and starts its loop, responding with an error
+// if that fails. It returns whether the handler should carry on.
+func (h *GameHandler) startGame(ctx context.Context, c *gin.Context, sessionID string, req StartGameRequest) (*StartGameResponse, bool) {
+	game, err := h.gameService.CreateGame(ctx, sessionID, domain.GameOptions{
+		Preset:     req.Preset,
+		MaxPlayers: req.MaxPlayers,
+		PlayerName: req.Name,
+		Versus:     req.Versus,
+		Maze:       req.Maze,
+		Headless:   req.Headless,
+		Autopilot:  req.Autopilot,
+		Owner:      c.GetHeader("X-Client-ID"),
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to create game",
 			"session_id", sessionID,
 			"error", err,
 		)
-		h.respondError(c, http.StatusInternalServerError, "Failed to start game loop", err)
-		return
+		h.respondServiceError(c, "Failed to create game", err)
+		return nil, false
 	}
 
-	// Get game state
-	state := game.ToGameState(20, 15) // Using constants from service
+	// Get game state before the loop can change the game
+	state := game.ToGameState()
 
-	response := StartGameResponse{
-		SessionID: sessionID,
-		State:     state,
+	if !h.startGameLoop(ctx, c, game) {
+		return nil, false
+	}
+
+	response := &StartGameResponse{
+		SessionID:      sessionID,
+		PlayerID:       game.Host().ID,
+		PlayerToken:    game.Host().Token,
+		SpectatorToken: game.SpectatorToken,
+		State:          state,
 	}
 
 	h.logger.InfoContext(ctx, "game started",
 		"session_id", sessionID,
 	)
 
-	c.JSON(http.StatusOK, response)
+	return response, true
+}
+
+// startGameLoop starts the loop of a game that ticks on a timer, deleting
+// the game and responding with an error if that fails. It returns whether
+// the handler should carry on.
+func (h *GameHandler) startGameLoop(ctx context.Context, c *gin.Context, game *domain.Game) bool {
+	if game.Headless {
+		return true
+	}
+
+	if err := h.gameService.StartGameLoop(ctx, game.ID); err != nil {
+		h.logger.ErrorContext(ctx, "failed to start game loop",
+			"session_id", game.ID,
+			"error", err,
+		)
+		// Don't keep a game around that will never tick
+		if err := h.gameService.DeleteGame(ctx, game.ID); err != nil {
+			h.logger.WarnContext(ctx, "failed to delete game without loop",
+				"session_id", game.ID,
+				"error", err,
+			)
+		}
+		h.respondServiceError(c, "Failed to start game loop", err)
+		return false
+	}
+	return true
 }
 
 // GetGameState handles retrieving game state
@@ -161,17 +290,25 @@ func (h *GameHandler) GetGameState(c *gin.Context) {
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
+	h.respondGameState(ctx, c, sessionID)
+}
+
+// respondGameState sends the state of a game, or 304 Not Modified when it
+// matches the client's If-None-Match header
+func (h *GameHandler) respondGameState(ctx context.Context, c *gin.Context, sessionID string) {
 	state, err := h.gameService.GetGameState(ctx, sessionID)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to get game state",
 			"session_id", sessionID,
 			"error", err,
 		)
-		h.respondError(c, http.StatusNotFound, "Game not found", err)
+		h.respondServiceError(c, "Failed to get game state", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, state)
+	if err := writeState(c, state); err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to encode game state", err)
+	}
 }
 
 // MovePlayer handles player movement
@@ -187,6 +324,18 @@ func (h *GameHandler) MovePlayer(c *gin.Context) {
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
+	if !h.setDirection(ctx, c, sessionID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+}
+
+// setDirection applies the move in the request body, responding with an
+// error if that fails. It returns whether the handler should carry on.
+func (h *GameHandler) setDirection(ctx context.Context, c *gin.Context, sessionID string) bool {
+	span := trace.SpanFromContext(ctx)
+
 	var req MoveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WarnContext(ctx, "invalid move request",
@@ -194,7 +343,7 @@ func (h *GameHandler) MovePlayer(c *gin.Context) {
 			"error", err,
 		)
 		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
-		return
+		return false
 	}
 
 	span.SetAttributes(attribute.String("direction", req.Direction))
@@ -202,22 +351,186 @@ func (h *GameHandler) MovePlayer(c *gin.Context) {
 	// Parse direction
 	dir, ok := domain.ParseDirection(req.Direction)
 	if !ok {
-		h.respondError(c, http.StatusBadRequest, "Invalid direction", nil)
-		return
+		h.respondServiceError(c, "Invalid direction", domain.ErrInvalidDirection)
+		return false
 	}
 
 	// Set player direction
-	if err := h.gameService.SetPlayerDirection(ctx, sessionID, dir); err != nil {
+	playerToken := c.GetHeader("X-Player-Token")
+	if err := h.gameService.SetPlayerDirection(ctx, sessionID, playerToken, dir); err != nil {
 		h.logger.ErrorContext(ctx, "failed to set player direction",
 			"session_id", sessionID,
 			"direction", req.Direction,
 			"error", err,
 		)
-		h.respondError(c, http.StatusNotFound, "Game not found", err)
+		h.respondServiceError(c, "Failed to set player direction", err)
+		return false
+	}
+	return true
+}
+
+// SetAutopilot handles handing a player to an autopilot strategy and back
+func (h *GameHandler) SetAutopilot(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "SetAutopilot")
+	defer span.End()
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		h.respondError(c, http.StatusBadRequest, "Session ID required", nil)
+		return
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	var req AutopilotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.gameService.SetAutopilot(ctx, sessionID, c.GetHeader("X-Player-Token"), req.Strategy); err != nil {
+		h.respondServiceError(c, "Failed to set autopilot", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+}
+
+// JoinGame handles joining an existing multiplayer game
+func (h *GameHandler) JoinGame(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "JoinGame")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	// The request body is optional
+	var req JoinGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	player, err := h.gameService.JoinGame(ctx, sessionID, req.Name)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to join game",
+			"session_id", sessionID,
+			"error", err,
+		)
+		h.respondServiceError(c, "Failed to join game", err)
+		return
+	}
+
+	state, err := h.gameService.GetGameState(ctx, sessionID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to get game state", err)
+		return
+	}
+
+	h.logger.InfoContext(ctx, "player joined",
+		"session_id", sessionID,
+		"player_id", player.ID,
+	)
+
+	c.JSON(http.StatusOK, JoinGameResponse{
+		SessionID:   sessionID,
+		PlayerID:    player.ID,
+		PlayerToken: player.Token,
+		State:       *state,
+	})
+}
+
+// ClaimGhost handles claiming a ghost in a versus game
+func (h *GameHandler) ClaimGhost(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ClaimGhost")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	// The request body is optional
+	var req JoinGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	ghost, err := h.gameService.ClaimGhost(ctx, sessionID, req.Name)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to claim ghost",
+			"session_id", sessionID,
+			"error", err,
+		)
+		h.respondServiceError(c, "Failed to claim ghost", err)
+		return
+	}
+
+	state, err := h.gameService.GetGameState(ctx, sessionID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to get game state", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.JSON(http.StatusOK, ClaimGhostResponse{
+		SessionID:   sessionID,
+		GhostID:     ghost.ID,
+		PlayerToken: ghost.Token,
+		State:       *state,
+	})
+}
+
+// Lobby handles listing versus games with open ghost seats
+func (h *GameHandler) Lobby(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "Lobby")
+	defer span.End()
+
+	games, err := h.gameService.ListVersusLobby(ctx)
+	if err != nil {
+		h.respondServiceError(c, "Failed to list games", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LobbyResponse{Games: games})
+}
+
+// WatchGame handles read-only access to a game through its spectator token
+func (h *GameHandler) WatchGame(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "WatchGame")
+	defer span.End()
+
+	// Viewers that do not identify themselves are counted per client IP
+	viewerID := c.GetHeader("X-Viewer-ID")
+	if viewerID == "" {
+		viewerID = c.ClientIP()
+	}
+
+	state, err := h.gameService.WatchGame(ctx, c.Param("token"), viewerID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to get game state", err)
+		return
+	}
+
+	if err := writeState(c, state); err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to encode game state", err)
+	}
+}
+
+// LiveGames handles listing running games for spectators
+func (h *GameHandler) LiveGames(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "LiveGames")
+	defer span.End()
+
+	games, err := h.gameService.ListLiveGames(ctx)
+	if err != nil {
+		h.respondServiceError(c, "Failed to list games", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LiveGamesResponse{Games: games})
 }
 
 // RestartGame handles restarting a game
@@ -227,38 +540,33 @@ func (h *GameHandler) RestartGame(c *gin.Context) {
 
 	sessionID := c.GetHeader("X-Session-ID")
 	if sessionID == "" {
-		sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano())
+		sessionID = newSessionID()
 	}
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
 	// Restart game
-	game, err := h.gameService.RestartGame(ctx, sessionID)
+	game, err := h.gameService.RestartGame(ctx, sessionID, c.GetHeader("X-Player-Token"))
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to restart game",
 			"session_id", sessionID,
 			"error", err,
 		)
-		h.respondError(c, http.StatusInternalServerError, "Failed to restart game", err)
+		h.respondServiceError(c, "Failed to restart game", err)
 		return
 	}
 
-	// Start game loop
-	if err := h.gameService.StartGameLoop(ctx, sessionID); err != nil {
-		h.logger.ErrorContext(ctx, "failed to start game loop",
-			"session_id", sessionID,
-			"error", err,
-		)
-		h.respondError(c, http.StatusInternalServerError, "Failed to start game loop", err)
+	// Get game state before the loop can change the game
+	state := game.ToGameState()
+
+	if !h.startGameLoop(ctx, c, game) {
 		return
 	}
 
-	// Get game state
-	state := game.ToGameState(20, 15)
-
 	response := StartGameResponse{
-		SessionID: sessionID,
-		State:     state,
+		SessionID:      sessionID,
+		SpectatorToken: game.SpectatorToken,
+		State:          state,
 	}
 
 	h.logger.InfoContext(ctx, "game restarted",
@@ -270,19 +578,5 @@ func (h *GameHandler) RestartGame(c *gin.Context) {
 
 // respondError sends an error response
 func (h *GameHandler) respondError(c *gin.Context, statusCode int, message string, err error) {
-	response := ErrorResponse{
-		Error:   http.StatusText(statusCode),
-		Message: message,
-	}
-
-	if err != nil {
-		h.logger.Error("handler error",
-			"status", statusCode,
-			"message", message,
-			"error", err,
-		)
-	}
-
-	c.JSON(statusCode, response)
+	writeError(c, h.logger, statusCode, message, err)
 }
-
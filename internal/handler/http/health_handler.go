@@ -0,0 +1,73 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/health"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	registry *health.Registry
+	logger   *slog.Logger
+	tracer   trace.Tracer
+}
+
+// NewHealthHandler creates a handler reporting the checks of registry
+func NewHealthHandler(registry *health.Registry, logger *slog.Logger) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+		logger:   logger,
+		tracer:   otel.Tracer("health-handler"),
+	}
+}
+
+// RegisterRoutes registers the probe routes
+func (h *HealthHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/livez", h.Livez)
+	r.GET("/readyz", h.Readyz)
+}
+
+// Livez handles liveness probes, failing when the process should be
+// restarted
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.respond(c, "Livez", health.Liveness)
+}
+
+// Readyz handles readiness probes, failing when the server should get no
+// new traffic, including while it drains
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	h.respond(c, "Readyz", health.Readiness)
+}
+
+// respond runs the checks of probe and reports them with 200 when all
+// pass and 503 otherwise
+func (h *HealthHandler) respond(c *gin.Context, name string, probe health.Probe) {
+	ctx, span := h.tracer.Start(c.Request.Context(), name)
+	defer span.End()
+
+	report := h.registry.Run(ctx, probe)
+	span.SetAttributes(attribute.String("health.status", report.Status))
+
+	if !report.OK() {
+		var failed []string
+		for _, res := range report.Checks {
+			if res.Status != health.StatusOK {
+				failed = append(failed, res.Name)
+			}
+		}
+		h.logger.WarnContext(ctx, "health probe failing",
+			"probe", name,
+			"failed_checks", failed,
+		)
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
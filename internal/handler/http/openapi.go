@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIHandler serves the OpenAPI document of the API
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+// NewOpenAPIHandler creates a handler serving spec
+func NewOpenAPIHandler(spec []byte) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec}
+}
+
+// RegisterRoutes registers the OpenAPI document route
+func (h *OpenAPIHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/api/openapi.json", h.Spec)
+}
+
+// Spec handles requests for the OpenAPI document
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.spec)
+}
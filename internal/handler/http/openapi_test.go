@@ -0,0 +1,230 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/api/openapi"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/health"
+)
+
+// TestAPIMatchesSpec checks that the routes the server registers and the
+// types its handlers use match the OpenAPI document
+func TestAPIMatchesSpec(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Registering routes does not use the services, so none are needed
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewGameHandler(nil, logger).RegisterRoutes(r)
+	NewRoomHandler(nil, logger).RegisterRoutes(r)
+	NewOpenAPIHandler(openapi.Spec).RegisterRoutes(r)
+	NewHealthHandler(health.NewRegistry(time.Second), logger).RegisterRoutes(r)
+	NewAdminHandler(nil, nil, "", logger).RegisterRoutes(r)
+
+	if err := verifyContract(doc, r.Routes()); err != nil {
+		t.Fatalf("handlers do not match the API spec:\n%v", err)
+	}
+}
+
+// contractSchema pairs a schema of the OpenAPI document with the type that
+// handlers bind requests to or encode responses from
+type contractSchema struct {
+	name  string
+	value any
+	// request schemas require the fields bound with binding:"required",
+	// response schemas every field that is not omitted when empty
+	request bool
+}
+
+// contractSchemas lists every schema of the OpenAPI document
+var contractSchemas = []contractSchema{
+	{"Position", domain.Position{}, false},
+	{"PlayerState", domain.PlayerState{}, false},
+	{"GhostState", domain.GhostState{}, false},
+	{"GameState", domain.GameState{}, false},
+	{"Fruit", domain.Fruit{}, false},
+	{"CollectedFruit", domain.CollectedFruit{}, false},
+	{"StartGameRequest", StartGameRequest{}, true},
+	{"StartGameResponse", StartGameResponse{}, false},
+	{"JoinGameRequest", JoinGameRequest{}, true},
+	{"JoinGameResponse", JoinGameResponse{}, false},
+	{"ClaimGhostResponse", ClaimGhostResponse{}, false},
+	{"GameSummary", domain.GameSummary{}, false},
+	{"LobbyResponse", LobbyResponse{}, false},
+	{"ListGamesResponse", ListGamesResponse{}, false},
+	{"LiveGame", domain.LiveGame{}, false},
+	{"LiveGamesResponse", LiveGamesResponse{}, false},
+	{"AutopilotRequest", AutopilotRequest{}, true},
+	{"MoveRequest", MoveRequest{}, true},
+	{"StatusResponse", StatusResponse{}, false},
+	{"ErrorResponse", ErrorResponse{}, false},
+	{"HealthResponse", HealthResponse{}, false},
+	{"HealthReport", health.Report{}, false},
+	{"HealthCheckResult", health.Result{}, false},
+	{"StepRequest", StepRequest{}, true},
+	{"StepResult", domain.StepResult{}, false},
+	{"BatchStartRequest", BatchStartRequest{}, true},
+	{"BatchStartResponse", BatchStartResponse{}, false},
+	{"BatchStep", BatchStep{}, true},
+	{"BatchStepRequest", BatchStepRequest{}, true},
+	{"BatchStepResult", BatchStepResult{}, false},
+	{"BatchStepResponse", BatchStepResponse{}, false},
+	{"CreateRoomRequest", CreateRoomRequest{}, true},
+	{"JoinRoomRequest", JoinRoomRequest{}, true},
+	{"RoomSettings", domain.RoomSettings{}, false},
+	{"RoomMemberState", domain.RoomMemberState{}, false},
+	{"RoomState", domain.RoomState{}, false},
+	{"RoomMemberResponse", RoomMemberResponse{}, false},
+	{"ListRoomsResponse", ListRoomsResponse{}, false},
+	{"ReadyRequest", ReadyRequest{}, true},
+	{"UpdateRoomSettingsRequest", UpdateRoomSettingsRequest{}, true},
+}
+
+// probePaths are the routes outside /api that the API spec documents
+var probePaths = map[string]bool{
+	"/health": true,
+	"/livez":  true,
+	"/readyz": true,
+}
+
+// verifyContract checks that the OpenAPI document and the handlers agree:
+// every API route is documented and every documented operation is routed,
+// and every schema has the JSON fields of its Go type. It reports all
+// mismatches at once.
+func verifyContract(doc *openapi3.T, routes gin.RoutesInfo) error {
+	var errs []error
+
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") && !probePaths[route.Path] {
+			continue
+		}
+		path := specPath(route.Path)
+		routed[route.Method+" "+path] = true
+
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			errs = append(errs, fmt.Errorf("route %s %s is not in the API spec", route.Method, route.Path))
+		}
+	}
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !routed[method+" "+path] {
+				errs = append(errs, fmt.Errorf("operation %s %s in the API spec has no route", method, path))
+			}
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, s := range contractSchemas {
+		checked[s.name] = true
+		ref := doc.Components.Schemas[s.name]
+		if ref == nil || ref.Value == nil {
+			errs = append(errs, fmt.Errorf("schema %s is not in the API spec", s.name))
+			continue
+		}
+		errs = append(errs, compareSchema(s, ref.Value)...)
+	}
+	for name := range doc.Components.Schemas {
+		if !checked[name] {
+			errs = append(errs, fmt.Errorf("schema %s in the API spec has no Go type", name))
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// specPath converts a gin route path such as /api/rooms/:id to the OpenAPI
+// form /api/rooms/{id}
+func specPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// compareSchema checks that schema has the JSON fields of the Go type of s,
+// requiring the same ones
+func compareSchema(s contractSchema, schema *openapi3.Schema) []error {
+	var errs []error
+
+	fields := make(map[string]bool)
+	collectFields(reflect.TypeOf(s.value), s.request, true, fields)
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	for name, req := range fields {
+		if _, ok := schema.Properties[name]; !ok {
+			errs = append(errs, fmt.Errorf("schema %s lacks field %q", s.name, name))
+			continue
+		}
+		if req != required[name] {
+			errs = append(errs, fmt.Errorf("schema %s field %q: required is %t in the spec but %t in Go", s.name, name, required[name], req))
+		}
+	}
+	for name := range schema.Properties {
+		if _, ok := fields[name]; !ok {
+			errs = append(errs, fmt.Errorf("schema %s field %q is not in the Go type", s.name, name))
+		}
+	}
+	return errs
+}
+
+// collectFields adds the JSON field names of struct type t to fields,
+// recording whether each one is required. Fields of embedded structs are
+// promoted, but only required when the embedded struct is always present.
+func collectFields(t reflect.Type, request, present bool, fields map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			collectFields(f.Type, request, present && f.Type.Kind() != reflect.Pointer, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		var required bool
+		if request {
+			required = strings.Contains(f.Tag.Get("binding"), "required")
+		} else {
+			required = !strings.Contains(opts, "omitempty")
+		}
+		fields[name] = present && required
+	}
+}
@@ -0,0 +1,364 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// roomEventHeartbeat is how often an idle room event stream sends a comment
+// to keep proxies from closing it
+const roomEventHeartbeat = 15 * time.Second
+
+// RoomHandler handles HTTP requests for matchmaking rooms
+type RoomHandler struct {
+	roomService domain.RoomService
+	logger      *slog.Logger
+	tracer      trace.Tracer
+}
+
+// NewRoomHandler creates a new room handler
+func NewRoomHandler(roomService domain.RoomService, logger *slog.Logger) *RoomHandler {
+	return &RoomHandler{
+		roomService: roomService,
+		logger:      logger,
+		tracer:      otel.Tracer("room-handler"),
+	}
+}
+
+// CreateRoomRequest represents the create room request
+type CreateRoomRequest struct {
+	Name     string `json:"name,omitempty"`
+	HostName string `json:"hostName,omitempty"`
+	Capacity int    `json:"capacity" binding:"required"`
+	Preset   string `json:"preset,omitempty"`
+	Maze     string `json:"maze,omitempty"`
+}
+
+// JoinRoomRequest represents the join room request
+type JoinRoomRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// RoomMemberResponse is returned to a participant entering a room
+type RoomMemberResponse struct {
+	RoomID      string           `json:"roomId"`
+	MemberID    string           `json:"memberId"`
+	MemberToken string           `json:"memberToken"`
+	Room        domain.RoomState `json:"room"`
+}
+
+// ListRoomsResponse lists open rooms
+type ListRoomsResponse struct {
+	Rooms []domain.RoomState `json:"rooms"`
+}
+
+// ReadyRequest represents a ready-check answer
+type ReadyRequest struct {
+	Ready *bool `json:"ready" binding:"required"`
+}
+
+// UpdateRoomSettingsRequest changes the settings given and keeps the rest
+type UpdateRoomSettingsRequest struct {
+	Preset   *string `json:"preset,omitempty"`
+	Maze     *string `json:"maze,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// RegisterRoutes registers all room routes
+func (h *RoomHandler) RegisterRoutes(r *gin.Engine) {
+	rooms := r.Group("/api/rooms")
+	{
+		rooms.POST("", h.CreateRoom)
+		rooms.GET("", h.ListRooms)
+		rooms.GET("/:id", h.GetRoom)
+		rooms.GET("/:id/events", h.RoomEvents)
+		rooms.POST("/:id/join", h.JoinRoom)
+		rooms.POST("/:id/leave", h.LeaveRoom)
+		rooms.POST("/:id/ready", h.SetReady)
+		rooms.PUT("/:id/settings", h.UpdateSettings)
+		rooms.POST("/:id/start", h.StartRoom)
+	}
+}
+
+// CreateRoom handles opening a room
+func (h *RoomHandler) CreateRoom(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "CreateRoom")
+	defer span.End()
+
+	var req CreateRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	room, host, err := h.roomService.CreateRoom(ctx, domain.RoomOptions{
+		Name:     req.Name,
+		HostName: req.HostName,
+		Settings: domain.RoomSettings{
+			Preset:   req.Preset,
+			Maze:     req.Maze,
+			Capacity: req.Capacity,
+		},
+	})
+	if err != nil {
+		h.respondServiceError(c, "Failed to create room", err)
+		return
+	}
+
+	span.SetAttributes(attribute.String("room.id", room.ID))
+
+	c.JSON(http.StatusCreated, RoomMemberResponse{
+		RoomID:      room.ID,
+		MemberID:    host.ID,
+		MemberToken: host.Token,
+		Room:        room.ToRoomState(host.Token),
+	})
+}
+
+// ListRooms handles listing open rooms
+func (h *RoomHandler) ListRooms(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ListRooms")
+	defer span.End()
+
+	rooms, err := h.roomService.ListRooms(ctx)
+	if err != nil {
+		h.respondServiceError(c, "Failed to list rooms", err)
+		return
+	}
+
+	states := make([]domain.RoomState, len(rooms))
+	for i := range rooms {
+		states[i] = rooms[i].ToRoomState("")
+	}
+
+	c.JSON(http.StatusOK, ListRoomsResponse{Rooms: states})
+}
+
+// GetRoom handles retrieving a room. Members see their own player token.
+func (h *RoomHandler) GetRoom(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "GetRoom")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	room, err := h.roomService.GetRoom(ctx, roomID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to get room", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, room.ToRoomState(memberToken(c)))
+}
+
+// JoinRoom handles entering a room
+func (h *RoomHandler) JoinRoom(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "JoinRoom")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	// The request body is optional
+	var req JoinRoomRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	room, member, err := h.roomService.JoinRoom(ctx, roomID, req.Name)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to join room",
+			"room_id", roomID,
+			"error", err,
+		)
+		h.respondServiceError(c, "Failed to join room", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RoomMemberResponse{
+		RoomID:      room.ID,
+		MemberID:    member.ID,
+		MemberToken: member.Token,
+		Room:        room.ToRoomState(member.Token),
+	})
+}
+
+// LeaveRoom handles leaving a room
+func (h *RoomHandler) LeaveRoom(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "LeaveRoom")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	if err := h.roomService.LeaveRoom(ctx, roomID, memberToken(c)); err != nil {
+		h.respondServiceError(c, "Failed to leave room", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+}
+
+// SetReady handles a member's ready-check answer
+func (h *RoomHandler) SetReady(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "SetReady")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	var req ReadyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	token := memberToken(c)
+	room, err := h.roomService.SetReady(ctx, roomID, token, *req.Ready)
+	if err != nil {
+		h.respondServiceError(c, "Failed to update ready state", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, room.ToRoomState(token))
+}
+
+// UpdateSettings handles the host changing the room's game settings
+func (h *RoomHandler) UpdateSettings(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "UpdateRoomSettings")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	var req UpdateRoomSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	room, err := h.roomService.GetRoom(ctx, roomID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to get room", err)
+		return
+	}
+
+	settings := room.Settings
+	if req.Preset != nil {
+		settings.Preset = *req.Preset
+	}
+	if req.Maze != nil {
+		settings.Maze = *req.Maze
+	}
+	if req.Capacity != nil {
+		settings.Capacity = *req.Capacity
+	}
+
+	token := memberToken(c)
+	room, err = h.roomService.UpdateSettings(ctx, roomID, token, settings)
+	if err != nil {
+		h.respondServiceError(c, "Failed to update room settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, room.ToRoomState(token))
+}
+
+// StartRoom handles the host starting the room's game
+func (h *RoomHandler) StartRoom(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "StartRoom")
+	defer span.End()
+
+	roomID := c.Param("id")
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	token := memberToken(c)
+	room, err := h.roomService.StartRoom(ctx, roomID, token)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to start room",
+			"room_id", roomID,
+			"error", err,
+		)
+		h.respondServiceError(c, "Failed to start room", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, room.ToRoomState(token))
+}
+
+// RoomEvents streams the room to the client as server-sent events, one
+// "room" event per change and a "closed" event when the room goes away
+func (h *RoomHandler) RoomEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	roomID := c.Param("id")
+
+	updates, err := h.roomService.Subscribe(ctx, roomID)
+	if err != nil {
+		h.respondServiceError(c, "Failed to follow room", err)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.WarnContext(ctx, "failed to clear write deadline for room events",
+			"room_id", roomID,
+			"error", err,
+		)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	token := memberToken(c)
+	heartbeat := time.NewTicker(roomEventHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case room, ok := <-updates:
+			if !ok {
+				c.SSEvent("closed", gin.H{"id": roomID})
+				return false
+			}
+			c.SSEvent("room", room.ToRoomState(token))
+			return true
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": heartbeat\n\n")
+			return err == nil
+		}
+	})
+}
+
+// memberToken returns the member token of the request. Browsers cannot set
+// headers on event streams, so the token query parameter is accepted too.
+func memberToken(c *gin.Context) string {
+	if token := c.GetHeader("X-Member-Token"); token != "" {
+		return token
+	}
+	return c.Query("token")
+}
+
+// respondServiceError sends an error response for an error returned by the
+// room service, choosing the status code from the domain error it wraps
+func (h *RoomHandler) respondServiceError(c *gin.Context, fallback string, err error) {
+	writeServiceError(c, h.logger, fallback, err)
+}
+
+// respondError sends an error response
+func (h *RoomHandler) respondError(c *gin.Context, statusCode int, message string, err error) {
+	writeError(c, h.logger, statusCode, message, err)
+}
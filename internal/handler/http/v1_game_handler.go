@@ -0,0 +1,166 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/wire"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ListGamesResponse lists the games created by the caller
+type ListGamesResponse struct {
+	Games []domain.GameSummary `json:"games"`
+}
+
+// CreateGame handles starting a game as a new resource. The session ID
+// comes from the body or is generated.
+func (h *GameHandler) CreateGame(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "CreateGame")
+	defer span.End()
+
+	// The request body is optional
+	var req StartGameRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = newSessionID()
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	response, ok := h.startGame(ctx, c, sessionID, req)
+	if !ok {
+		return
+	}
+
+	c.Header("Location", "/api/v1/games/"+url.PathEscape(sessionID))
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListGames handles listing the games created by the client named in the
+// X-Client-ID header
+func (h *GameHandler) ListGames(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "ListGames")
+	defer span.End()
+
+	owner := c.GetHeader("X-Client-ID")
+	if owner == "" {
+		h.respondError(c, http.StatusBadRequest, "Client ID required", nil)
+		return
+	}
+
+	games, err := h.gameService.ListOwnedGames(ctx, owner)
+	if err != nil {
+		h.respondServiceError(c, "Failed to list games", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListGamesResponse{Games: games})
+}
+
+// GetGame handles retrieving the state of a game, answering conditional
+// requests with 304 Not Modified while the state is unchanged
+func (h *GameHandler) GetGame(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "GetGame")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	h.respondGameState(ctx, c, sessionID)
+}
+
+// DeleteGame handles stopping a game and removing it for its host, who
+// sends its token in X-Player-Token
+func (h *GameHandler) DeleteGame(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "DeleteGame")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if err := h.gameService.DeleteGameAsHost(ctx, sessionID, c.GetHeader("X-Player-Token")); err != nil {
+		h.respondServiceError(c, "Failed to delete game", err)
+		return
+	}
+
+	h.logger.InfoContext(ctx, "game deleted by client",
+		"session_id", sessionID,
+	)
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateMove handles changing the direction of a player or claimed ghost.
+// The move is applied on the game's next tick.
+func (h *GameHandler) CreateMove(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "CreateMove")
+	defer span.End()
+
+	sessionID := c.Param("id")
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if !h.setDirection(ctx, c, sessionID) {
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// newSessionID generates a session ID for a game the client did not name
+func newSessionID() string {
+	return fmt.Sprintf("session-%d", time.Now().UnixNano())
+}
+
+// writeState sends state in the format negotiated from the request's
+// Accept header, with an ETag derived from its encoding, or 304 Not
+// Modified without a body when the request's If-None-Match header already
+// names that ETag
+func writeState(c *gin.Context, state *domain.GameState) error {
+	format := wire.Negotiate(c.GetHeader("Accept"))
+	body, err := wire.EncodeState(format, state)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Vary", "Accept")
+	// Clients may cache the state but must check it is current
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+
+	c.Data(http.StatusOK, format.ContentType(), body)
+	return nil
+}
+
+// etagMatches reports whether an If-None-Match header names etag. Weak
+// validators match too, as If-None-Match uses weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
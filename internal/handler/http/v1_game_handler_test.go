@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/wire"
+)
+
+// serveState records the response of writeState to a request with the
+// given headers
+func serveState(t *testing.T, state *domain.GameState, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/state", func(c *gin.Context) {
+		if err := writeState(c, state); err != nil {
+			t.Errorf("writeState: %v", err)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/state", nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestWriteStateNegotiatesFormat(t *testing.T) {
+	state := &domain.GameState{Board: domain.NewBoard([]string{"#.#"}, 3, 1), DotsLeft: 1}
+
+	for _, tc := range []struct {
+		accept string
+		want   wire.Format
+	}{
+		{"", wire.FormatJSON},
+		{"application/msgpack", wire.FormatMsgPack},
+		{"application/x-protobuf;q=0.5, application/json;q=0.4", wire.FormatProtobuf},
+		{"image/png", wire.FormatJSON},
+	} {
+		w := serveState(t, state, map[string]string{"Accept": tc.accept})
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != tc.want.ContentType() {
+			t.Errorf("Accept %q: got %d %s, want 200 %s", tc.accept, w.Code, w.Header().Get("Content-Type"), tc.want.ContentType())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: got Vary %q, want Accept", tc.accept, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestWriteStateNotModified(t *testing.T) {
+	state := &domain.GameState{Board: domain.NewBoard([]string{"#.#"}, 3, 1), DotsLeft: 1}
+
+	first := serveState(t, state, nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request: got %d with ETag %q", first.Code, etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		w := serveState(t, state, map[string]string{"If-None-Match": ifNoneMatch})
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got %d with %d bytes, want 304 without a body", ifNoneMatch, w.Code, w.Body.Len())
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: got ETag %q, want %q", ifNoneMatch, w.Header().Get("ETag"), etag)
+		}
+	}
+
+	// Other encodings and changed states have other ETags
+	if w := serveState(t, state, map[string]string{"Accept": "application/msgpack", "If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Errorf("MessagePack with the JSON ETag: got %d, want 200", w.Code)
+	}
+	state.Score = 10
+	if w := serveState(t, state, map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Errorf("changed state: got %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,155 @@
+// Package health runs the checks behind the liveness and readiness probes
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Probe selects which endpoint a check reports to. A check can report to
+// both.
+type Probe uint8
+
+const (
+	// Liveness checks fail when the process should be restarted
+	Liveness Probe = 1 << iota
+	// Readiness checks fail when the process should not get new traffic
+	Readiness
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc checks one dependency or condition. It returns a short
+// description of what it observed, and an error when the check fails.
+type CheckFunc func(ctx context.Context) (string, error)
+
+// Result is the outcome of one check
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// Report is the outcome of every check of a probe. It is ok only when all
+// of its checks are.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// check is a registered check
+type check struct {
+	name   string
+	probes Probe
+	fn     CheckFunc
+}
+
+// Registry holds the checks of the probes. Checks can be registered at
+// any time and are run concurrently, each bounded by the registry timeout.
+type Registry struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []check
+}
+
+// NewRegistry creates an empty registry whose checks fail when they take
+// longer than timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check reporting to probes. Names identify checks in
+// reports and must be unique.
+func (r *Registry) Register(name string, probes Probe, fn CheckFunc) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.checks {
+		if c.name == name {
+			return fmt.Errorf("health check %q is already registered", name)
+		}
+	}
+	r.checks = append(r.checks, check{name: name, probes: probes, fn: fn})
+	return nil
+}
+
+// Run runs the checks of probe and reports their results by name
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	r.mu.RLock()
+	var checks []check
+	for _, c := range r.checks {
+		if c.probes&probe != 0 {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs one check, failing it when it outlasts the timeout. A check
+// that hangs is left to finish in the background.
+func (r *Registry) run(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		detail, err := c.fn(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	res := Result{Name: c.name, Status: StatusOK}
+	select {
+	case o := <-done:
+		res.Detail = o.detail
+		if o.err != nil {
+			res.Status = StatusFail
+			res.Error = o.err.Error()
+		}
+	case <-ctx.Done():
+		res.Status = StatusFail
+		res.Error = fmt.Sprintf("check did not finish within %s", r.timeout)
+	}
+	res.DurationMS = time.Since(start).Milliseconds()
+	return res
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// pass is a check that always passes
+func pass(detail string) CheckFunc {
+	return func(ctx context.Context) (string, error) { return detail, nil }
+}
+
+func TestRegistryRunsChecksOfProbe(t *testing.T) {
+	r := NewRegistry(time.Second)
+	for _, c := range []struct {
+		name   string
+		probes Probe
+	}{
+		{"b_ready", Readiness},
+		{"a_ready", Readiness},
+		{"live", Liveness},
+		{"both", Liveness | Readiness},
+	} {
+		if err := r.Register(c.name, c.probes, pass(c.name)); err != nil {
+			t.Fatalf("Register(%s): %v", c.name, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		probe Probe
+		want  []string
+	}{
+		{Liveness, []string{"both", "live"}},
+		{Readiness, []string{"a_ready", "b_ready", "both"}},
+	} {
+		report := r.Run(context.Background(), tc.probe)
+		if !report.OK() {
+			t.Errorf("probe %d: got status %s, want ok", tc.probe, report.Status)
+		}
+		var names []string
+		for _, res := range report.Checks {
+			names = append(names, res.Name)
+			if res.Detail != res.Name || res.Status != StatusOK {
+				t.Errorf("check %s: got %+v", res.Name, res)
+			}
+		}
+		if len(names) != len(tc.want) {
+			t.Fatalf("probe %d: got checks %v, want %v", tc.probe, names, tc.want)
+		}
+		for i := range names {
+			if names[i] != tc.want[i] {
+				t.Fatalf("probe %d: got checks %v, want %v", tc.probe, names, tc.want)
+			}
+		}
+	}
+}
+
+func TestRegistryRejectsDuplicateNames(t *testing.T) {
+	r := NewRegistry(time.Second)
+	if err := r.Register("check", Liveness, pass("")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := r.Register("check", Readiness, pass("")); err == nil {
+		t.Fatal("second check with the same name was registered")
+	}
+}
+
+func TestRegistryFailsProbeWithFailingCheck(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("fine", Readiness, pass("fine"))
+	r.Register("broken", Readiness, func(ctx context.Context) (string, error) {
+		return "observed", errors.New("it broke")
+	})
+
+	report := r.Run(context.Background(), Readiness)
+	if report.OK() || report.Status != StatusFail {
+		t.Fatalf("got status %s, want fail", report.Status)
+	}
+	broken := report.Checks[0]
+	if broken.Name != "broken" || broken.Status != StatusFail || broken.Detail != "observed" || broken.Error != "it broke" {
+		t.Errorf("failing check: got %+v", broken)
+	}
+	if report.Checks[1].Status != StatusOK {
+		t.Errorf("passing check: got %+v", report.Checks[1])
+	}
+
+	// Probes without failing checks are unaffected
+	if report := r.Run(context.Background(), Liveness); !report.OK() || len(report.Checks) != 0 {
+		t.Errorf("liveness: got %+v, want ok without checks", report)
+	}
+}
+
+func TestRegistryFailsSlowChecks(t *testing.T) {
+	r := NewRegistry(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	r.Register("hung", Liveness, func(ctx context.Context) (string, error) {
+		<-release
+		return "", nil
+	})
+
+	start := time.Now()
+	report := r.Run(context.Background(), Liveness)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Run waited %s for a hung check", elapsed)
+	}
+	if report.OK() || report.Checks[0].Error == "" {
+		t.Fatalf("hung check: got %+v, want a timeout failure", report.Checks[0])
+	}
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// sessionRequest is implemented by gRPC requests that name a game session
+type sessionRequest interface {
+	GetSessionId() string
+}
+
+// playerRequest is implemented by gRPC requests that carry a player token
+type playerRequest interface {
+	GetPlayerToken() string
+}
+
+// UnaryTracing returns an interceptor that adds OpenTelemetry tracing to
+// unary gRPC calls
+func UnaryTracing(serviceName string) grpc.UnaryServerInterceptor {
+	tracer := otel.Tracer(serviceName)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startRPCSpan(ctx, tracer, info.FullMethod)
+		defer span.End()
+
+		// Add session ID if present
+		if r, ok := req.(sessionRequest); ok && r.GetSessionId() != "" {
+			span.SetAttributes(attribute.String("session.id", r.GetSessionId()))
+		}
+
+		resp, err := handler(ctx, req)
+		endRPCSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamTracing returns an interceptor that adds OpenTelemetry tracing to
+// streaming gRPC calls
+func StreamTracing(serviceName string) grpc.StreamServerInterceptor {
+	tracer := otel.Tracer(serviceName)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startRPCSpan(ss.Context(), tracer, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		endRPCSpan(span, err)
+		return err
+	}
+}
+
+// startRPCSpan starts a server span for method, continuing the trace
+// carried in the call's metadata
+func startRPCSpan(ctx context.Context, tracer trace.Tracer, method string) (context.Context, trace.Span) {
+	// Extract trace context from metadata
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer))
+	span.SetAttributes(
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.method", method),
+		attribute.String("rpc.client_ip", peerAddr(ctx)),
+	)
+	return ctx, span
+}
+
+// endRPCSpan records the status code of a finished call on span
+func endRPCSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, code.String())
+	}
+}
+
+// UnaryLogging returns an interceptor that logs unary gRPC calls
+func UnaryLogging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		var sessionID string
+		if r, ok := req.(sessionRequest); ok {
+			sessionID = r.GetSessionId()
+		}
+
+		// Process call
+		resp, err := handler(ctx, req)
+
+		logger.Info("grpc request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", peerAddr(ctx),
+			"session_id", sessionID,
+		)
+		return resp, err
+	}
+}
+
+// StreamLogging returns an interceptor that logs streaming gRPC calls once
+// they end
+func StreamLogging(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		// Process call
+		err := handler(srv, ss)
+
+		logger.Info("grpc stream",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", peerAddr(ss.Context()),
+		)
+		return err
+	}
+}
+
+// UnaryRecovery returns an interceptor that turns panics in unary gRPC
+// calls into Internal errors
+func UnaryRecovery(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic recovered",
+					"error", r,
+					"method", info.FullMethod,
+				)
+				err = status.Error(grpccodes.Internal, fmt.Sprintf("Unexpected error: %v", r))
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery returns an interceptor that turns panics in streaming gRPC
+// calls into Internal errors
+func StreamRecovery(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic recovered",
+					"error", r,
+					"method", info.FullMethod,
+				)
+				err = status.Error(grpccodes.Internal, fmt.Sprintf("Unexpected error: %v", r))
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// UnaryRateLimit returns an interceptor that rejects unary gRPC calls over
+// the limit with ResourceExhausted and a retry-after header. Calls are
+// limited per client IP and per session like HTTP requests, and methods
+// listed in routes share the rule and buckets of the HTTP route they map to.
+func (l *RateLimiter) UnaryRateLimit(routes map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var sessionID, playerToken string
+		if r, ok := req.(sessionRequest); ok {
+			sessionID = r.GetSessionId()
+		}
+		if r, ok := req.(playerRequest); ok {
+			playerToken = r.GetPlayerToken()
+		}
+		if err := l.allowCall(ctx, info.FullMethod, routes[info.FullMethod], sessionID, playerToken); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimit returns an interceptor that rejects streaming gRPC calls
+// over the limit, as UnaryRateLimit does. Streams are opened before their
+// request arrives, so they are only limited per client IP and route.
+func (l *RateLimiter) StreamRateLimit(routes map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allowCall(ss.Context(), info.FullMethod, routes[info.FullMethod], "", ""); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// allowCall takes a token for a gRPC call to method from the buckets of its
+// client, route and session, returning a ResourceExhausted error when any of
+// them is empty
+func (l *RateLimiter) allowCall(ctx context.Context, method, route, sessionID, playerToken string) error {
+	l.mu.Lock()
+	cfg := l.cfg
+	l.mu.Unlock()
+
+	if !cfg.Enabled {
+		return nil
+	}
+
+	clientIP := peerIP(ctx)
+	limits := []limit{{key: "ip:" + clientIP, rule: cfg.PerIP}}
+	if rule, ok := cfg.Routes[route]; ok {
+		limits = append(limits, limit{key: "route:" + route + ":" + clientIP, rule: *rule})
+	}
+	if sessionID != "" {
+		limits = append(limits, limit{key: sessionLimitKey(sessionID, playerToken, clientIP), rule: cfg.PerSession})
+	}
+
+	wait, ok := l.allow(time.Now(), limits)
+	if ok {
+		return nil
+	}
+	retryAfter := retryAfterSeconds(wait)
+
+	l.logger.Warn("rate limit exceeded",
+		"method", method,
+		"client_ip", clientIP,
+		"retry_after_s", retryAfter,
+	)
+
+	// The header is advisory, so a failure to send it is not worth reporting
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return status.Error(grpccodes.ResourceExhausted, "Rate limit exceeded")
+}
+
+// contextStream is a server stream whose context carries the call's span
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// metadataCarrier adapts gRPC metadata for trace context propagation
+type metadataCarrier metadata.MD
+
+// Get returns the first value for key
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set replaces the values for key
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys lists the metadata keys
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// peerAddr returns the address of the calling client, if known
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// peerIP returns the IP address of the calling client without its port, if
+// known
+func peerIP(ctx context.Context) string {
+	addr := peerAddr(ctx)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -13,7 +13,7 @@ func Logging(logger *slog.Logger) gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		method := c.Request.Method
-		sessionID := c.GetHeader("X-Session-ID")
+		sessionID := requestSessionID(c)
 
 		// Process request
 		c.Next()
@@ -33,4 +33,3 @@ func Logging(logger *slog.Logger) gin.HandlerFunc {
 		)
 	}
 }
-
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestValidator rejects requests that do not match the OpenAPI document
+type RequestValidator struct {
+	router routers.Router
+	logger *slog.Logger
+}
+
+// NewRequestValidator creates a request validator for doc
+func NewRequestValidator(doc *openapi3.T, logger *slog.Logger) (*RequestValidator, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+
+	return &RequestValidator{
+		router: router,
+		logger: logger,
+	}, nil
+}
+
+// Handler returns a middleware that responds with 400 Bad Request to
+// requests whose parameters or body do not match their operation. Routes
+// the document does not describe, such as static files, pass unchecked.
+func (v *RequestValidator) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, pathParams, err := v.router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+		}
+		// The body is put back for the handler once read
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			message := validationMessage(err)
+			v.logger.Warn("request does not match API spec",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"operation", route.Operation.OperationID,
+				"error", message,
+			)
+
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   http.StatusText(http.StatusBadRequest),
+				"message": message,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// validationMessage describes a validation error without the schema dump
+// kin-openapi includes in its error text
+func validationMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return err.Error()
+	}
+
+	reason := reqErr.Reason
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		reason = schemaErr.Reason
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			reason = fmt.Sprintf("%s: %s", strings.Join(pointer, "."), reason)
+		}
+	} else if reqErr.Err != nil {
+		reason = reqErr.Err.Error()
+	}
+
+	switch {
+	case reqErr.Parameter != nil:
+		return fmt.Sprintf("Invalid %s parameter %s: %s", reqErr.Parameter.In, reqErr.Parameter.Name, reason)
+	case reqErr.RequestBody != nil:
+		return "Invalid request body: " + reason
+	default:
+		return reason
+	}
+}
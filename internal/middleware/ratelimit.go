@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/config"
+)
+
+// bucketSweepInterval is how often refilled buckets are evicted
+const bucketSweepInterval = time.Minute
+
+// refillKey is the context key under which Handler stores the refill time
+// of the buckets a request drew from
+const refillKey = "rate_limit.refill"
+
+// tokenBucket holds the state of a single rate limit bucket
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket will have refilled completely
+}
+
+// RateLimiter enforces token bucket limits per client IP, per session and
+// per route. Buckets are created lazily and evicted once they have refilled.
+type RateLimiter struct {
+	cfg       config.RateLimitConfig
+	logger    *slog.Logger
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewRateLimiter creates a new rate limiter from configuration
+func NewRateLimiter(cfg config.RateLimitConfig, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		cfg:       cfg,
+		logger:    logger,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Update replaces the limiter's rules. Existing buckets keep their tokens
+// and are refilled at the new rates.
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg = cfg
+}
+
+// limit pairs a bucket key with the rule that governs it
+type limit struct {
+	key  string
+	rule config.RateLimitRule
+}
+
+// Handler returns a middleware that rejects requests over the limit with
+// 429 Too Many Requests and a Retry-After header
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.mu.Lock()
+		cfg := l.cfg
+		l.mu.Unlock()
+
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		clientIP := c.ClientIP()
+		limits := []limit{{key: "ip:" + clientIP, rule: cfg.PerIP}}
+
+		route := c.Request.Method + " " + c.FullPath()
+		if rule, ok := cfg.Routes[route]; ok {
+			limits = append(limits, limit{key: "route:" + route + ":" + clientIP, rule: *rule})
+		}
+		if sessionID := requestSessionID(c); sessionID != "" {
+			key := sessionLimitKey(sessionID, c.GetHeader("X-Player-Token"), clientIP)
+			limits = append(limits, limit{key: key, rule: cfg.PerSession})
+		}
+
+		if wait, ok := l.allow(time.Now(), limits); !ok {
+			retryAfter := retryAfterSeconds(wait)
+
+			l.logger.Warn("rate limit exceeded",
+				"path", c.Request.URL.Path,
+				"method", c.Request.Method,
+				"client_ip", clientIP,
+				"retry_after_s", retryAfter,
+			)
+
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   http.StatusText(http.StatusTooManyRequests),
+				"message": "Rate limit exceeded",
+			})
+			return
+		}
+
+		c.Set(refillKey, refillTime(limits))
+		c.Next()
+	}
+}
+
+// RetryAfter returns the Retry-After value, in whole seconds, for a request
+// the rate limiter let through but the server could not serve: the time the
+// slowest bucket the request drew from takes to regain a token. It reports
+// false when the request was not rate limited.
+func RetryAfter(c *gin.Context) (string, bool) {
+	v, ok := c.Get(refillKey)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(retryAfterSeconds(v.(time.Duration))), true
+}
+
+// refillTime returns the time the slowest bucket of limits takes to gain
+// one token
+func refillTime(limits []limit) time.Duration {
+	var refill time.Duration
+	for _, lim := range limits {
+		refill = max(refill, time.Duration(float64(time.Second)/lim.rule.Rate))
+	}
+	return refill
+}
+
+// sessionLimitKey returns the key of the per-session bucket of a request.
+// Session IDs are public, so a request draws from the bucket of the player
+// token it presents, or else from that of the session and its client IP:
+// other clients cannot use up a player's budget by naming its session.
+func sessionLimitKey(sessionID, playerToken, clientIP string) string {
+	if playerToken != "" {
+		return "player:" + playerToken
+	}
+	return "session:" + sessionID + ":" + clientIP
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, at least one
+func retryAfterSeconds(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
+
+// allow takes one token from every bucket in limits, or none if any bucket
+// is empty. When the request is rejected it returns how long the caller
+// should wait before retrying.
+func (l *RateLimiter) allow(now time.Time, limits []limit) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	var wait time.Duration
+	buckets := make([]*tokenBucket, len(limits))
+	for i, lim := range limits {
+		b, exists := l.buckets[lim.key]
+		if !exists {
+			b = &tokenBucket{tokens: float64(lim.rule.Burst), last: now}
+			l.buckets[lim.key] = b
+		}
+
+		b.tokens = math.Min(float64(lim.rule.Burst), b.tokens+now.Sub(b.last).Seconds()*lim.rule.Rate)
+		b.last = now
+		buckets[i] = b
+
+		if b.tokens < 1 {
+			need := time.Duration((1 - b.tokens) / lim.rule.Rate * float64(time.Second))
+			if need > wait {
+				wait = need
+			}
+		}
+	}
+
+	if wait > 0 {
+		return wait, false
+	}
+
+	for i, b := range buckets {
+		b.tokens--
+		rule := limits[i].rule
+		b.full = now.Add(time.Duration((float64(rule.Burst) - b.tokens) / rule.Rate * float64(time.Second)))
+	}
+	return 0, true
+}
+
+// sweep evicts buckets that have refilled completely, since a fresh bucket
+// is equivalent. Callers must hold l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if !now.Before(b.full) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/siddarth/go-app/internal/config"
+)
+
+// generous is a rule that no test exhausts
+var generous = config.RateLimitRule{Rate: 1000, Burst: 1000}
+
+// newLimitedRouter returns a router rate limited by cfg that trusts proxies
+// at trusted, with a game route and a route reporting the Retry-After hint
+// a handler would send
+func newLimitedRouter(t *testing.T, cfg config.RateLimitConfig, trusted []string) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trusted); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	cfg.Enabled = true
+	r.Use(NewRateLimiter(cfg, slog.New(slog.NewTextHandler(io.Discard, nil))).Handler())
+
+	r.GET("/api/v1/games/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/game/start", func(c *gin.Context) {
+		retryAfter, _ := RetryAfter(c)
+		c.String(http.StatusOK, retryAfter)
+	})
+	return r
+}
+
+// send makes a request from remoteAddr with the given headers and returns
+// the response
+func send(r *gin.Engine, method, path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiterRejectsOnceBucketIsEmpty(t *testing.T) {
+	r := newLimitedRouter(t, config.RateLimitConfig{
+		PerIP:      config.RateLimitRule{Rate: 0.5, Burst: 2},
+		PerSession: generous,
+	}, nil)
+
+	for i := 0; i < 2; i++ {
+		if w := send(r, http.MethodGet, "/api/v1/games/a", "10.0.0.1:1000", nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200", i, w.Code)
+		}
+	}
+
+	w := send(r, http.MethodGet, "/api/v1/games/a", "10.0.0.1:1000", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the burst: got %d, want 429", w.Code)
+	}
+	// One token comes back every two seconds
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After: got %q, want 2", got)
+	}
+
+	// Other clients have buckets of their own
+	if w := send(r, http.MethodGet, "/api/v1/games/a", "10.0.0.2:1000", nil); w.Code != http.StatusOK {
+		t.Errorf("another client: got %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimiterRefillsBuckets(t *testing.T) {
+	l := NewRateLimiter(config.RateLimitConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	limits := []limit{{key: "ip:a", rule: config.RateLimitRule{Rate: 1, Burst: 1}}}
+
+	now := time.Now()
+	if _, ok := l.allow(now, limits); !ok {
+		t.Fatal("first request rejected")
+	}
+	wait, ok := l.allow(now.Add(250*time.Millisecond), limits)
+	if ok || wait != 750*time.Millisecond {
+		t.Fatalf("request before refill: got wait %s and ok %t, want 750ms and false", wait, ok)
+	}
+	if _, ok := l.allow(now.Add(time.Second), limits); !ok {
+		t.Fatal("request after refill rejected")
+	}
+}
+
+func TestRateLimiterTakesNoTokenFromRejectedRequests(t *testing.T) {
+	l := NewRateLimiter(config.RateLimitConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	roomy := limit{key: "ip:a", rule: config.RateLimitRule{Rate: 1, Burst: 2}}
+	full := limit{key: "route:a", rule: config.RateLimitRule{Rate: 1, Burst: 1}}
+
+	now := time.Now()
+	if _, ok := l.allow(now, []limit{roomy, full}); !ok {
+		t.Fatal("first request rejected")
+	}
+	if _, ok := l.allow(now, []limit{roomy, full}); ok {
+		t.Fatal("request over the route limit accepted")
+	}
+	// The rejected request left the client's token in place
+	if _, ok := l.allow(now, []limit{roomy}); !ok {
+		t.Fatal("client bucket lost a token to a rejected request")
+	}
+}
+
+func TestRateLimiterSessionKeys(t *testing.T) {
+	r := newLimitedRouter(t, config.RateLimitConfig{
+		PerIP:      generous,
+		PerSession: config.RateLimitRule{Rate: 0.01, Burst: 1},
+	}, nil)
+
+	for _, tc := range []struct {
+		name       string
+		remoteAddr string
+		token      string
+		want       int
+	}{
+		{"player a", "10.0.0.1:1000", "a", http.StatusOK},
+		{"player a again", "10.0.0.2:1000", "a", http.StatusTooManyRequests},
+		{"player b", "10.0.0.1:1000", "b", http.StatusOK},
+		{"no token", "10.0.0.1:1000", "", http.StatusOK},
+		{"no token again", "10.0.0.1:1000", "", http.StatusTooManyRequests},
+		{"no token from another client", "10.0.0.2:1000", "", http.StatusOK},
+	} {
+		headers := map[string]string{"X-Player-Token": tc.token}
+		if w := send(r, http.MethodGet, "/api/v1/games/s", tc.remoteAddr, headers); w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
+
+func TestRateLimiterIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		PerIP:      config.RateLimitRule{Rate: 0.01, Burst: 1},
+		PerSession: generous,
+	}
+
+	// Made-up client IPs from an untrusted peer share its bucket
+	r := newLimitedRouter(t, cfg, nil)
+	send(r, http.MethodGet, "/api/v1/games/a", "203.0.113.7:1000", map[string]string{"X-Forwarded-For": "198.51.100.1"})
+	w := send(r, http.MethodGet, "/api/v1/games/a", "203.0.113.7:1000", map[string]string{"X-Forwarded-For": "198.51.100.2"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed X-Forwarded-For: got %d, want 429", w.Code)
+	}
+
+	// Behind a trusted proxy each forwarded client has its own bucket
+	r = newLimitedRouter(t, cfg, []string{"10.0.0.0/8"})
+	send(r, http.MethodGet, "/api/v1/games/a", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "198.51.100.1"})
+	w = send(r, http.MethodGet, "/api/v1/games/a", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "198.51.100.2"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("client behind a trusted proxy: got %d, want 200", w.Code)
+	}
+}
+
+func TestRetryAfterReportsSlowestRefill(t *testing.T) {
+	r := newLimitedRouter(t, config.RateLimitConfig{
+		PerIP:      generous,
+		PerSession: generous,
+		Routes: map[string]*config.RateLimitRule{
+			"POST /api/game/start": {Rate: 0.2, Burst: 5},
+		},
+	}, nil)
+
+	w := send(r, http.MethodPost, "/api/game/start", "10.0.0.1:1000", nil)
+	if got := w.Body.String(); got != "5" {
+		t.Fatalf("RetryAfter: got %q, want 5", got)
+	}
+}
@@ -29,4 +29,3 @@ func Recovery(logger *slog.Logger) gin.HandlerFunc {
 		c.Next()
 	}
 }
-
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gameResourcePath is the route prefix of the versioned game resources,
+// which carry the session ID in the path instead of a header
+const gameResourcePath = "/api/v1/games/:id"
+
+// requestSessionID returns the game session a request addresses, from the
+// X-Session-ID header or the path of a versioned game resource
+func requestSessionID(c *gin.Context) string {
+	if id := c.GetHeader("X-Session-ID"); id != "" {
+		return id
+	}
+	if strings.HasPrefix(c.FullPath(), gameResourcePath) {
+		return c.Param("id")
+	}
+	return ""
+}
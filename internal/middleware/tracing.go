@@ -36,7 +36,7 @@ func Tracing(serviceName string) gin.HandlerFunc {
 		)
 
 		// Add session ID if present
-		if sessionID := c.GetHeader("X-Session-ID"); sessionID != "" {
+		if sessionID := requestSessionID(c); sessionID != "" {
 			span.SetAttributes(attribute.String("session.id", sessionID))
 		}
 
@@ -50,4 +50,3 @@ func Tracing(serviceName string) gin.HandlerFunc {
 		span.SetAttributes(attribute.Int("http.status_code", c.Writer.Status()))
 	}
 }
-
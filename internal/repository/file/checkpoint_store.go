@@ -0,0 +1,108 @@
+// Package file keeps data in local files, so that it outlives the process
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// checkpointVersion identifies the checkpoint format, so that a server
+// never resumes games it cannot read correctly. Version 2 stores boards
+// as rows of tile symbols.
+const checkpointVersion = 2
+
+// checkpoint is the file format of a checkpoint
+type checkpoint struct {
+	Version int                       `json:"version"`
+	SavedAt time.Time                 `json:"savedAt"`
+	Games   []domain.CheckpointedGame `json:"games"`
+}
+
+// CheckpointStore implements domain.CheckpointStore with a JSON file
+type CheckpointStore struct {
+	path string
+}
+
+// NewCheckpointStore creates a checkpoint store writing to path
+func NewCheckpointStore(path string) *CheckpointStore {
+	return &CheckpointStore{path: path}
+}
+
+// Save replaces the checkpoint file with games. The file is written next
+// to the old one and renamed over it, so a crash never leaves half a
+// checkpoint behind.
+func (s *CheckpointStore) Save(ctx context.Context, games []domain.CheckpointedGame) error {
+	data, err := json.Marshal(checkpoint{
+		Version: checkpointVersion,
+		SavedAt: time.Now(),
+		Games:   games,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads the checkpoint file. A missing file is an empty checkpoint.
+func (s *CheckpointStore) Load(ctx context.Context) ([]domain.CheckpointedGame, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint %s: %w", s.path, err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %s has version %d, expected %d", s.path, cp.Version, checkpointVersion)
+	}
+
+	return cp.Games, nil
+}
+
+// Clear removes the checkpoint file
+func (s *CheckpointStore) Clear(ctx context.Context) error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
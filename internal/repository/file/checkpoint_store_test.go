@@ -0,0 +1,122 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// newGame creates a game on the default maze with the normal rules and a
+// host and guest, advanced a few ticks
+func newGame(t *testing.T, id string) *domain.Game {
+	t.Helper()
+
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	engine := service.NewEngine(1)
+	game, err := engine.NewGame(id, *cfg.Game.Presets["normal"], "", []domain.Player{
+		{Name: "host", Token: id + "-host"},
+		{Name: "guest", Token: id + "-guest"},
+	})
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		engine.Advance(game)
+	}
+	return game
+}
+
+func TestCheckpointStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// Missing directories are created on save
+	store := NewCheckpointStore(filepath.Join(dir, "state", "checkpoint.json"))
+
+	if games, err := store.Load(ctx); err != nil || games != nil {
+		t.Fatalf("Load without a checkpoint: got %v and %v, want nothing", games, err)
+	}
+
+	want := []domain.CheckpointedGame{
+		{Game: newGame(t, "a"), Running: true},
+		{Game: newGame(t, "b"), Running: false},
+	}
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Load: got %d games, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Running != w.Running || g.Game.ID != w.Game.ID {
+			t.Errorf("game %d: got %s running %t, want %s running %t", i, g.Game.ID, g.Running, w.Game.ID, w.Running)
+		}
+		if !reflect.DeepEqual(g.Game.ToGameState(), w.Game.ToGameState()) {
+			t.Errorf("game %s: state changed in the checkpoint", w.Game.ID)
+		}
+		for j, p := range w.Game.Players {
+			if g.Game.Players[j].Token != p.Token {
+				t.Errorf("game %s: player %s lost its token", w.Game.ID, p.ID)
+			}
+		}
+		if g.Game.SpectatorToken != w.Game.SpectatorToken || !reflect.DeepEqual(g.Game.Rules, w.Game.Rules) {
+			t.Errorf("game %s: spectator token or rules changed", w.Game.ID)
+		}
+	}
+
+	// Only the checkpoint is left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "state"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("checkpoint directory: got %v and %v, want only the checkpoint", entries, err)
+	}
+
+	if err := store.Clear(ctx); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if games, err := store.Load(ctx); err != nil || games != nil {
+		t.Fatalf("Load after Clear: got %v and %v, want nothing", games, err)
+	}
+	if err := store.Clear(ctx); err != nil {
+		t.Fatalf("second Clear: %v", err)
+	}
+}
+
+func TestCheckpointStoreRejectsUnreadableFiles(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{"corrupt", `{"version": 2, "games": [`},
+		{"other version", `{"version": 1, "games": []}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			if err := os.WriteFile(path, []byte(tc.content), 0o600); err != nil {
+				t.Fatalf("failed to write checkpoint: %v", err)
+			}
+
+			if _, err := NewCheckpointStore(path).Load(ctx); err == nil {
+				t.Fatal("Load accepted an unreadable checkpoint")
+			}
+			if data, err := os.ReadFile(path); err != nil || string(data) != tc.content {
+				t.Fatalf("unreadable checkpoint was changed: %q %v", data, err)
+			}
+		})
+	}
+}
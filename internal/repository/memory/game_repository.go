@@ -4,66 +4,168 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/siddarth/go-app/internal/domain"
 )
 
-// GameRepository implements domain.GameRepository using in-memory storage
+// DefaultGameShards is the number of shards used when none is given
+const DefaultGameShards = 64
+
+// expiryInterval is how often RunExpiry removes expired games
+const expiryInterval = time.Minute
+
+// Save times that mark entries taken out of their shard, so that a
+// concurrent Save cannot refresh them
+const (
+	// removedAt marks entries removed because they expired. Saving one
+	// adds the game again.
+	removedAt = -1
+	// deletedAt marks deleted entries. A save racing the delete is
+	// ordered before it, leaving the game deleted.
+	deletedAt = -2
+)
+
+// GameRepository implements domain.GameRepository using in-memory storage.
+// It stores a copy of every saved game and hands out copies, so callers
+// never share a game that another goroutine changes. Games are spread over
+// shards by ID. Adding and removing games locks only their shard and
+// replaces its map with an updated copy, so readers use the current copy
+// without locking. Saving a game that is already stored swaps in the new
+// copy without locking or copying the map.
 type GameRepository struct {
-	games map[string]*domain.Game
-	mu    sync.RWMutex
+	shards []*gameShard
+	// ttl is how long a game is kept after it was last saved; zero keeps
+	// games until they are deleted
+	ttl time.Duration
+	// clock returns the current time
+	clock func() time.Time
 }
 
-// NewGameRepository creates a new in-memory game repository
-func NewGameRepository() *GameRepository {
-	return &GameRepository{
-		games: make(map[string]*domain.Game),
+// gameShard holds the games of one shard
+type gameShard struct {
+	// mu serializes writers
+	mu sync.Mutex
+	// games is never changed once published, only replaced
+	games atomic.Pointer[map[string]*gameEntry]
+}
+
+// gameEntry is a stored game and when it was last saved, in Unix
+// nanoseconds. The stored game is never changed, only replaced.
+type gameEntry struct {
+	game    atomic.Pointer[domain.Game]
+	savedAt atomic.Int64
+}
+
+// NewGameRepository creates a new in-memory game repository with shards
+// shards, or DefaultGameShards when shards is not positive. Games expire
+// ttl after they were last saved, unless ttl is zero.
+func NewGameRepository(shards int, ttl time.Duration) *GameRepository {
+	if shards <= 0 {
+		shards = DefaultGameShards
 	}
+
+	r := &GameRepository{
+		shards: make([]*gameShard, shards),
+		ttl:    ttl,
+		clock:  time.Now,
+	}
+	for i := range r.shards {
+		sh := &gameShard{}
+		games := make(map[string]*gameEntry)
+		sh.games.Store(&games)
+		r.shards[i] = sh
+	}
+	return r
 }
 
-// Save persists a game to memory
+// Save stores a copy of game
 func (r *GameRepository) Save(ctx context.Context, game *domain.Game) error {
 	if game == nil {
-		return fmt.Errorf("game cannot be nil")
+		return fmt.Errorf("%w: game cannot be nil", domain.ErrInvalidGame)
 	}
 	if game.ID == "" {
-		return fmt.Errorf("game ID cannot be empty")
+		return fmt.Errorf("%w: game ID cannot be empty", domain.ErrInvalidGame)
+	}
+
+	now := r.now()
+	snapshot := game.Clone()
+	sh := r.shardFor(game.ID)
+
+	// Game loops save the game they loaded on every tick. Refreshing the
+	// save time first keeps RemoveExpired from removing the entry unless
+	// it already has, in which case the game is added again below.
+	if e, ok := (*sh.games.Load())[game.ID]; ok {
+		for {
+			saved := e.savedAt.Load()
+			if saved == deletedAt {
+				return nil
+			}
+			if saved == removedAt {
+				break
+			}
+			if e.savedAt.CompareAndSwap(saved, now) {
+				e.game.Store(snapshot)
+				return nil
+			}
+		}
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	
-	r.games[game.ID] = game
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e := &gameEntry{}
+	e.game.Store(snapshot)
+	e.savedAt.Store(now)
+
+	old := *sh.games.Load()
+	games := make(map[string]*gameEntry, len(old)+1)
+	for id, entry := range old {
+		games[id] = entry
+	}
+	games[game.ID] = e
+	sh.games.Store(&games)
 	return nil
 }
 
-// FindByID retrieves a game by ID
+// FindByID retrieves a copy of a game by ID
 func (r *GameRepository) FindByID(ctx context.Context, id string) (*domain.Game, error) {
 	if id == "" {
-		return nil, fmt.Errorf("game ID cannot be empty")
+		return nil, domain.ErrInvalidSessionID
 	}
 
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	
-	game, exists := r.games[id]
-	if !exists {
-		return nil, fmt.Errorf("game not found: %s", id)
+	e, ok := (*r.shardFor(id).games.Load())[id]
+	if !ok || r.expired(e, r.now()) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrGameNotFound, id)
 	}
-	
-	return game, nil
+
+	return e.game.Load().Clone(), nil
 }
 
 // Delete removes a game from storage
 func (r *GameRepository) Delete(ctx context.Context, id string) error {
 	if id == "" {
-		return fmt.Errorf("game ID cannot be empty")
+		return domain.ErrInvalidSessionID
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	
-	delete(r.games, id)
+	sh := r.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	old := *sh.games.Load()
+	e, ok := old[id]
+	if !ok {
+		return nil
+	}
+	e.savedAt.Store(deletedAt)
+	games := make(map[string]*gameEntry, len(old))
+	for key, entry := range old {
+		if key != id {
+			games[key] = entry
+		}
+	}
+	sh.games.Store(&games)
 	return nil
 }
 
@@ -73,10 +175,181 @@ func (r *GameRepository) Exists(ctx context.Context, id string) bool {
 		return false
 	}
 
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	
-	_, exists := r.games[id]
-	return exists
+	e, ok := (*r.shardFor(id).games.Load())[id]
+	return ok && !r.expired(e, r.now())
+}
+
+// List returns copies of every stored game
+func (r *GameRepository) List(ctx context.Context) ([]*domain.Game, error) {
+	games := make([]*domain.Game, 0, r.size())
+	err := r.Range(ctx, func(game *domain.Game) bool {
+		games = append(games, game)
+		return true
+	})
+	return games, err
+}
+
+// Count returns the number of stored games
+func (r *GameRepository) Count(ctx context.Context) (int, error) {
+	if r.ttl <= 0 {
+		return r.size(), nil
+	}
+
+	count := 0
+	err := r.rangeEntries(ctx, func(*gameEntry) bool {
+		count++
+		return true
+	})
+	return count, err
+}
+
+// Range calls fn with a copy of every game stored when it starts, shard by
+// shard, until fn returns false. fn may use the repository.
+func (r *GameRepository) Range(ctx context.Context, fn func(game *domain.Game) bool) error {
+	return r.rangeEntries(ctx, func(e *gameEntry) bool {
+		return fn(e.game.Load().Clone())
+	})
+}
+
+// rangeEntries calls fn for every entry that has not expired, shard by
+// shard, until fn returns false
+func (r *GameRepository) rangeEntries(ctx context.Context, fn func(e *gameEntry) bool) error {
+	now := r.now()
+	for _, sh := range r.shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, e := range *sh.games.Load() {
+			if r.expired(e, now) {
+				continue
+			}
+			if !fn(e) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// ExpiresAt returns when a stored game expires, and false when games do
+// not expire or the game is not stored
+func (r *GameRepository) ExpiresAt(ctx context.Context, id string) (time.Time, bool) {
+	if r.ttl <= 0 || id == "" {
+		return time.Time{}, false
+	}
+
+	e, ok := (*r.shardFor(id).games.Load())[id]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, e.savedAt.Load()).Add(r.ttl), true
+}
+
+// RemoveExpired deletes the games last saved more than the TTL before now
+// and returns them
+func (r *GameRepository) RemoveExpired(now time.Time) []*domain.Game {
+	if r.ttl <= 0 {
+		return nil
+	}
+
+	var removed []*domain.Game
+	for _, sh := range r.shards {
+		sh.mu.Lock()
+		removed = r.removeExpired(sh, now.UnixNano(), removed)
+		sh.mu.Unlock()
+	}
+	return removed
+}
+
+// removeExpired deletes the expired games of sh, copying its map only if
+// there are any, and appends them to removed. Callers must hold the
+// shard's mu.
+func (r *GameRepository) removeExpired(sh *gameShard, now int64, removed []*domain.Game) []*domain.Game {
+	old := *sh.games.Load()
+	var expired []string
+	for id, e := range old {
+		saved := e.savedAt.Load()
+		// A game saved since it was checked is kept
+		if r.expiredAt(saved, now) && e.savedAt.CompareAndSwap(saved, removedAt) {
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) == 0 {
+		return removed
+	}
+
+	games := make(map[string]*gameEntry, len(old)-len(expired))
+	for id, e := range old {
+		games[id] = e
+	}
+	for _, id := range expired {
+		removed = append(removed, games[id].game.Load().Clone())
+		delete(games, id)
+	}
+	sh.games.Store(&games)
+	return removed
+}
+
+// RunExpiry removes expired games every minute until ctx is done, calling
+// onExpired, if set, with each game it removed. Expired games are
+// invisible before they are removed.
+func (r *GameRepository) RunExpiry(ctx context.Context, onExpired func(game *domain.Game)) {
+	if r.ttl <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, game := range r.RemoveExpired(now) {
+				if onExpired != nil {
+					onExpired(game)
+				}
+			}
+		}
+	}
+}
+
+// expired reports whether e is past its TTL at now, in Unix nanoseconds
+func (r *GameRepository) expired(e *gameEntry, now int64) bool {
+	return r.expiredAt(e.savedAt.Load(), now)
+}
+
+// expiredAt reports whether a game saved at saved is past its TTL at now,
+// both in Unix nanoseconds
+func (r *GameRepository) expiredAt(saved, now int64) bool {
+	return r.ttl > 0 && (saved == removedAt || now-saved > int64(r.ttl))
 }
 
+// size returns the number of stored games, including expired ones
+func (r *GameRepository) size() int {
+	n := 0
+	for _, sh := range r.shards {
+		n += len(*sh.games.Load())
+	}
+	return n
+}
+
+// shardFor returns the shard of a game, hashing its ID with FNV-1a
+func (r *GameRepository) shardFor(id string) *gameShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return r.shards[h%uint32(len(r.shards))]
+}
+
+// now returns the current time in Unix nanoseconds, or zero when games do
+// not expire and the time is not needed
+func (r *GameRepository) now() int64 {
+	if r.ttl <= 0 {
+		return 0
+	}
+	return r.clock().UnixNano()
+}
@@ -0,0 +1,371 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// newTestGame returns a game with one player on a small board
+func newTestGame(id string) *domain.Game {
+	return &domain.Game{
+		ID:         id,
+		Board:      domain.NewBoard([]string{"#....#"}, 6, 1),
+		Players:    []domain.Player{{ID: "p1", Position: domain.Position{X: 1}, Lives: 3}},
+		MaxPlayers: 1,
+		Spectators: map[string]time.Time{},
+	}
+}
+
+// fakeClock is a clock tests move by hand
+type fakeClock struct {
+	now atomic.Int64
+}
+
+// newRepositoryWithClock creates a repository whose time is the returned
+// fake clock, starting at an arbitrary time
+func newRepositoryWithClock(ttl time.Duration) (*GameRepository, *fakeClock) {
+	clock := &fakeClock{}
+	clock.now.Store(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+
+	r := NewGameRepository(4, ttl)
+	r.clock = clock.time
+	return r, clock
+}
+
+// time returns the current time of the clock
+func (c *fakeClock) time() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+// advance moves the clock on by d
+func (c *fakeClock) advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+func TestGameRepositoryHandsOutCopies(t *testing.T) {
+	ctx := context.Background()
+	r := NewGameRepository(0, 0)
+
+	game := newTestGame("a")
+	if err := r.Save(ctx, game); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Changing the saved game does not change the stored one
+	game.Players[0].Score = 100
+	game.Board.EatDot(domain.Position{X: 1})
+	game.WatchedBy("viewer", time.Now())
+
+	found, err := r.FindByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.Players[0].Score != 0 || found.DotsLeft() != 4 || len(found.Spectators) != 0 {
+		t.Fatalf("stored game changed with the saved one: score %d, dots %d, spectators %d",
+			found.Players[0].Score, found.DotsLeft(), len(found.Spectators))
+	}
+
+	// Nor does changing a game that was found
+	found.Players[0].Score = 50
+	found.Board.EatDot(domain.Position{X: 2})
+
+	again, err := r.FindByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if again.Players[0].Score != 0 || again.DotsLeft() != 4 {
+		t.Fatalf("stored game changed with a found one: score %d, dots %d", again.Players[0].Score, again.DotsLeft())
+	}
+
+	// Until it is saved
+	if err := r.Save(ctx, found); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	games, err := r.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(games) != 1 || games[0].Players[0].Score != 50 || games[0].DotsLeft() != 3 {
+		t.Fatalf("List did not return the saved game: %+v", games)
+	}
+}
+
+func TestGameRepositoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	r, clock := newRepositoryWithClock(time.Minute)
+
+	for _, id := range []string{"a", "b"} {
+		if err := r.Save(ctx, newTestGame(id)); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	// Saving b refreshes it
+	clock.advance(30 * time.Second)
+	b, err := r.FindByID(ctx, "b")
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if err := r.Save(ctx, b); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// a is past its TTL and invisible before it is removed
+	clock.advance(40 * time.Second)
+	if _, err := r.FindByID(ctx, "a"); !errors.Is(err, domain.ErrGameNotFound) {
+		t.Fatalf("FindByID of an expired game: got %v, want %v", err, domain.ErrGameNotFound)
+	}
+	if r.Exists(ctx, "a") {
+		t.Fatal("an expired game exists")
+	}
+	if count, _ := r.Count(ctx); count != 1 {
+		t.Fatalf("Count: got %d, want 1", count)
+	}
+	if at, ok := r.ExpiresAt(ctx, "b"); !ok || !at.Equal(clock.time().Add(20*time.Second)) {
+		t.Fatalf("ExpiresAt of b: got %v %v, want %v", at, ok, clock.time().Add(20*time.Second))
+	}
+
+	removed := r.RemoveExpired(clock.time())
+	if len(removed) != 1 || removed[0].ID != "a" {
+		t.Fatalf("RemoveExpired: got %v, want only a", removed)
+	}
+	if len(r.RemoveExpired(clock.time())) != 0 {
+		t.Fatal("RemoveExpired removed a game twice")
+	}
+	if !r.Exists(ctx, "b") {
+		t.Fatal("b was removed before it expired")
+	}
+}
+
+func TestGameRepositorySaveRacingExpiryKeepsGame(t *testing.T) {
+	ctx := context.Background()
+
+	for i := 0; i < 500; i++ {
+		r, clock := newRepositoryWithClock(time.Minute)
+		game := newTestGame("a")
+		if err := r.Save(ctx, game); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		clock.advance(2 * time.Minute)
+
+		// The game expired, but one goroutine saves it while another
+		// removes expired games. Whichever wins, the saved game stays.
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		var saveErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			saveErr = r.Save(ctx, game)
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			r.RemoveExpired(clock.time())
+		}()
+		close(start)
+		wg.Wait()
+
+		if saveErr != nil {
+			t.Fatalf("Save: %v", saveErr)
+		}
+		if _, err := r.FindByID(ctx, "a"); err != nil {
+			t.Fatalf("iteration %d: saved game lost to expiry: %v", i, err)
+		}
+		if removed := r.RemoveExpired(clock.time()); len(removed) != 0 {
+			t.Fatalf("iteration %d: saved game removed as expired", i)
+		}
+	}
+}
+
+func TestGameRepositorySaveRacingDelete(t *testing.T) {
+	ctx := context.Background()
+	r := NewGameRepository(1, 0)
+
+	for i := 0; i < 500; i++ {
+		game := newTestGame("a")
+		if err := r.Save(ctx, game); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		// A save that loaded the entry before the delete finds it marked
+		stale := (*r.shardFor("a").games.Load())["a"]
+
+		// Tick-like saves race the delete. Whichever is ordered last
+		// decides whether the game is stored, but the repository must
+		// agree with itself either way.
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		wg.Add(3)
+		for j := 0; j < 2; j++ {
+			go func() {
+				defer wg.Done()
+				<-start
+				for k := 0; k < 5; k++ {
+					if err := r.Save(ctx, game); err != nil {
+						t.Errorf("Save: %v", err)
+					}
+				}
+			}()
+		}
+		go func() {
+			defer wg.Done()
+			<-start
+			if err := r.Delete(ctx, "a"); err != nil {
+				t.Errorf("Delete: %v", err)
+			}
+		}()
+		close(start)
+		wg.Wait()
+
+		if got := stale.savedAt.Load(); got != deletedAt {
+			t.Fatalf("iteration %d: deleted entry has save time %d, want %d", i, got, deletedAt)
+		}
+		_, err := r.FindByID(ctx, "a")
+		count, _ := r.Count(ctx)
+		if found := err == nil; found != r.Exists(ctx, "a") || found != (count == 1) {
+			t.Fatalf("iteration %d: FindByID %v, Exists %t and Count %d disagree", i, err, r.Exists(ctx, "a"), count)
+		}
+
+		// A delete after the race always removes the game
+		if err := r.Delete(ctx, "a"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if r.Exists(ctx, "a") {
+			t.Fatalf("iteration %d: game exists after Delete", i)
+		}
+	}
+}
+
+func TestGameRepositoryRejectsInvalidGames(t *testing.T) {
+	ctx := context.Background()
+	r := NewGameRepository(0, 0)
+
+	if err := r.Save(ctx, nil); !errors.Is(err, domain.ErrInvalidGame) {
+		t.Errorf("Save(nil): got %v, want %v", err, domain.ErrInvalidGame)
+	}
+	if err := r.Save(ctx, &domain.Game{}); !errors.Is(err, domain.ErrInvalidGame) {
+		t.Errorf("Save without ID: got %v, want %v", err, domain.ErrInvalidGame)
+	}
+	if _, err := r.FindByID(ctx, ""); !errors.Is(err, domain.ErrInvalidSessionID) {
+		t.Errorf("FindByID(\"\"): got %v, want %v", err, domain.ErrInvalidSessionID)
+	}
+}
+
+// benchmarkStore is the part of a repository the benchmarks use
+type benchmarkStore interface {
+	Save(ctx context.Context, game *domain.Game) error
+	FindByID(ctx context.Context, id string) (*domain.Game, error)
+	List(ctx context.Context) ([]*domain.Game, error)
+}
+
+// singleRepository stores copies of games in one map behind one lock, as
+// the repository did before it was sharded
+type singleRepository struct {
+	mu    sync.RWMutex
+	games map[string]*domain.Game
+}
+
+// Save stores a copy of game
+func (r *singleRepository) Save(ctx context.Context, game *domain.Game) error {
+	snapshot := game.Clone()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games[game.ID] = snapshot
+	return nil
+}
+
+// FindByID returns a copy of a stored game
+func (r *singleRepository) FindByID(ctx context.Context, id string) (*domain.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	game, ok := r.games[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrGameNotFound, id)
+	}
+	return game.Clone(), nil
+}
+
+// List returns copies of every stored game
+func (r *singleRepository) List(ctx context.Context) ([]*domain.Game, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	games := make([]*domain.Game, 0, len(r.games))
+	for _, game := range r.games {
+		games = append(games, game.Clone())
+	}
+	return games, nil
+}
+
+// BenchmarkGameRepository compares the sharded repository with a single
+// map behind one lock, with every CPU ticking, reading or listing games
+func BenchmarkGameRepository(b *testing.B) {
+	const sessions = 10000
+
+	stores := []struct {
+		name string
+		new  func() benchmarkStore
+	}{
+		{"single", func() benchmarkStore { return &singleRepository{games: make(map[string]*domain.Game)} }},
+		{"sharded", func() benchmarkStore { return NewGameRepository(DefaultGameShards, time.Hour) }},
+	}
+
+	ops := []struct {
+		name string
+		run  func(ctx context.Context, store benchmarkStore, id string) error
+	}{
+		{"tick", func(ctx context.Context, store benchmarkStore, id string) error {
+			game, err := store.FindByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			return store.Save(ctx, game)
+		}},
+		{"read", func(ctx context.Context, store benchmarkStore, id string) error {
+			_, err := store.FindByID(ctx, id)
+			return err
+		}},
+		{"list", func(ctx context.Context, store benchmarkStore, _ string) error {
+			_, err := store.List(ctx)
+			return err
+		}},
+	}
+
+	ctx := context.Background()
+	for _, s := range stores {
+		store := s.new()
+		for i := 0; i < sessions; i++ {
+			if err := store.Save(ctx, newTestGame(strconv.Itoa(i))); err != nil {
+				b.Fatalf("Save: %v", err)
+			}
+		}
+
+		for _, op := range ops {
+			b.Run(s.name+"/"+op.name, func(b *testing.B) {
+				b.ReportAllocs()
+				var seed atomic.Int64
+				b.RunParallel(func(pb *testing.PB) {
+					rng := mathrand.New(mathrand.NewSource(seed.Add(1)))
+					for pb.Next() {
+						if err := op.run(ctx, store, strconv.Itoa(rng.Intn(sessions))); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
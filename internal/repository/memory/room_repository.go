@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// RoomRepository implements domain.RoomRepository using in-memory storage
+type RoomRepository struct {
+	rooms map[string]*domain.Room
+	mu    sync.RWMutex
+}
+
+// NewRoomRepository creates a new in-memory room repository
+func NewRoomRepository() *RoomRepository {
+	return &RoomRepository{
+		rooms: make(map[string]*domain.Room),
+	}
+}
+
+// Save persists a room to memory
+func (r *RoomRepository) Save(ctx context.Context, room *domain.Room) error {
+	if room == nil || room.ID == "" {
+		return fmt.Errorf("room and room ID cannot be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rooms[room.ID] = room
+	return nil
+}
+
+// FindByID retrieves a room by ID
+func (r *RoomRepository) FindByID(ctx context.Context, id string) (*domain.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	room, exists := r.rooms[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", domain.ErrRoomNotFound, id)
+	}
+
+	return room, nil
+}
+
+// Delete removes a room from storage
+func (r *RoomRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.rooms, id)
+	return nil
+}
+
+// List returns every stored room
+func (r *RoomRepository) List(ctx context.Context) ([]*domain.Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rooms := make([]*domain.Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
@@ -0,0 +1,214 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/repository/memory"
+	"github.com/siddarth/go-app/internal/scheduler"
+	"github.com/siddarth/go-app/internal/service"
+)
+
+// benchInterval is the tick interval of every benchmarked game
+const benchInterval = 10 * time.Millisecond
+
+// benchStripes is the number of locks the benchmarked games are spread over
+const benchStripes = 64
+
+// workload ticks games the way the game service does: under the game's
+// lock, loading it from the repository and saving it back
+type workload struct {
+	repo  *memory.GameRepository
+	rules domain.GameRules
+	locks []workloadLock
+
+	// done is closed once target ticks have run
+	ticks  atomic.Int64
+	target int64
+	done   chan struct{}
+	once   sync.Once
+
+	lagMu sync.Mutex
+	lags  []time.Duration
+}
+
+// workloadLock guards the games of one stripe and the engine ticking them
+type workloadLock struct {
+	sync.Mutex
+	engine *service.Engine
+}
+
+// newWorkload creates n games named 0 to n-1 that tick until target ticks
+// have run in total
+func newWorkload(b *testing.B, n, target int) *workload {
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		b.Fatalf("failed to load configuration: %v", err)
+	}
+	rules := *cfg.Game.Presets[cfg.Game.DefaultPreset]
+	rules.TickInterval = benchInterval
+
+	w := &workload{
+		repo:   memory.NewGameRepository(0, 0),
+		rules:  rules,
+		locks:  make([]workloadLock, benchStripes),
+		target: int64(target),
+		done:   make(chan struct{}),
+		lags:   make([]time.Duration, 0, target),
+	}
+	for i := range w.locks {
+		w.locks[i].engine = service.NewEngine(int64(i))
+	}
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(i)
+		if err := w.reset(w.locks[i%benchStripes].engine, id); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return w
+}
+
+// reset replaces a game with a new one
+func (w *workload) reset(engine *service.Engine, id string) error {
+	game, err := engine.NewGame(id, w.rules, "", make([]domain.Player, 1))
+	if err != nil {
+		return fmt.Errorf("failed to create game: %w", err)
+	}
+	return w.repo.Save(context.Background(), game)
+}
+
+// tick advances game n that was due at due, starting a new one when it has
+// finished so that every session keeps ticking
+func (w *workload) tick(n int, id string, due time.Time) error {
+	lag := time.Since(due)
+	if w.ticks.Add(1) > w.target {
+		return nil
+	}
+	w.lagMu.Lock()
+	w.lags = append(w.lags, lag)
+	w.lagMu.Unlock()
+	defer func() {
+		if w.ticks.Load() >= w.target {
+			w.once.Do(func() { close(w.done) })
+		}
+	}()
+
+	lock := &w.locks[n%benchStripes]
+	lock.Lock()
+	defer lock.Unlock()
+
+	ctx := context.Background()
+	game, err := w.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if game.IsFinished() {
+		return w.reset(lock.engine, id)
+	}
+	lock.engine.Advance(game)
+	game.UpdatedAt = time.Now()
+	return w.repo.Save(ctx, game)
+}
+
+// report adds the tick lag percentiles to the benchmark's results
+func (w *workload) report(b *testing.B) {
+	w.lagMu.Lock()
+	defer w.lagMu.Unlock()
+	if len(w.lags) == 0 {
+		return
+	}
+	slices.Sort(w.lags)
+	b.ReportMetric(float64(w.lags[(len(w.lags)-1)*50/100].Microseconds()), "lag-p50-µs")
+	b.ReportMetric(float64(w.lags[(len(w.lags)-1)*99/100].Microseconds()), "lag-p99-µs")
+}
+
+// runLoops runs every session on its own goroutine and ticker, as game
+// loops ran before the scheduler, and returns a function stopping them
+func runLoops(w *workload, n int) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(n int, id string) {
+			defer wg.Done()
+			ticker := time.NewTicker(benchInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case due := <-ticker.C:
+					if err := w.tick(n, id, due); err != nil {
+						return
+					}
+				}
+			}
+		}(i, strconv.Itoa(i))
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// runScheduler runs every session on a scheduler and returns a function
+// stopping it, which reports the ticks it skipped
+func runScheduler(b *testing.B, w *workload, n int) func() {
+	sched := scheduler.New(scheduler.Options{})
+	for i := 0; i < n; i++ {
+		i, id := i, strconv.Itoa(i)
+		err := sched.Add(id, benchInterval, func(due time.Time) error {
+			return w.tick(i, id, due)
+		})
+		if err != nil {
+			b.Fatalf("Add: %v", err)
+		}
+	}
+	return func() {
+		b.ReportMetric(float64(sched.Stats().Skipped), "skipped")
+		if err := sched.Stop(context.Background()); err != nil {
+			b.Fatalf("Stop: %v", err)
+		}
+	}
+}
+
+// BenchmarkGameLoops compares the scheduler with a goroutine and ticker
+// per game, at increasing numbers of sessions ticking every 10ms. Each
+// iteration is one game tick.
+func BenchmarkGameLoops(b *testing.B) {
+	drivers := []struct {
+		name string
+		run  func(b *testing.B, w *workload, n int) func()
+	}{
+		{"loop", func(_ *testing.B, w *workload, n int) func() { return runLoops(w, n) }},
+		{"scheduler", runScheduler},
+	}
+
+	for _, sessions := range []int{100, 1000, 5000} {
+		for _, driver := range drivers {
+			b.Run(fmt.Sprintf("%s/sessions=%d", driver.name, sessions), func(b *testing.B) {
+				w := newWorkload(b, sessions, b.N)
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				stop := driver.run(b, w, sessions)
+				goroutines := runtime.NumGoroutine()
+				<-w.done
+				b.StopTimer()
+				stop()
+
+				b.ReportMetric(float64(goroutines), "goroutines")
+				w.report(b)
+			})
+		}
+	}
+}
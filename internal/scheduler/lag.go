@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// lagWindow is how long a tick's lag counts towards Lag
+const lagWindow = 10 * time.Second
+
+// lagTracker keeps the worst lag of a shard's ticks seen in the current and
+// previous window, so that one slow tick is reported for at least a full
+// window. Each shard has its own, so workers never wait on each other to
+// record lag.
+type lagTracker struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	current     time.Duration
+	previous    time.Duration
+}
+
+// observe records the lag of one tick
+func (t *lagTracker) observe(lag time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate(time.Now())
+	if lag > t.current {
+		t.current = lag
+	}
+}
+
+// max returns the worst lag of the current and previous window
+func (t *lagTracker) max(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate(now)
+	return max(t.current, t.previous)
+}
+
+// rotate starts a new window once the current one is over. Callers must
+// hold mu.
+func (t *lagTracker) rotate(now time.Time) {
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < lagWindow {
+		return
+	}
+	if elapsed < 2*lagWindow {
+		t.previous = t.current
+	} else {
+		// No tick in the whole previous window
+		t.previous = 0
+	}
+	t.current = 0
+	t.windowStart = now
+}
@@ -0,0 +1,431 @@
+// Package scheduler advances many periodic sessions from a few worker
+// goroutines on one shared clock, instead of a goroutine and timer each
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults used for zero options
+const (
+	DefaultResolution = 5 * time.Millisecond
+	DefaultMaxCatchUp = 3
+)
+
+// ErrStopped is returned when adding sessions to a stopped scheduler
+var ErrStopped = errors.New("scheduler is stopped")
+
+// TickFunc advances a session by one tick that was due at due. Returning
+// an error removes the session.
+type TickFunc func(due time.Time) error
+
+// Options tune a scheduler
+type Options struct {
+	// Shards is the number of worker goroutines. Sessions are spread over
+	// them by ID. Zero uses one per CPU.
+	Shards int
+
+	// Resolution is the period of the shared clock. Ticks run up to one
+	// resolution after they are due.
+	Resolution time.Duration
+
+	// MaxCatchUp is the most ticks a session runs on one clock tick after
+	// falling behind. The ticks it missed beyond that are skipped.
+	MaxCatchUp int
+
+	// OnStop, if set, is called with the error of a session's tick after
+	// the session is removed because of it
+	OnStop func(id string, err error)
+}
+
+// Stats describes a scheduler's sessions and how they keep up
+type Stats struct {
+	Shards   int
+	Sessions int
+	// Ticks counts the ticks run since the scheduler started
+	Ticks uint64
+	// Skipped counts the ticks dropped because sessions fell further
+	// behind than MaxCatchUp
+	Skipped uint64
+	// Lag is the longest delay of a tick behind schedule in the last ten
+	// to twenty seconds
+	Lag time.Duration
+}
+
+// Scheduler runs the ticks of periodic sessions. Each session belongs to
+// one shard, whose worker runs the ticks that are due on every beat of the
+// shared clock, oldest first. Shards run their ticks in parallel, so tick
+// functions lock whatever they share with other sessions. A session that
+// falls behind catches up by at most MaxCatchUp ticks per beat and skips
+// the rest, keeping its phase, so how many ticks run depends only on how
+// late they are.
+type Scheduler struct {
+	opts   Options
+	shards []*shard
+
+	// mu guards stopped, and is held while adding sessions so that none
+	// are added after Stop
+	mu      sync.RWMutex
+	stopped bool
+	done    chan struct{}
+	workers sync.WaitGroup
+
+	ticks   atomic.Uint64
+	skipped atomic.Uint64
+}
+
+// New creates a scheduler and starts its clock and workers
+func New(opts Options) *Scheduler {
+	if opts.Shards <= 0 {
+		opts.Shards = runtime.GOMAXPROCS(0)
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = DefaultResolution
+	}
+	if opts.MaxCatchUp <= 0 {
+		opts.MaxCatchUp = DefaultMaxCatchUp
+	}
+
+	s := &Scheduler{
+		opts:   opts,
+		shards: make([]*shard, opts.Shards),
+		done:   make(chan struct{}),
+	}
+	now := time.Now()
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			entries: make(map[string]*entry),
+			wake:    make(chan time.Time, 1),
+		}
+		s.shards[i].beat.Store(now.UnixNano())
+	}
+
+	s.workers.Add(len(s.shards) + 1)
+	go s.runClock()
+	for _, sh := range s.shards {
+		go s.runShard(sh)
+	}
+	return s
+}
+
+// Add schedules tick every interval, starting one interval from now. It
+// replaces any session with the same ID.
+func (s *Scheduler) Add(id string, interval time.Duration, tick TickFunc) error {
+	if interval <= 0 {
+		return fmt.Errorf("tick interval must be positive: %s", interval)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return ErrStopped
+	}
+
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if old, ok := sh.entries[id]; ok {
+		sh.remove(old)
+	}
+	e := &entry{
+		id:       id,
+		interval: interval,
+		next:     time.Now().Add(interval),
+		tick:     tick,
+	}
+	sh.entries[id] = e
+	heap.Push(&sh.queue, e)
+	return nil
+}
+
+// Remove unschedules a session and reports whether it was scheduled. A
+// tick already running finishes, but no tick starts afterwards.
+func (s *Scheduler) Remove(id string) bool {
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, ok := sh.entries[id]
+	if ok {
+		sh.remove(e)
+	}
+	return ok
+}
+
+// Has reports whether a session is scheduled
+func (s *Scheduler) Has(id string) bool {
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	_, ok := sh.entries[id]
+	return ok
+}
+
+// Lag returns the longest delay of a tick behind schedule in the last ten
+// to twenty seconds
+func (s *Scheduler) Lag() time.Duration {
+	now := time.Now()
+	var lag time.Duration
+	for _, sh := range s.shards {
+		lag = max(lag, sh.lag.max(now))
+	}
+	return lag
+}
+
+// Stall returns how long the slowest shard has gone without finishing a
+// beat of the clock. It grows while a tick function hangs, since every
+// other session of its shard waits on it. A stopped scheduler never stalls.
+func (s *Scheduler) Stall() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return 0
+	}
+
+	now := time.Now()
+	var stall time.Duration
+	for _, sh := range s.shards {
+		stall = max(stall, now.Sub(time.Unix(0, sh.beat.Load())))
+	}
+	return stall
+}
+
+// Stats returns the scheduler's counters
+func (s *Scheduler) Stats() Stats {
+	stats := Stats{
+		Shards:  len(s.shards),
+		Ticks:   s.ticks.Load(),
+		Skipped: s.skipped.Load(),
+		Lag:     s.Lag(),
+	}
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		stats.Sessions += len(sh.entries)
+		sh.mu.Unlock()
+	}
+	return stats
+}
+
+// Stop stops the clock and waits for running ticks to finish, or for ctx
+// to be done. Sessions are not run again.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shardFor returns the shard of a session, hashing its ID with FNV-1a
+func (s *Scheduler) shardFor(id string) *shard {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
+// runClock wakes every shard on each beat of the shared clock. A shard
+// still busy with an earlier beat gets the latest one when it is done.
+func (s *Scheduler) runClock() {
+	defer s.workers.Done()
+
+	clock := time.NewTicker(s.opts.Resolution)
+	defer clock.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-clock.C:
+			for _, sh := range s.shards {
+				select {
+				case <-sh.wake:
+				default:
+				}
+				sh.wake <- now
+			}
+		}
+	}
+}
+
+// runShard runs the due ticks of sh on every beat until the scheduler
+// stops
+func (s *Scheduler) runShard(sh *shard) {
+	defer s.workers.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-sh.wake:
+			s.runDue(sh, now)
+			sh.beat.Store(now.UnixNano())
+		}
+	}
+}
+
+// runDue runs the ticks of sh that are due at now
+func (s *Scheduler) runDue(sh *shard, now time.Time) {
+	sh.mu.Lock()
+	due := sh.due[:0]
+	for len(sh.queue) > 0 && !sh.queue[0].next.After(now) {
+		due = append(due, heap.Pop(&sh.queue).(*entry))
+	}
+	sh.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	for _, e := range due {
+		s.runEntry(sh, e, now)
+	}
+
+	var failed []*entry
+	sh.mu.Lock()
+	for _, e := range due {
+		switch {
+		case e.err != nil:
+			if sh.entries[e.id] == e {
+				sh.remove(e)
+				failed = append(failed, e)
+			}
+		case !e.removed.Load():
+			heap.Push(&sh.queue, e)
+		}
+	}
+	sh.mu.Unlock()
+
+	clear(due)
+	sh.due = due[:0]
+
+	if s.opts.OnStop != nil {
+		for _, e := range failed {
+			s.opts.OnStop(e.id, e.err)
+		}
+	}
+}
+
+// runEntry runs the ticks of e, a session of sh, due at now, skipping
+// those beyond MaxCatchUp, and moves e to its next tick
+func (s *Scheduler) runEntry(sh *shard, e *entry, now time.Time) {
+	if e.removed.Load() {
+		return
+	}
+
+	due := int(now.Sub(e.next)/e.interval) + 1
+	run := min(due, s.opts.MaxCatchUp)
+	first := e.next
+	e.next = first.Add(time.Duration(due) * e.interval)
+
+	if skipped := due - run; skipped > 0 {
+		s.skipped.Add(uint64(skipped))
+	}
+	sh.lag.observe(time.Since(first))
+
+	for i := 0; i < run; i++ {
+		s.ticks.Add(1)
+		if err := e.tick(first.Add(time.Duration(i) * e.interval)); err != nil {
+			e.err = err
+			return
+		}
+	}
+}
+
+// shard holds the sessions run by one worker
+type shard struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	queue   entryQueue
+	wake    chan time.Time
+	// beat is the time of the last beat the worker finished, in Unix
+	// nanoseconds
+	beat atomic.Int64
+	// lag is only observed by the worker, and read by Lag
+	lag lagTracker
+
+	// due is reused by the worker between beats
+	due []*entry
+}
+
+// remove unschedules e. Callers must hold mu.
+func (sh *shard) remove(e *entry) {
+	delete(sh.entries, e.id)
+	if e.index >= 0 {
+		heap.Remove(&sh.queue, e.index)
+	}
+	e.removed.Store(true)
+}
+
+// entry is a scheduled session
+type entry struct {
+	id       string
+	interval time.Duration
+	tick     TickFunc
+
+	// next is when the next tick is due. It is guarded by the shard's mu
+	// while queued, and owned by the worker while running.
+	next time.Time
+	// index is the position in the queue, or -1 while running
+	index int
+	// removed is set once the session is unscheduled
+	removed atomic.Bool
+	// err is the error of the last tick, set by the worker
+	err error
+}
+
+// entryQueue is a min-heap of entries by due time, then ID
+type entryQueue []*entry
+
+func (q entryQueue) Len() int { return len(q) }
+
+func (q entryQueue) Less(i, j int) bool {
+	if q[i].next.Equal(q[j].next) {
+		return q[i].id < q[j].id
+	}
+	return q[i].next.Before(q[j].next)
+}
+
+func (q entryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *entryQueue) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *entryQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
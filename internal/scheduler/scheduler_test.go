@@ -0,0 +1,339 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStopped returns a scheduler with opts, and at least one shard, whose
+// clock and workers are not running, for tests that drive it by hand
+func newStopped(opts Options) *Scheduler {
+	s := &Scheduler{opts: opts, shards: make([]*shard, max(opts.Shards, 1))}
+	for i := range s.shards {
+		s.shards[i] = &shard{entries: make(map[string]*entry)}
+	}
+	return s
+}
+
+// stop stops s at the end of the test
+func stop(t *testing.T, s *Scheduler) {
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := s.Stop(ctx); err != nil {
+			t.Errorf("Stop: %v", err)
+		}
+	})
+}
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunEntryCatchesUpAndSkips(t *testing.T) {
+	s := newStopped(Options{MaxCatchUp: 3})
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var dues []time.Time
+	e := &entry{
+		id:       "a",
+		interval: 10 * time.Millisecond,
+		next:     start,
+		tick: func(due time.Time) error {
+			dues = append(dues, due)
+			return nil
+		},
+	}
+
+	// Six ticks are due: the first three run and the rest are skipped
+	s.runEntry(s.shards[0], e, start.Add(55*time.Millisecond))
+
+	want := []time.Time{start, start.Add(10 * time.Millisecond), start.Add(20 * time.Millisecond)}
+	if len(dues) != len(want) {
+		t.Fatalf("ran %d ticks, want %d", len(dues), len(want))
+	}
+	for i := range want {
+		if !dues[i].Equal(want[i]) {
+			t.Errorf("tick %d due at %s, want %s", i, dues[i].Sub(start), want[i].Sub(start))
+		}
+	}
+	if got := s.skipped.Load(); got != 3 {
+		t.Errorf("skipped %d ticks, want 3", got)
+	}
+	if got := s.ticks.Load(); got != 3 {
+		t.Errorf("counted %d ticks, want 3", got)
+	}
+	// The session keeps its phase
+	if want := start.Add(60 * time.Millisecond); !e.next.Equal(want) {
+		t.Errorf("next tick due at %s, want %s", e.next.Sub(start), want.Sub(start))
+	}
+
+	// Running on time runs one tick and skips none
+	dues = nil
+	s.runEntry(s.shards[0], e, start.Add(60*time.Millisecond))
+	if len(dues) != 1 || s.skipped.Load() != 3 {
+		t.Errorf("on time: ran %d ticks and skipped %d in total, want 1 and 3", len(dues), s.skipped.Load())
+	}
+}
+
+func TestRunEntryStopsAtError(t *testing.T) {
+	s := newStopped(Options{MaxCatchUp: 3})
+
+	failure := errors.New("game over")
+	start := time.Now()
+	runs := 0
+	e := &entry{
+		id:       "a",
+		interval: time.Millisecond,
+		next:     start,
+		tick: func(time.Time) error {
+			runs++
+			return failure
+		},
+	}
+
+	s.runEntry(s.shards[0], e, start.Add(10*time.Millisecond))
+	if runs != 1 || !errors.Is(e.err, failure) {
+		t.Fatalf("ran %d ticks with error %v, want 1 with %v", runs, e.err, failure)
+	}
+}
+
+func TestRunEntrySkipsRemovedSessions(t *testing.T) {
+	s := newStopped(Options{MaxCatchUp: 3})
+
+	runs := 0
+	e := &entry{
+		id:       "a",
+		interval: time.Millisecond,
+		next:     time.Now(),
+		tick: func(time.Time) error {
+			runs++
+			return nil
+		},
+	}
+	e.removed.Store(true)
+
+	s.runEntry(s.shards[0], e, time.Now().Add(time.Second))
+	if runs != 0 {
+		t.Fatalf("ran %d ticks of a removed session", runs)
+	}
+}
+
+func TestSchedulerLagPerShard(t *testing.T) {
+	s := newStopped(Options{Shards: 2, MaxCatchUp: 3})
+	if lag := s.Lag(); lag != 0 {
+		t.Fatalf("Lag before any tick: got %s, want 0", lag)
+	}
+
+	now := time.Now()
+	e := &entry{
+		id:       "a",
+		interval: time.Second,
+		next:     now.Add(-200 * time.Millisecond),
+		tick:     func(time.Time) error { return nil },
+	}
+	s.runEntry(s.shards[1], e, now)
+
+	if lag := s.shards[0].lag.max(time.Now()); lag != 0 {
+		t.Errorf("lag of the idle shard: got %s, want 0", lag)
+	}
+	if lag := s.shards[1].lag.max(time.Now()); lag < 200*time.Millisecond {
+		t.Errorf("lag of the late shard: got %s, want at least 200ms", lag)
+	}
+	if lag := s.Lag(); lag < 200*time.Millisecond {
+		t.Errorf("Lag: got %s, want the late shard's", lag)
+	}
+}
+
+func TestSchedulerTicksSessions(t *testing.T) {
+	s := New(Options{Shards: 2, Resolution: time.Millisecond})
+	stop(t, s)
+
+	var a, b atomic.Int32
+	if err := s.Add("a", 5*time.Millisecond, func(time.Time) error { a.Add(1); return nil }); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add("b", 5*time.Millisecond, func(time.Time) error { b.Add(1); return nil }); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	waitFor(t, "both sessions to tick three times", func() bool {
+		return a.Load() >= 3 && b.Load() >= 3
+	})
+	if stats := s.Stats(); stats.Sessions != 2 || stats.Ticks < 6 {
+		t.Errorf("Stats: %+v, want 2 sessions and at least 6 ticks", stats)
+	}
+	if err := s.Add("c", 0, func(time.Time) error { return nil }); err == nil {
+		t.Error("Add accepted a zero interval")
+	}
+}
+
+func TestSchedulerAddReplacesSession(t *testing.T) {
+	s := New(Options{Shards: 1, Resolution: time.Millisecond})
+	stop(t, s)
+
+	var old, replacement atomic.Int32
+	if err := s.Add("a", time.Millisecond, func(time.Time) error { old.Add(1); return nil }); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	waitFor(t, "the first session to tick", func() bool { return old.Load() > 0 })
+
+	if err := s.Add("a", time.Millisecond, func(time.Time) error { replacement.Add(1); return nil }); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// A tick of the old session may have been running while it was replaced
+	ran := old.Load()
+	waitFor(t, "the replacement to tick", func() bool { return replacement.Load() >= 3 })
+	if got := old.Load(); got > ran+1 {
+		t.Errorf("replaced session ticked %d more times", got-ran)
+	}
+	if stats := s.Stats(); stats.Sessions != 1 {
+		t.Errorf("Stats: %d sessions, want 1", stats.Sessions)
+	}
+}
+
+func TestSchedulerRemoveWhileTickRuns(t *testing.T) {
+	s := New(Options{Shards: 1, Resolution: time.Millisecond})
+	stop(t, s)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	var runs atomic.Int32
+	err := s.Add("a", time.Millisecond, func(time.Time) error {
+		if runs.Add(1) == 1 {
+			close(running)
+			<-release
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	<-running
+	if !s.Remove("a") {
+		t.Fatal("Remove did not find the running session")
+	}
+	if s.Has("a") {
+		t.Fatal("removed session is still scheduled")
+	}
+	close(release)
+
+	// Give the worker beats to requeue the session, which it must not
+	time.Sleep(20 * time.Millisecond)
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("removed session ticked %d times, want 1", got)
+	}
+	if s.Remove("a") {
+		t.Error("Remove found a session removed before")
+	}
+}
+
+func TestSchedulerOnStop(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		stopped []string
+		errs    []error
+	)
+	failure := errors.New("game over")
+	s := New(Options{
+		Shards:     1,
+		Resolution: time.Millisecond,
+		OnStop: func(id string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, id)
+			errs = append(errs, err)
+		},
+	})
+	stop(t, s)
+
+	var runs atomic.Int32
+	err := s.Add("a", time.Millisecond, func(time.Time) error {
+		if runs.Add(1) == 2 {
+			return failure
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	waitFor(t, "OnStop", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(stopped) > 0
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stopped) != 1 || stopped[0] != "a" || !errors.Is(errs[0], failure) {
+		t.Fatalf("OnStop called with %v %v, want a once with %v", stopped, errs, failure)
+	}
+	if s.Has("a") {
+		t.Error("failed session is still scheduled")
+	}
+	if got := runs.Load(); got != 2 {
+		t.Errorf("failed session ticked %d times, want 2", got)
+	}
+}
+
+func TestSchedulerStall(t *testing.T) {
+	s := New(Options{Shards: 2, Resolution: time.Millisecond})
+	stop(t, s)
+
+	waitFor(t, "beats", func() bool { return s.Stall() < 50*time.Millisecond })
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	err := s.Add("a", time.Millisecond, func(time.Time) error {
+		once.Do(func() {
+			close(running)
+			<-release
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// A hung tick holds up its shard while the other keeps beating
+	<-running
+	time.Sleep(50 * time.Millisecond)
+	if stall := s.Stall(); stall < 50*time.Millisecond {
+		t.Errorf("Stall with a hung tick: got %s, want at least 50ms", stall)
+	}
+	close(release)
+	waitFor(t, "the shard to recover", func() bool { return s.Stall() < 50*time.Millisecond })
+}
+
+func TestSchedulerStop(t *testing.T) {
+	s := New(Options{Resolution: time.Millisecond})
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := s.Add("a", time.Millisecond, func(time.Time) error { return nil }); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Add after Stop: got %v, want %v", err, ErrStopped)
+	}
+	if stall := s.Stall(); stall != 0 {
+		t.Errorf("Stall after Stop: got %s, want 0", stall)
+	}
+	// Stopping again is harmless
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+}
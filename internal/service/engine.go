@@ -0,0 +1,354 @@
+package service
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+	"time"
+
+	"github.com/siddarth/go-app/internal/autopilot"
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// playerSpawns are the starting points of players, in join order. There
+// must be at least domain.MaxPlayers of them.
+var playerSpawns = []domain.Position{
+	{X: 1, Y: 1},
+	{X: GameWidth - 2, Y: 7},
+	{X: 1, Y: 9},
+	{X: 12, Y: 9},
+}
+
+// ghostSpawns are the starting points of ghosts, in spawn order. There must
+// be at least domain.MaxGhosts of them.
+var ghostSpawns = []domain.Ghost{
+	{Position: domain.Position{X: GameWidth - 2, Y: GameHeight - 2}, Direction: domain.DirectionLeft},
+	{Position: domain.Position{X: GameWidth - 2, Y: 1}, Direction: domain.DirectionLeft},
+	{Position: domain.Position{X: 1, Y: GameHeight - 2}, Direction: domain.DirectionRight},
+	{Position: domain.Position{X: 9, Y: 7}, Direction: domain.DirectionRight},
+	{Position: domain.Position{X: 9, Y: 3}, Direction: domain.DirectionLeft},
+	{Position: domain.Position{X: 9, Y: GameHeight - 2}, Direction: domain.DirectionRight},
+}
+
+// Engine applies the movement, ghost and collision rules of the game. An
+// Engine is not safe for concurrent use: the game service only uses an
+// engine while holding the game lock that owns it, and simulations give
+// each worker its own.
+type Engine struct {
+	rng *mathrand.Rand
+	// controllers holds the autopilot strategies by name. They share rng.
+	controllers map[string]domain.PlayerController
+}
+
+// Catch records a ghost catching a player
+type Catch struct {
+	PlayerID  string
+	GhostID   string
+	Position  domain.Position
+	LivesLeft int
+}
+
+// NewEngine creates an engine whose ghosts and autopilots draw from a
+// random source with the given seed, so that equal seeds replay equal games
+func NewEngine(seed int64) *Engine {
+	rng := mathrand.New(mathrand.NewSource(seed))
+	return &Engine{
+		rng:         rng,
+		controllers: autopilot.All(rng),
+	}
+}
+
+// NewGame creates a game on the named maze, or the default maze when the
+// name is empty, with the players of roster seated and their autopilots
+// kept
+func (e *Engine) NewGame(id string, rules domain.GameRules, mazeName string, roster []domain.Player) (*domain.Game, error) {
+	if len(roster) < 1 || len(roster) > domain.MaxPlayers {
+		return nil, fmt.Errorf("%w: players must be between 1 and %d", domain.ErrInvalidGameOptions, domain.MaxPlayers)
+	}
+	for _, p := range roster {
+		if !hasStrategy(p.Autopilot) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownStrategy, p.Autopilot)
+		}
+	}
+
+	name, maze, err := mazeFor(mazeName)
+	if err != nil {
+		return nil, err
+	}
+
+	game := newGame(id, rules, maze, len(roster), roster)
+	game.Maze = name
+	return game, nil
+}
+
+// hasStrategy reports whether name is an autopilot strategy, or empty for
+// human control
+func hasStrategy(name string) bool {
+	return name == "" || slices.Contains(autopilot.Names(), name)
+}
+
+// newGame creates a new game with initial state, seating the players of
+// roster at their spawn points
+func newGame(sessionID string, rules domain.GameRules, maze []string, maxPlayers int, roster []domain.Player) *domain.Game {
+	game := &domain.Game{
+		ID:         sessionID,
+		Board:      domain.NewBoard(maze, GameWidth, GameHeight),
+		Players:    make([]domain.Player, 0, maxPlayers),
+		MaxPlayers: maxPlayers,
+		Ghosts:     append([]domain.Ghost(nil), ghostSpawns[:rules.GhostCount]...),
+		Rules:      rules,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	for _, p := range roster {
+		player := newPlayer(len(game.Players), p.Name, p.Token, rules.Lives)
+		player.Autopilot = p.Autopilot
+		game.Players = append(game.Players, player)
+	}
+
+	for i := range game.Ghosts {
+		game.Ghosts[i].ID = fmt.Sprintf("g%d", i+1)
+	}
+
+	return game
+}
+
+// Advance moves every player and ghost of game by one step and resolves
+// collisions, returning the catches made during the tick
+func (e *Engine) Advance(game *domain.Game) []Catch {
+	// Let autopilots steer before anyone moves
+	for i := range game.Players {
+		player := &game.Players[i]
+		controller, ok := e.controllers[player.Autopilot]
+		if !ok || !player.IsAlive() {
+			continue
+		}
+		if dir := controller.NextDirection(game, player); dir != domain.DirectionNone {
+			player.Direction = dir
+		}
+	}
+
+	// Move players
+	for i := range game.Players {
+		e.movePlayer(game, &game.Players[i])
+	}
+
+	updateFruit(game)
+
+	// Move ghosts
+	e.moveGhosts(game)
+
+	// Check collisions
+	return e.checkCollisions(game)
+}
+
+// movePlayer moves a player based on its current direction
+func (e *Engine) movePlayer(game *domain.Game, player *domain.Player) {
+	if !player.IsAlive() || player.Direction == domain.DirectionNone {
+		return
+	}
+
+	newPos := game.Board.Next(player.Position, player.Direction)
+
+	if game.IsValidPosition(newPos) {
+		player.Position = newPos
+
+		// Collect dot
+		if game.Board.EatDot(newPos) {
+			player.Score += game.Rules.ScorePerDot
+			game.DotsEaten++
+		}
+	}
+}
+
+// updateFruit hands the bonus fruit to a player standing on it, removes
+// it once its time is up, and spawns the next one when enough dots have
+// been collected
+func updateFruit(game *domain.Game) {
+	if fruit := game.Fruit; fruit != nil {
+		for i := range game.Players {
+			player := &game.Players[i]
+			if !player.IsAlive() || !player.Position.Equals(fruit.Position) {
+				continue
+			}
+			player.Score += fruit.Points
+			game.FruitCollected = append(game.FruitCollected, domain.CollectedFruit{
+				Type:     fruit.Type,
+				Points:   fruit.Points,
+				PlayerID: player.ID,
+				DotsLeft: game.DotsLeft(),
+			})
+			game.Fruit = nil
+			break
+		}
+	}
+
+	if game.Fruit != nil {
+		game.Fruit.RemainingTicks--
+		if game.Fruit.RemainingTicks <= 0 {
+			game.Fruit = nil
+		}
+	}
+
+	rules := game.Rules
+	if game.FruitsSpawned >= len(rules.FruitDots) || game.DotsEaten < rules.FruitDots[game.FruitsSpawned] {
+		return
+	}
+	game.FruitsSpawned++
+	if pos, ok := game.Board.FruitSpawn(); ok {
+		game.Fruit = domain.NewFruit(rules.Level, pos, rules.FruitTicks)
+	}
+}
+
+// moveGhosts moves all ghosts with AI behavior
+func (e *Engine) moveGhosts(game *domain.Game) {
+	for i := range game.Ghosts {
+		ghost := &game.Ghosts[i]
+		if slowedInTunnel(game, ghost) {
+			continue
+		}
+
+		// Claimed ghosts keep going the way their controller steers them
+		if ghost.IsHuman() {
+			if newPos := game.Board.Next(ghost.Position, ghost.Direction); game.IsValidPosition(newPos) {
+				ghost.Position = newPos
+			}
+			continue
+		}
+
+		// Determine ghost direction
+		var dir domain.Direction
+		target, hasTarget := nearestPlayer(game, ghost.Position)
+		if !hasTarget || e.rng.Intn(100) >= game.Rules.GhostAggression {
+			// Less aggressive ghosts change direction randomly more often
+			dir = domain.Direction(e.rng.Intn(4))
+		} else {
+			// Try to move towards the nearest player, through tunnels
+			// when that is shorter
+			dx, dy := game.Board.Delta(ghost.Position, target)
+
+			if domain.Abs(dx) > domain.Abs(dy) {
+				if dx > 0 {
+					dir = domain.DirectionRight
+				} else {
+					dir = domain.DirectionLeft
+				}
+			} else if dy > 0 {
+				dir = domain.DirectionDown
+			} else {
+				dir = domain.DirectionUp
+			}
+		}
+
+		newPos := game.Board.Next(ghost.Position, dir)
+
+		if game.IsValidPosition(newPos) {
+			ghost.Position = newPos
+			ghost.Direction = dir
+		} else {
+			// Try random direction if current doesn't work
+			dirs := []domain.Direction{
+				domain.DirectionUp,
+				domain.DirectionDown,
+				domain.DirectionLeft,
+				domain.DirectionRight,
+			}
+			e.rng.Shuffle(len(dirs), func(i, j int) {
+				dirs[i], dirs[j] = dirs[j], dirs[i]
+			})
+			for _, d := range dirs {
+				newPos := game.Board.Next(ghost.Position, d)
+				if game.IsValidPosition(newPos) {
+					ghost.Position = newPos
+					ghost.Direction = d
+					break
+				}
+			}
+		}
+	}
+}
+
+// slowedInTunnel reports whether ghost sits out this tick. Ghosts in a
+// tunnel move on every other tick only.
+func slowedInTunnel(game *domain.Game, ghost *domain.Ghost) bool {
+	if game.Board.Tile(ghost.Position) != domain.TileTunnel {
+		ghost.TunnelWait = false
+		return false
+	}
+	ghost.TunnelWait = !ghost.TunnelWait
+	return ghost.TunnelWait
+}
+
+// nearestPlayer returns the position of the living player closest to pos
+func nearestPlayer(game *domain.Game, pos domain.Position) (domain.Position, bool) {
+	var nearest domain.Position
+	best := -1
+	for _, p := range game.Players {
+		if !p.IsAlive() {
+			continue
+		}
+		d := game.Board.Distance(pos, p.Position)
+		if best < 0 || d < best {
+			best = d
+			nearest = p.Position
+		}
+	}
+	return nearest, best >= 0
+}
+
+// checkCollisions checks if any player collided with a ghost. A caught
+// player loses a life and respawns; the game is over once every player
+// has run out of lives.
+func (e *Engine) checkCollisions(game *domain.Game) []Catch {
+	var catches []Catch
+	alive := 0
+	for i := range game.Players {
+		player := &game.Players[i]
+		if !player.IsAlive() {
+			continue
+		}
+
+		for j := range game.Ghosts {
+			ghost := &game.Ghosts[j]
+			if player.Position.Equals(ghost.Position) {
+				player.Lives--
+				ghost.Score += game.Rules.ScorePerCatch
+				catches = append(catches, Catch{
+					PlayerID:  player.ID,
+					GhostID:   ghost.ID,
+					Position:  player.Position,
+					LivesLeft: player.Lives,
+				})
+				if player.IsAlive() {
+					player.Position = player.Spawn
+					player.Direction = domain.DirectionNone
+				}
+				break
+			}
+		}
+
+		if player.IsAlive() {
+			alive++
+		}
+	}
+
+	if alive == 0 {
+		game.GameOver = true
+	}
+	return catches
+}
+
+// newPlayer creates the player seated at index with a full set of lives
+func newPlayer(index int, name, token string, lives int) domain.Player {
+	spawn := playerSpawns[index]
+	return domain.Player{
+		ID:        fmt.Sprintf("p%d", index+1),
+		Token:     token,
+		Name:      name,
+		Position:  spawn,
+		Direction: domain.DirectionNone,
+		Spawn:     spawn,
+		Lives:     lives,
+	}
+}
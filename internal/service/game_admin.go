@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/siddarth/go-app/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	// maxAnnouncementLength is the longest message operators can broadcast,
+	// in characters
+	maxAnnouncementLength = 280
+	// maxAnnouncementTTL is the longest time a broadcast message is shown
+	maxAnnouncementTTL = 24 * time.Hour
+)
+
+// Status summarizes the games of the server
+func (s *gameService) Status(ctx context.Context) domain.AdminStatus {
+	_, span := s.tracer.Start(ctx, "AdminStatus")
+	defer span.End()
+
+	s.gameLoopMu.RLock()
+	status := domain.AdminStatus{
+		Draining:           s.draining || s.shuttingDown,
+		RunningLoops:       len(s.gameLoops),
+		HeadlessGames:      len(s.headlessGames),
+		MaxConcurrentGames: s.cfg.MaxConcurrentGames,
+	}
+	s.gameLoopMu.RUnlock()
+
+	if count, err := s.repo.Count(ctx); err == nil {
+		status.Sessions = count
+	}
+
+	ticks := s.ticks.Stats()
+	status.Ticks = domain.TickStats{
+		Shards:  ticks.Shards,
+		Ticks:   ticks.Ticks,
+		Skipped: ticks.Skipped,
+		LagMS:   ticks.Lag.Milliseconds(),
+	}
+
+	s.announcementMu.RLock()
+	if s.announcement.Message != "" && time.Now().Before(s.announcement.ExpiresAt) {
+		announcement := s.announcement
+		status.Announcement = &announcement
+	}
+	s.announcementMu.RUnlock()
+
+	span.SetAttributes(
+		attribute.Bool("admin.draining", status.Draining),
+		attribute.Int("admin.sessions", status.Sessions),
+		attribute.Int("admin.running_loops", status.RunningLoops),
+		attribute.Int("admin.headless_games", status.HeadlessGames),
+		attribute.Int64("admin.tick_lag_ms", status.Ticks.LagMS),
+	)
+	return status
+}
+
+// ListSessions lists every stored game, oldest first
+func (s *gameService) ListSessions(ctx context.Context) ([]domain.SessionInfo, error) {
+	ctx, span := s.tracer.Start(ctx, "ListSessions")
+	defer span.End()
+
+	running := s.runningLoops()
+
+	games, err := s.repo.List(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list games")
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-spectatorTimeout)
+	sessions := make([]domain.SessionInfo, 0, len(games))
+	for _, game := range games {
+		game.PruneSpectators(cutoff)
+		sessions = append(sessions, s.sessionInfo(ctx, game, running[game.ID], now))
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	span.SetAttributes(attribute.Int("admin.sessions", len(sessions)))
+	return sessions, nil
+}
+
+// InspectSession returns everything operators can see about a game
+func (s *gameService) InspectSession(ctx context.Context, sessionID string) (*domain.SessionDetail, error) {
+	ctx, span := s.tracer.Start(ctx, "InspectSession")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	running := s.runningLoops()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return nil, fmt.Errorf("failed to inspect session: %w", err)
+	}
+
+	now := time.Now()
+	game.PruneSpectators(now.Add(-spectatorTimeout))
+
+	return &domain.SessionDetail{
+		SessionInfo:    s.sessionInfo(ctx, game, running[sessionID], now),
+		SpectatorToken: game.SpectatorToken,
+		State:          s.gameState(game),
+	}, nil
+}
+
+// sessionInfo describes game to operators, with its expiry when the
+// repository expires games
+func (s *gameService) sessionInfo(ctx context.Context, game *domain.Game, loopRunning bool, now time.Time) domain.SessionInfo {
+	info := game.Info(loopRunning, now)
+	if expiry, ok := s.repo.(domain.GameExpiry); ok {
+		if at, ok := expiry.ExpiresAt(ctx, game.ID); ok {
+			info.ExpiresAt = &at
+		}
+	}
+	return info
+}
+
+// StopSession stops the game loop of a session, leaving the game stored
+func (s *gameService) StopSession(ctx context.Context, sessionID string) error {
+	ctx, span := s.tracer.Start(ctx, "StopSession")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	if _, err := s.repo.FindByID(ctx, sessionID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return fmt.Errorf("failed to stop session: %w", err)
+	}
+
+	s.stopGameLoop(sessionID)
+
+	s.logger.WarnContext(ctx, "game loop stopped by operator", "session_id", sessionID)
+	return nil
+}
+
+// Broadcast shows message to every client until ttl has passed
+func (s *gameService) Broadcast(ctx context.Context, message string, ttl time.Duration) (*domain.Announcement, error) {
+	ctx, span := s.tracer.Start(ctx, "Broadcast")
+	defer span.End()
+
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxAnnouncementLength {
+		err := fmt.Errorf("%w: message is longer than %d characters", domain.ErrInvalidAnnouncement, maxAnnouncementLength)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid announcement")
+		return nil, err
+	}
+	if message != "" && (ttl <= 0 || ttl > maxAnnouncementTTL) {
+		err := fmt.Errorf("%w: lifetime must be between 1s and %s", domain.ErrInvalidAnnouncement, maxAnnouncementTTL)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid announcement")
+		return nil, err
+	}
+
+	announcement := domain.Announcement{Message: message}
+	if message != "" {
+		announcement.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	s.announcementMu.Lock()
+	s.announcement = announcement
+	s.announcementMu.Unlock()
+
+	if message == "" {
+		s.logger.InfoContext(ctx, "announcement cleared")
+	} else {
+		s.logger.InfoContext(ctx, "announcement broadcast",
+			"message", message,
+			"expires_at", announcement.ExpiresAt,
+		)
+	}
+	return &announcement, nil
+}
+
+// currentAnnouncement returns the message to show clients at now, or ""
+// once it has expired
+func (s *gameService) currentAnnouncement(now time.Time) string {
+	s.announcementMu.RLock()
+	defer s.announcementMu.RUnlock()
+
+	if now.After(s.announcement.ExpiresAt) {
+		return ""
+	}
+	return s.announcement.Message
+}
+
+// SetDraining makes the server refuse new games while draining is true.
+// Running games carry on until they finish or are stopped.
+func (s *gameService) SetDraining(ctx context.Context, draining bool) {
+	ctx, span := s.tracer.Start(ctx, "SetDraining")
+	defer span.End()
+
+	span.SetAttributes(attribute.Bool("admin.draining", draining))
+
+	s.gameLoopMu.Lock()
+	changed := s.draining != draining
+	s.draining = draining
+	running := len(s.gameLoops)
+	s.gameLoopMu.Unlock()
+
+	if !changed {
+		return
+	}
+	if draining {
+		s.logger.WarnContext(ctx, "draining, new games are refused", "running_loops", running)
+	} else {
+		s.logger.InfoContext(ctx, "drain ended, accepting new games")
+	}
+}
+
+// runningLoops returns the set of sessions with a running game loop
+func (s *gameService) runningLoops() map[string]bool {
+	s.gameLoopMu.RLock()
+	defer s.gameLoopMu.RUnlock()
+
+	running := make(map[string]bool, len(s.gameLoops))
+	for id := range s.gameLoops {
+		running[id] = true
+	}
+	return running
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
-	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/siddarth/go-app/internal/config"
 	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/scheduler"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -20,119 +25,300 @@ const (
 	GameWidth = 20
 	// GameHeight is the height of the game board
 	GameHeight = 15
-	// GameTickInterval is the interval between game ticks
-	GameTickInterval = 200 * time.Millisecond
-	// ScorePerDot is the score awarded for collecting a dot
-	ScorePerDot = 10
+	// spectatorTimeout is how long a viewer counts as a spectator after
+	// last fetching a game
+	spectatorTimeout = 10 * time.Second
+	// shutdownNoticeTTL is how long clients are shown that the server is
+	// shutting down
+	shutdownNoticeTTL = time.Minute
+	// gameLockStripes is the number of locks games are spread over by ID
+	gameLockStripes = 256
 )
 
 // gameService implements domain.GameService
 type gameService struct {
-	repo       domain.GameRepository
-	logger     *slog.Logger
-	tracer     trace.Tracer
-	gameLoops  map[string]context.CancelFunc
-	gameLoopMu sync.RWMutex
-	rng        *rand.Rand
+	repo domain.GameRepository
+	// checkpoints keeps games across restarts and may be nil
+	checkpoints domain.CheckpointStore
+	cfg         config.GameConfig
+	logger      *slog.Logger
+	tracer      trace.Tracer
+	// gameLoops holds the sessions whose game loop is running
+	gameLoops map[string]bool
+	// headlessGames holds the unfinished headless games, which count
+	// against the concurrent game limit like game loops
+	headlessGames map[string]bool
+	gameLoopMu    sync.RWMutex
+	// ticks runs the game loops, each tick holding the lock of its game.
+	// Loops are only added to it while holding gameLoopMu.
+	ticks *scheduler.Scheduler
+	// locks serializes changes to each game between ticks and requests.
+	// Reads need no lock, as the repository hands out snapshots.
+	locks []gameLock
+	// spectatorTokens maps spectator tokens to session IDs
+	spectatorTokens map[string]string
+	spectatorMu     sync.Mutex
+	// ownedGames maps owners to the session IDs of the games they created
+	ownedGames map[string]map[string]bool
+	ownerMu    sync.Mutex
+	// draining refuses new games, and shuttingDown also new game loops.
+	// Both are guarded by gameLoopMu.
+	draining     bool
+	shuttingDown bool
+	// announcement is shown to every client until it expires
+	announcement   domain.Announcement
+	announcementMu sync.RWMutex
 }
 
-// NewGameService creates a new game service
-func NewGameService(repo domain.GameRepository, logger *slog.Logger) domain.GameService {
-	return &gameService{
-		repo:      repo,
-		logger:    logger,
-		tracer:    otel.Tracer("game-service"),
-		gameLoops: make(map[string]context.CancelFunc),
-		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+// gameLock serializes changes to the games whose IDs hash to it, and owns
+// the engine that advances them
+type gameLock struct {
+	sync.Mutex
+	engine *Engine
+}
+
+// NewGameService creates a new game service whose game loops are run by a
+// scheduler tuned by sched. Games are checkpointed to checkpoints on
+// shutdown; nil checkpoints lets them end with the process.
+func NewGameService(repo domain.GameRepository, checkpoints domain.CheckpointStore, cfg config.GameConfig, sched config.SchedulerConfig, logger *slog.Logger) domain.GameService {
+	s := &gameService{
+		repo:          repo,
+		checkpoints:   checkpoints,
+		cfg:           cfg,
+		logger:        logger,
+		tracer:        otel.Tracer("game-service"),
+		gameLoops:     make(map[string]bool),
+		headlessGames: make(map[string]bool),
+		locks:         make([]gameLock, gameLockStripes),
+
+		spectatorTokens: make(map[string]string),
+		ownedGames:      make(map[string]map[string]bool),
 	}
+	seed := time.Now().UnixNano()
+	for i := range s.locks {
+		s.locks[i].engine = NewEngine(seed + int64(i))
+	}
+	s.ticks = scheduler.New(scheduler.Options{
+		Shards:     sched.Shards,
+		Resolution: sched.Resolution,
+		MaxCatchUp: sched.MaxCatchUp,
+		OnStop:     s.gameLoopEnded,
+	})
+	return s
+}
+
+// lockGame locks the game with the given session ID and returns its lock,
+// for the caller to unlock
+func (s *gameService) lockGame(sessionID string) *gameLock {
+	h := uint32(2166136261)
+	for i := 0; i < len(sessionID); i++ {
+		h ^= uint32(sessionID[i])
+		h *= 16777619
+	}
+	lock := &s.locks[h%uint32(len(s.locks))]
+	lock.Lock()
+	return lock
 }
 
 // CreateGame creates a new game session
-func (s *gameService) CreateGame(ctx context.Context, sessionID string) (*domain.Game, error) {
+func (s *gameService) CreateGame(ctx context.Context, sessionID string, opts domain.GameOptions) (*domain.Game, error) {
 	ctx, span := s.tracer.Start(ctx, "CreateGame")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
+	defer s.lockGame(sessionID).Unlock()
+	return s.createGame(ctx, sessionID, opts, nil)
+}
+
+// createGame creates and saves a new game. When prev is given, its players
+// and ghost controllers are seated again with their IDs, names and tokens
+// instead of a new host. Errors are recorded on the span in ctx. Callers
+// must hold the game's lock.
+func (s *gameService) createGame(ctx context.Context, sessionID string, opts domain.GameOptions, prev *domain.Game) (*domain.Game, error) {
+	span := trace.SpanFromContext(ctx)
+
 	if sessionID == "" {
-		err := fmt.Errorf("session ID cannot be empty")
+		err := domain.ErrInvalidSessionID
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if existing, err := s.repo.FindByID(ctx, sessionID); err == nil && !existing.IsFinished() {
+		err := fmt.Errorf("%w: %s", domain.ErrSessionConflict, sessionID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "session conflict")
+		return nil, err
+	}
+
+	s.gameLoopMu.RLock()
+	hasCapacity := s.hasCapacity(sessionID)
+	maxGames := s.cfg.MaxConcurrentGames
+	draining := s.draining || s.shuttingDown
+	rules, rulesErr := s.rulesFor(opts.Preset)
+	s.gameLoopMu.RUnlock()
+	if draining {
+		err := domain.ErrDraining
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if rulesErr != nil {
+		span.RecordError(rulesErr)
+		span.SetStatus(codes.Error, "unknown preset")
+		return nil, rulesErr
+	}
+	mazeName, maze, err := mazeFor(opts.Maze)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown maze")
+		return nil, err
+	}
+	if !hasStrategy(opts.Autopilot) {
+		err := fmt.Errorf("%w: %s", domain.ErrUnknownStrategy, opts.Autopilot)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown autopilot strategy")
+		return nil, err
+	}
+	if !hasCapacity {
+		err := domain.ErrTooManyGames
+		s.logger.WarnContext(ctx, "game limit reached",
+			"session_id", sessionID,
+			"max_concurrent_games", maxGames,
+		)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
-	game := s.initializeGame(sessionID)
+	maxPlayers := opts.MaxPlayers
+	if maxPlayers == 0 {
+		maxPlayers = 1
+	}
+	if maxPlayers < 1 || maxPlayers > domain.MaxPlayers {
+		err := fmt.Errorf("%w: max players must be between 1 and %d", domain.ErrInvalidGameOptions, domain.MaxPlayers)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid game options")
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("game.preset", rules.Preset),
+		attribute.String("game.maze", mazeName),
+		attribute.Int("game.max_players", maxPlayers),
+		attribute.Bool("game.versus", opts.Versus),
+	)
+
+	var roster []domain.Player
+	var spectatorToken string
+	if prev != nil {
+		roster = prev.Players
+		spectatorToken = prev.SpectatorToken
+	} else {
+		token, err := newPlayerToken()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to create player token")
+			return nil, err
+		}
+		roster = []domain.Player{{Name: opts.PlayerName, Token: token, Autopilot: opts.Autopilot}}
+
+		spectatorToken, err = newPlayerToken()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to create spectator token")
+			return nil, err
+		}
+	}
+
+	game := newGame(sessionID, rules, maze, maxPlayers, roster)
+	game.Versus = opts.Versus
+	game.Maze = mazeName
+	game.Headless = opts.Headless
+	game.SpectatorToken = spectatorToken
+	game.Owner = opts.Owner
+	if prev != nil {
+		game.Spectators = prev.Spectators
+	}
+
+	// Claimed ghosts stay with their controllers across restarts
+	if prev != nil && game.Versus {
+		for i := range game.Ghosts {
+			if i < len(prev.Ghosts) {
+				game.Ghosts[i].Token = prev.Ghosts[i].Token
+				game.Ghosts[i].Name = prev.Ghosts[i].Name
+			}
+		}
+	}
+
+	// Headless games have no loop to count, so they take their place
+	// under the limit before they are saved
+	if game.Headless && !s.reserveHeadless(sessionID) {
+		err := domain.ErrTooManyGames
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
 
 	if err := s.repo.Save(ctx, game); err != nil {
 		s.logger.ErrorContext(ctx, "failed to save game",
 			"session_id", sessionID,
 			"error", err,
 		)
+		s.releaseHeadless(sessionID)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to save game")
 		return nil, fmt.Errorf("failed to save game: %w", err)
 	}
 
+	s.indexGame(game)
+
 	s.logger.InfoContext(ctx, "game created",
 		"session_id", sessionID,
-		"dots_count", game.DotsLeft,
+		"dots_count", game.DotsLeft(),
+		"preset", rules.Preset,
+		"maze", mazeName,
+		"max_players", maxPlayers,
+		"versus", game.Versus,
+		"headless", game.Headless,
 	)
 
 	return game, nil
 }
 
-// initializeGame creates a new game with initial state
-func (s *gameService) initializeGame(sessionID string) *domain.Game {
-	game := &domain.Game{
-		ID:     sessionID,
-		Board:  make([][]rune, GameHeight),
-		Player: domain.Position{X: 1, Y: 1},
-		Ghosts: []domain.Ghost{
-			{Position: domain.Position{X: GameWidth - 2, Y: GameHeight - 2}, Direction: domain.DirectionLeft},
-			{Position: domain.Position{X: GameWidth - 2, Y: 1}, Direction: domain.DirectionLeft},
-			{Position: domain.Position{X: 1, Y: GameHeight - 2}, Direction: domain.DirectionRight},
-		},
-		Score:     0,
-		PlayerDir: domain.DirectionNone,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	// Initialize board with maze
-	maze := []string{
-		"####################",
-		"#..................#",
-		"#.##.##.##.##.##.###",
-		"#..................#",
-		"#.##.##....##.##.###",
-		"#......##.##......##",
-		"#.##.##....##.##.###",
-		"#..................#",
-		"#.##.##.##.##.##.###",
-		"#..................#",
-		"#.##....##....##.###",
-		"#......##.##......##",
-		"#.##....##....##.###",
-		"#..................#",
-		"####################",
-	}
-
-	for i := 0; i < GameHeight; i++ {
-		game.Board[i] = make([]rune, GameWidth)
-		mazeRow := maze[i]
-		for j := 0; j < GameWidth; j++ {
-			if j < len(mazeRow) {
-				game.Board[i][j] = rune(mazeRow[j])
-				if mazeRow[j] == '.' {
-					game.DotsLeft++
-				}
-			} else {
-				game.Board[i][j] = '#'
-			}
+// indexGame makes game reachable by its spectator token and lists it for
+// its owner
+func (s *gameService) indexGame(game *domain.Game) {
+	s.spectatorMu.Lock()
+	s.spectatorTokens[game.SpectatorToken] = game.ID
+	s.spectatorMu.Unlock()
+
+	if game.Owner != "" {
+		s.ownerMu.Lock()
+		if s.ownedGames[game.Owner] == nil {
+			s.ownedGames[game.Owner] = make(map[string]bool)
 		}
+		s.ownedGames[game.Owner][game.ID] = true
+		s.ownerMu.Unlock()
+	}
+}
+
+// rulesFor resolves a preset name to game rules, using the default preset
+// when name is empty. Callers must hold gameLoopMu.
+func (s *gameService) rulesFor(name string) (domain.GameRules, error) {
+	if name == "" {
+		name = s.cfg.DefaultPreset
+	}
+
+	rules, ok := s.cfg.Presets[name]
+	if !ok {
+		return domain.GameRules{}, fmt.Errorf("%w: %s", domain.ErrUnknownPreset, name)
 	}
 
-	return game
+	resolved := *rules
+	resolved.Preset = name
+	return resolved, nil
 }
 
 // GetGame retrieves a game by session ID
@@ -143,7 +329,7 @@ func (s *gameService) GetGame(ctx context.Context, sessionID string) (*domain.Ga
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
 	if sessionID == "" {
-		err := fmt.Errorf("session ID cannot be empty")
+		err := domain.ErrInvalidSessionID
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
@@ -153,14 +339,225 @@ func (s *gameService) GetGame(ctx context.Context, sessionID string) (*domain.Ga
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "game not found")
-		return nil, fmt.Errorf("game not found: %w", err)
+		return nil, fmt.Errorf("failed to get game: %w", err)
 	}
 
 	return game, nil
 }
 
-// SetPlayerDirection sets the player's movement direction
-func (s *gameService) SetPlayerDirection(ctx context.Context, sessionID string, dir domain.Direction) error {
+// JoinGame adds a player to an existing game
+func (s *gameService) JoinGame(ctx context.Context, sessionID string, name string) (*domain.Player, error) {
+	ctx, span := s.tracer.Start(ctx, "JoinGame")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	token, err := newPlayerToken()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create player token")
+		return nil, err
+	}
+
+	defer s.lockGame(sessionID).Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return nil, fmt.Errorf("failed to join game: %w", err)
+	}
+
+	if game.IsFinished() {
+		err := fmt.Errorf("%w: %s", domain.ErrGameOver, sessionID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game is over")
+		return nil, err
+	}
+
+	if len(game.Players) >= game.MaxPlayers {
+		err := fmt.Errorf("%w: %d of %d players", domain.ErrGameFull, len(game.Players), game.MaxPlayers)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game is full")
+		return nil, err
+	}
+
+	player := newPlayer(len(game.Players), name, token, game.Rules.Lives)
+	game.Players = append(game.Players, player)
+	game.UpdatedAt = time.Now()
+
+	if err := s.repo.Save(ctx, game); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update game",
+			"session_id", sessionID,
+			"error", err,
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save game")
+		return nil, fmt.Errorf("failed to update game: %w", err)
+	}
+
+	span.SetAttributes(attribute.String("player.id", player.ID))
+
+	s.logger.InfoContext(ctx, "player joined",
+		"session_id", sessionID,
+		"player_id", player.ID,
+		"players", len(game.Players),
+	)
+
+	return &player, nil
+}
+
+// ClaimGhost hands control of a server-driven ghost to a participant
+func (s *gameService) ClaimGhost(ctx context.Context, sessionID string, name string) (*domain.Ghost, error) {
+	ctx, span := s.tracer.Start(ctx, "ClaimGhost")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	token, err := newPlayerToken()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create player token")
+		return nil, err
+	}
+
+	defer s.lockGame(sessionID).Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return nil, fmt.Errorf("failed to claim ghost: %w", err)
+	}
+
+	if game.IsFinished() {
+		err := fmt.Errorf("%w: %s", domain.ErrGameOver, sessionID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game is over")
+		return nil, err
+	}
+
+	var ghost *domain.Ghost
+	if game.Versus {
+		for i := range game.Ghosts {
+			if !game.Ghosts[i].IsHuman() {
+				ghost = &game.Ghosts[i]
+				break
+			}
+		}
+	}
+	if ghost == nil {
+		err := fmt.Errorf("%w: %s", domain.ErrNoGhostSeat, sessionID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "no ghost seat")
+		return nil, err
+	}
+
+	ghost.Token = token
+	ghost.Name = name
+	game.UpdatedAt = time.Now()
+
+	if err := s.repo.Save(ctx, game); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update game",
+			"session_id", sessionID,
+			"error", err,
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save game")
+		return nil, fmt.Errorf("failed to update game: %w", err)
+	}
+
+	span.SetAttributes(attribute.String("ghost.id", ghost.ID))
+
+	s.logger.InfoContext(ctx, "ghost claimed",
+		"session_id", sessionID,
+		"ghost_id", ghost.ID,
+		"open_ghost_seats", game.OpenGhostSeats(),
+	)
+
+	claimed := *ghost
+	return &claimed, nil
+}
+
+// ListOwnedGames lists the games created by owner, oldest first
+func (s *gameService) ListOwnedGames(ctx context.Context, owner string) ([]domain.GameSummary, error) {
+	ctx, span := s.tracer.Start(ctx, "ListOwnedGames")
+	defer span.End()
+
+	s.ownerMu.Lock()
+	sessionIDs := make([]string, 0, len(s.ownedGames[owner]))
+	for id := range s.ownedGames[owner] {
+		sessionIDs = append(sessionIDs, id)
+	}
+	s.ownerMu.Unlock()
+
+	summaries := make([]domain.GameSummary, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		game, err := s.repo.FindByID(ctx, id)
+		if err != nil || game.Owner != owner {
+			// The session was deleted, or reused by another client after
+			// the game finished
+			s.forgetOwnedGame(owner, id)
+			continue
+		}
+		summaries = append(summaries, game.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.Before(summaries[j].CreatedAt)
+	})
+
+	span.SetAttributes(attribute.Int("owner.games", len(summaries)))
+	return summaries, nil
+}
+
+// forgetOwnedGame removes sessionID from the games listed for owner
+func (s *gameService) forgetOwnedGame(owner, sessionID string) {
+	if owner == "" {
+		return
+	}
+
+	s.ownerMu.Lock()
+	defer s.ownerMu.Unlock()
+
+	delete(s.ownedGames[owner], sessionID)
+	if len(s.ownedGames[owner]) == 0 {
+		delete(s.ownedGames, owner)
+	}
+}
+
+// ListVersusLobby lists running versus games with unclaimed ghosts
+func (s *gameService) ListVersusLobby(ctx context.Context) ([]domain.GameSummary, error) {
+	ctx, span := s.tracer.Start(ctx, "ListVersusLobby")
+	defer span.End()
+
+	s.gameLoopMu.RLock()
+	sessionIDs := make([]string, 0, len(s.gameLoops))
+	for id := range s.gameLoops {
+		sessionIDs = append(sessionIDs, id)
+	}
+	s.gameLoopMu.RUnlock()
+
+	summaries := make([]domain.GameSummary, 0)
+	for _, id := range sessionIDs {
+		game, err := s.repo.FindByID(ctx, id)
+		if err != nil || game.IsFinished() || game.OpenGhostSeats() == 0 {
+			continue
+		}
+		summaries = append(summaries, game.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.Before(summaries[j].CreatedAt)
+	})
+
+	span.SetAttributes(attribute.Int("lobby.games", len(summaries)))
+	return summaries, nil
+}
+
+// SetPlayerDirection sets the movement direction of the player or claimed
+// ghost authenticated by playerToken
+func (s *gameService) SetPlayerDirection(ctx context.Context, sessionID string, playerToken string, dir domain.Direction) error {
 	ctx, span := s.tracer.Start(ctx, "SetPlayerDirection")
 	defer span.End()
 
@@ -169,14 +566,58 @@ func (s *gameService) SetPlayerDirection(ctx context.Context, sessionID string,
 		attribute.String("direction", dir.String()),
 	)
 
+	if !dir.IsValid() {
+		err := fmt.Errorf("%w: %d", domain.ErrInvalidDirection, dir)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid direction")
+		return err
+	}
+
+	defer s.lockGame(sessionID).Unlock()
+
 	game, err := s.repo.FindByID(ctx, sessionID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "game not found")
-		return fmt.Errorf("game not found: %w", err)
+		return fmt.Errorf("failed to set player direction: %w", err)
+	}
+
+	if game.IsFinished() {
+		err := fmt.Errorf("%w: %s", domain.ErrGameOver, sessionID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game is over")
+		return err
 	}
 
-	game.PlayerDir = dir
+	if ghost, ok := game.GhostByToken(playerToken); ok {
+		span.SetAttributes(attribute.String("ghost.id", ghost.ID))
+		ghost.Direction = dir
+		game.UpdatedAt = time.Now()
+		if err := s.repo.Save(ctx, game); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to save game")
+			return fmt.Errorf("failed to update game: %w", err)
+		}
+		return nil
+	}
+
+	player, err := authenticatePlayer(game, playerToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid player token")
+		return err
+	}
+
+	span.SetAttributes(attribute.String("player.id", player.ID))
+
+	if !player.IsAlive() {
+		err := fmt.Errorf("%w: %s", domain.ErrPlayerEliminated, player.ID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "player eliminated")
+		return err
+	}
+
+	player.Direction = dir
 	game.UpdatedAt = time.Now()
 
 	if err := s.repo.Save(ctx, game); err != nil {
@@ -192,6 +633,207 @@ func (s *gameService) SetPlayerDirection(ctx context.Context, sessionID string,
 	return nil
 }
 
+// authenticatePlayer returns the player of game that token belongs to.
+// Single-player games accept an empty token for the host, which keeps
+// clients that only send a session ID working. Versus games do not, since
+// the lobby publishes their session IDs.
+func authenticatePlayer(game *domain.Game, token string) (*domain.Player, error) {
+	if token == "" {
+		if game.MaxPlayers == 1 && !game.Versus {
+			return game.Host(), nil
+		}
+		return nil, fmt.Errorf("%w: token required in multiplayer and versus games", domain.ErrInvalidPlayerToken)
+	}
+
+	player, ok := game.PlayerByToken(token)
+	if !ok {
+		return nil, domain.ErrInvalidPlayerToken
+	}
+	return player, nil
+}
+
+// authenticateHost checks that token belongs to the host of game. Unlike
+// authenticatePlayer, it never accepts an empty token.
+func authenticateHost(game *domain.Game, token string) error {
+	if token == "" {
+		return fmt.Errorf("%w: token required", domain.ErrInvalidPlayerToken)
+	}
+	player, ok := game.PlayerByToken(token)
+	if !ok {
+		return domain.ErrInvalidPlayerToken
+	}
+	if player.ID != game.Host().ID {
+		return domain.ErrNotGameHost
+	}
+	return nil
+}
+
+// SetAutopilot hands a player to an autopilot strategy, or back to human
+// input when strategy is empty
+func (s *gameService) SetAutopilot(ctx context.Context, sessionID string, playerToken string, strategy string) error {
+	ctx, span := s.tracer.Start(ctx, "SetAutopilot")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("session.id", sessionID),
+		attribute.String("autopilot", strategy),
+	)
+
+	if !hasStrategy(strategy) {
+		err := fmt.Errorf("%w: %s", domain.ErrUnknownStrategy, strategy)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unknown autopilot strategy")
+		return err
+	}
+
+	defer s.lockGame(sessionID).Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return fmt.Errorf("failed to set autopilot: %w", err)
+	}
+
+	player, err := authenticatePlayer(game, playerToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid player token")
+		return err
+	}
+
+	player.Autopilot = strategy
+	game.UpdatedAt = time.Now()
+
+	if err := s.repo.Save(ctx, game); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save game")
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "autopilot changed",
+		"session_id", sessionID,
+		"player_id", player.ID,
+		"autopilot", strategy,
+	)
+	return nil
+}
+
+// Step applies a bot's action to a headless game and advances it by one
+// tick
+func (s *gameService) Step(ctx context.Context, sessionID string, playerToken string, dir domain.Direction) (*domain.StepResult, error) {
+	ctx, span := s.tracer.Start(ctx, "Step")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("session.id", sessionID),
+		attribute.String("direction", dir.String()),
+	)
+
+	result, err := s.step(ctx, sessionID, playerToken, dir)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to step game")
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int("reward", result.Reward),
+		attribute.Bool("done", result.Done),
+	)
+	return result, nil
+}
+
+// StepMany applies several bot actions, each to its own headless game,
+// and reports an outcome per action in the same order
+func (s *gameService) StepMany(ctx context.Context, actions []domain.StepAction) []domain.StepOutcome {
+	ctx, span := s.tracer.Start(ctx, "StepMany")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("batch.size", len(actions)))
+
+	// Games step in parallel; the actions on one game run in order
+	games := make(map[string][]int)
+	for i, a := range actions {
+		games[a.SessionID] = append(games[a.SessionID], i)
+	}
+
+	outcomes := make([]domain.StepOutcome, len(actions))
+	var wg sync.WaitGroup
+	for _, indexes := range games {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			for _, i := range indexes {
+				a := actions[i]
+				outcomes[i].Result, outcomes[i].Err = s.step(ctx, a.SessionID, a.PlayerToken, a.Direction)
+			}
+		}(indexes)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// step implements Step, locking the game. DirectionNone keeps the current
+// direction.
+func (s *gameService) step(ctx context.Context, sessionID string, playerToken string, dir domain.Direction) (*domain.StepResult, error) {
+	if dir != domain.DirectionNone && !dir.IsValid() {
+		return nil, fmt.Errorf("%w: %d", domain.ErrInvalidDirection, dir)
+	}
+
+	lock := s.lockGame(sessionID)
+	defer lock.Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to step game: %w", err)
+	}
+
+	if !game.Headless {
+		return nil, fmt.Errorf("%w: %s", domain.ErrNotHeadless, sessionID)
+	}
+	if game.IsFinished() {
+		return nil, fmt.Errorf("%w: %s", domain.ErrGameOver, sessionID)
+	}
+
+	// score reads the score of whoever the token controls
+	var score func() int
+	var alive func() bool
+	if ghost, ok := game.GhostByToken(playerToken); ok {
+		if dir != domain.DirectionNone {
+			ghost.Direction = dir
+		}
+		score = func() int { return ghost.Score }
+		alive = func() bool { return true }
+	} else {
+		player, err := authenticatePlayer(game, playerToken)
+		if err != nil {
+			return nil, err
+		}
+		if dir != domain.DirectionNone && player.IsAlive() {
+			player.Direction = dir
+		}
+		score = func() int { return player.Score }
+		alive = player.IsAlive
+	}
+
+	before := score()
+	s.advance(lock.engine, game)
+
+	if err := s.repo.Save(ctx, game); err != nil {
+		return nil, fmt.Errorf("failed to save game: %w", err)
+	}
+	if game.IsFinished() {
+		s.releaseHeadless(sessionID)
+	}
+
+	return &domain.StepResult{
+		State:  s.gameState(game),
+		Reward: score() - before,
+		Done:   game.IsFinished() || !alive(),
+	}, nil
+}
+
 // GetGameState retrieves the current game state
 func (s *gameService) GetGameState(ctx context.Context, sessionID string) (*domain.GameState, error) {
 	ctx, span := s.tracer.Start(ctx, "GetGameState")
@@ -203,10 +845,12 @@ func (s *gameService) GetGameState(ctx context.Context, sessionID string) (*doma
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "game not found")
-		return nil, fmt.Errorf("game not found: %w", err)
+		return nil, fmt.Errorf("failed to get game state: %w", err)
 	}
 
-	state := game.ToGameState(GameWidth, GameHeight)
+	// Only the copy is pruned; WatchGame prunes the stored game
+	game.PruneSpectators(time.Now().Add(-spectatorTimeout))
+	state := s.gameState(game)
 
 	span.SetAttributes(
 		attribute.Int("score", state.Score),
@@ -218,13 +862,125 @@ func (s *gameService) GetGameState(ctx context.Context, sessionID string) (*doma
 	return &state, nil
 }
 
-// RestartGame restarts a game session
-func (s *gameService) RestartGame(ctx context.Context, sessionID string) (*domain.Game, error) {
+// WatchGame retrieves the state of the game granted by spectatorToken
+func (s *gameService) WatchGame(ctx context.Context, spectatorToken string, viewerID string) (*domain.GameState, error) {
+	ctx, span := s.tracer.Start(ctx, "WatchGame")
+	defer span.End()
+
+	s.spectatorMu.Lock()
+	sessionID, ok := s.spectatorTokens[spectatorToken]
+	s.spectatorMu.Unlock()
+	if !ok {
+		err := fmt.Errorf("%w: unknown spectator token", domain.ErrGameNotFound)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	defer s.lockGame(sessionID).Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return nil, fmt.Errorf("failed to watch game: %w", err)
+	}
+
+	now := time.Now()
+	game.PruneSpectators(now.Add(-spectatorTimeout))
+	game.WatchedBy(viewerID, now)
+	if err := s.repo.Save(ctx, game); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save game")
+		return nil, fmt.Errorf("failed to update game: %w", err)
+	}
+	state := s.gameState(game)
+
+	span.SetAttributes(attribute.Int("game.spectators", state.Spectators))
+	return &state, nil
+}
+
+// gameState converts game to the state sent to clients, adding the
+// current announcement
+func (s *gameService) gameState(game *domain.Game) domain.GameState {
+	state := game.ToGameState()
+	state.Announcement = s.currentAnnouncement(time.Now())
+	return state
+}
+
+// ListLiveGames lists running games, highest score first
+func (s *gameService) ListLiveGames(ctx context.Context) ([]domain.LiveGame, error) {
+	ctx, span := s.tracer.Start(ctx, "ListLiveGames")
+	defer span.End()
+
+	s.gameLoopMu.RLock()
+	sessionIDs := make([]string, 0, len(s.gameLoops))
+	for id := range s.gameLoops {
+		sessionIDs = append(sessionIDs, id)
+	}
+	s.gameLoopMu.RUnlock()
+
+	cutoff := time.Now().Add(-spectatorTimeout)
+	games := make([]domain.LiveGame, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		game, err := s.repo.FindByID(ctx, id)
+		if err != nil || game.IsFinished() {
+			continue
+		}
+		game.PruneSpectators(cutoff)
+		games = append(games, game.Live())
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Score != games[j].Score {
+			return games[i].Score > games[j].Score
+		}
+		return games[i].CreatedAt.Before(games[j].CreatedAt)
+	})
+
+	span.SetAttributes(attribute.Int("live.games", len(games)))
+	return games, nil
+}
+
+// RestartGame restarts a game session for its host, authenticated by
+// playerToken
+func (s *gameService) RestartGame(ctx context.Context, sessionID string, playerToken string) (*domain.Game, error) {
 	ctx, span := s.tracer.Start(ctx, "RestartGame")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
+	// A draining server would refuse the new game, so keep the old one
+	if s.Draining() {
+		err := domain.ErrDraining
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	defer s.lockGame(sessionID).Unlock()
+
+	// Keep the rules and players of the game being replaced
+	var opts domain.GameOptions
+	old, err := s.repo.FindByID(ctx, sessionID)
+	if err == nil {
+		if err := authenticateHost(old, playerToken); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "host not authenticated")
+			return nil, err
+		}
+		opts.Preset = old.Rules.Preset
+		opts.MaxPlayers = old.MaxPlayers
+		opts.Versus = old.Versus
+		opts.Maze = old.Maze
+		opts.Headless = old.Headless
+		opts.Owner = old.Owner
+	} else {
+		old = nil
+	}
+
 	// Stop existing game loop
 	s.stopGameLoop(sessionID)
 
@@ -237,7 +993,7 @@ func (s *gameService) RestartGame(ctx context.Context, sessionID string) (*domai
 	}
 
 	// Create new game
-	return s.CreateGame(ctx, sessionID)
+	return s.createGame(ctx, sessionID, opts, old)
 }
 
 // DeleteGame removes a game session
@@ -247,8 +1003,51 @@ func (s *gameService) DeleteGame(ctx context.Context, sessionID string) error {
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
+	defer s.lockGame(sessionID).Unlock()
+	return s.deleteGame(ctx, sessionID)
+}
+
+// DeleteGameAsHost removes a game session for its host, authenticated by
+// playerToken
+func (s *gameService) DeleteGameAsHost(ctx context.Context, sessionID string, playerToken string) error {
+	ctx, span := s.tracer.Start(ctx, "DeleteGameAsHost")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("session.id", sessionID))
+
+	defer s.lockGame(sessionID).Unlock()
+
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "game not found")
+		return fmt.Errorf("failed to delete game: %w", err)
+	}
+	if err := authenticateHost(game, playerToken); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "host not authenticated")
+		return err
+	}
+	return s.deleteGame(ctx, sessionID)
+}
+
+// deleteGame stops and removes a game session. Errors are recorded on the
+// span in ctx. Callers must hold the game's lock.
+func (s *gameService) deleteGame(ctx context.Context, sessionID string) error {
+	span := trace.SpanFromContext(ctx)
+
 	// Stop game loop
 	s.stopGameLoop(sessionID)
+	s.releaseHeadless(sessionID)
+
+	// Revoke the spectator link and drop the game from its owner's list
+	if game, err := s.repo.FindByID(ctx, sessionID); err == nil {
+		s.spectatorMu.Lock()
+		delete(s.spectatorTokens, game.SpectatorToken)
+		s.spectatorMu.Unlock()
+
+		s.forgetOwnedGame(game.Owner, sessionID)
+	}
 
 	// Delete from repository
 	if err := s.repo.Delete(ctx, sessionID); err != nil {
@@ -272,85 +1071,87 @@ func (s *gameService) StartGameLoop(ctx context.Context, sessionID string) error
 
 	span.SetAttributes(attribute.String("session.id", sessionID))
 
-	// Check if game exists
-	if !s.repo.Exists(ctx, sessionID) {
-		err := fmt.Errorf("game not found: %s", sessionID)
+	game, err := s.repo.FindByID(ctx, sessionID)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "game not found")
-		return err
+		return fmt.Errorf("failed to start game loop: %w", err)
 	}
 
-	// Create cancellable context for the game loop
-	loopCtx, cancel := context.WithCancel(context.Background())
-
 	s.gameLoopMu.Lock()
-	// Stop existing loop if any
-	if existingCancel, exists := s.gameLoops[sessionID]; exists {
-		existingCancel()
+	if s.shuttingDown {
+		s.gameLoopMu.Unlock()
+		err := domain.ErrDraining
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if !s.hasCapacity(sessionID) {
+		s.gameLoopMu.Unlock()
+		err := domain.ErrTooManyGames
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
-	s.gameLoops[sessionID] = cancel
+	// Replaces the existing loop, if any
+	err = s.ticks.Add(sessionID, game.Rules.TickInterval, func(time.Time) error {
+		return s.gameTick(context.Background(), sessionID)
+	})
+	if err != nil {
+		s.gameLoopMu.Unlock()
+		if errors.Is(err, scheduler.ErrStopped) {
+			err = domain.ErrDraining
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to schedule game loop")
+		return fmt.Errorf("failed to start game loop: %w", err)
+	}
+	s.gameLoops[sessionID] = true
 	s.gameLoopMu.Unlock()
 
-	// Start game loop in goroutine
-	go s.runGameLoop(loopCtx, sessionID)
-
 	s.logger.InfoContext(ctx, "game loop started", "session_id", sessionID)
 	return nil
 }
 
-// runGameLoop runs the game loop until context is cancelled or game ends
-func (s *gameService) runGameLoop(ctx context.Context, sessionID string) {
-	ticker := time.NewTicker(GameTickInterval)
-	defer ticker.Stop()
-	defer s.cleanupGameLoop(sessionID)
-
-	s.logger.Info("game loop running", "session_id", sessionID)
-
-	for {
-		select {
-		case <-ctx.Done():
-			s.logger.Info("game loop stopped", "session_id", sessionID)
-			return
-		case <-ticker.C:
-			if err := s.gameTick(ctx, sessionID); err != nil {
-				s.logger.Error("game tick failed",
-					"session_id", sessionID,
-					"error", err,
-				)
-				return
-			}
-		}
+// gameLoopEnded forgets the game loop of a session once a tick ended it,
+// unless the loop was started again in the meantime
+func (s *gameService) gameLoopEnded(sessionID string, err error) {
+	if !errors.Is(err, domain.ErrGameOver) {
+		s.logger.Error("game tick failed",
+			"session_id", sessionID,
+			"error", err,
+		)
+	}
+
+	s.gameLoopMu.Lock()
+	defer s.gameLoopMu.Unlock()
+
+	if !s.ticks.Has(sessionID) {
+		delete(s.gameLoops, sessionID)
 	}
 }
 
-// gameTick performs one game tick
+// gameTick performs one game tick, locking the game
 func (s *gameService) gameTick(ctx context.Context, sessionID string) error {
+	lock := s.lockGame(sessionID)
+	defer lock.Unlock()
+
 	game, err := s.repo.FindByID(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("game not found: %w", err)
+		return fmt.Errorf("failed to load game: %w", err)
 	}
 
 	// Stop if game is over or won
-	if game.GameOver || game.DotsLeft == 0 {
+	if game.IsFinished() {
 		s.logger.Info("game ended",
 			"session_id", sessionID,
 			"game_over", game.GameOver,
-			"won", game.DotsLeft == 0,
+			"won", game.DotsLeft() == 0,
 		)
-		return fmt.Errorf("game ended")
+		return domain.ErrGameOver
 	}
 
-	// Move player
-	s.movePlayer(game)
-
-	// Move ghosts
-	s.moveGhosts(game)
-
-	// Check collisions
-	s.checkCollisions(game)
-
-	// Update timestamp
-	game.UpdatedAt = time.Now()
+	s.advance(lock.engine, game)
 
 	// Save game state
 	if err := s.repo.Save(ctx, game); err != nil {
@@ -360,120 +1161,118 @@ func (s *gameService) gameTick(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-// movePlayer moves the player based on current direction
-func (s *gameService) movePlayer(game *domain.Game) {
-	if game.PlayerDir == domain.DirectionNone {
-		return
+// advance moves game on by one tick with engine, logging who was caught.
+// Callers must hold the lock that owns engine.
+func (s *gameService) advance(engine *Engine, game *domain.Game) {
+	for _, c := range engine.Advance(game) {
+		s.logger.Info("player caught",
+			"session_id", game.ID,
+			"player_id", c.PlayerID,
+			"player_position", c.Position,
+			"lives_left", c.LivesLeft,
+			"ghost_id", c.GhostID,
+		)
+	}
+	if game.GameOver {
+		s.logger.Info("game over - all players caught", "session_id", game.ID)
 	}
 
-	newPos := game.Player.Move(game.PlayerDir)
+	// Update timestamp
+	game.UpdatedAt = time.Now()
+}
 
-	if game.IsValidPosition(newPos, GameWidth, GameHeight) {
-		game.Player = newPos
+// ExpiryHandler is implemented by services that keep track of games
+// outside the repository, and must forget the games it expires
+type ExpiryHandler interface {
+	GameExpired(game *domain.Game)
+}
 
-		// Collect dot
-		if game.Board[game.Player.Y][game.Player.X] == '.' {
-			game.Board[game.Player.Y][game.Player.X] = ' '
-			game.Score += ScorePerDot
-			game.DotsLeft--
-		}
+// GameExpired forgets the spectator link and owner of a game the
+// repository expired, unless the game was saved again since
+func (s *gameService) GameExpired(game *domain.Game) {
+	defer s.lockGame(game.ID).Unlock()
+
+	if s.repo.Exists(context.Background(), game.ID) {
+		return
 	}
+
+	s.spectatorMu.Lock()
+	if s.spectatorTokens[game.SpectatorToken] == game.ID {
+		delete(s.spectatorTokens, game.SpectatorToken)
+	}
+	s.spectatorMu.Unlock()
+
+	s.forgetOwnedGame(game.Owner, game.ID)
+	s.releaseHeadless(game.ID)
+
+	s.logger.Info("expired game forgotten", "session_id", game.ID)
 }
 
-// moveGhosts moves all ghosts with AI behavior
-func (s *gameService) moveGhosts(game *domain.Game) {
-	for i := range game.Ghosts {
-		ghost := &game.Ghosts[i]
-
-		// Determine ghost direction
-		var dir domain.Direction
-		if s.rng.Intn(100) < 30 {
-			// 30% chance to change direction randomly
-			dir = domain.Direction(s.rng.Intn(4))
-		} else {
-			// Try to move towards player
-			dx := game.Player.X - ghost.Position.X
-			dy := game.Player.Y - ghost.Position.Y
-
-			if abs(dx) > abs(dy) {
-				if dx > 0 {
-					dir = domain.DirectionRight
-				} else {
-					dir = domain.DirectionLeft
-				}
-			} else if dy > 0 {
-				dir = domain.DirectionDown
-			} else {
-				dir = domain.DirectionUp
-			}
-		}
+// ConfigUpdater is implemented by services whose tuning can be changed
+// while games are running
+type ConfigUpdater interface {
+	UpdateConfig(cfg config.GameConfig)
+}
 
-		newPos := ghost.Position.Move(dir)
+// UpdateConfig applies new game configuration. Running games are not
+// stopped if the new limit is lower; it only applies to new games.
+func (s *gameService) UpdateConfig(cfg config.GameConfig) {
+	s.gameLoopMu.Lock()
+	defer s.gameLoopMu.Unlock()
 
-		if game.IsValidPosition(newPos, GameWidth, GameHeight) {
-			ghost.Position = newPos
-			ghost.Direction = dir
-		} else {
-			// Try random direction if current doesn't work
-			dirs := []domain.Direction{
-				domain.DirectionUp,
-				domain.DirectionDown,
-				domain.DirectionLeft,
-				domain.DirectionRight,
-			}
-			s.rng.Shuffle(len(dirs), func(i, j int) {
-				dirs[i], dirs[j] = dirs[j], dirs[i]
-			})
-			for _, d := range dirs {
-				newPos := ghost.Position.Move(d)
-				if game.IsValidPosition(newPos, GameWidth, GameHeight) {
-					ghost.Position = newPos
-					ghost.Direction = d
-					break
-				}
-			}
-		}
-	}
+	s.cfg = cfg
 }
 
-// checkCollisions checks if player collided with any ghost
-func (s *gameService) checkCollisions(game *domain.Game) {
-	for _, ghost := range game.Ghosts {
-		if game.Player.Equals(ghost.Position) {
-			game.GameOver = true
-			s.logger.Info("game over - collision",
-				"session_id", game.ID,
-				"player_position", game.Player,
-				"ghost_position", ghost.Position,
-			)
-			return
-		}
+// hasCapacity reports whether a loop or headless game may be started for
+// sessionID without exceeding the concurrent game limit, which counts both.
+// Replacing a session's own game never counts against the limit. Callers
+// must hold gameLoopMu.
+func (s *gameService) hasCapacity(sessionID string) bool {
+	if s.gameLoops[sessionID] || s.headlessGames[sessionID] {
+		return true
 	}
+	return len(s.gameLoops)+len(s.headlessGames) < s.cfg.MaxConcurrentGames
 }
 
-// stopGameLoop stops the game loop for a session
-func (s *gameService) stopGameLoop(sessionID string) {
+// reserveHeadless counts a headless game against the concurrent game
+// limit, and reports false when the limit is reached
+func (s *gameService) reserveHeadless(sessionID string) bool {
 	s.gameLoopMu.Lock()
 	defer s.gameLoopMu.Unlock()
 
-	if cancel, exists := s.gameLoops[sessionID]; exists {
-		cancel()
-		delete(s.gameLoops, sessionID)
+	if !s.hasCapacity(sessionID) {
+		return false
 	}
+	s.headlessGames[sessionID] = true
+	return true
 }
 
-// cleanupGameLoop cleans up game loop resources
-func (s *gameService) cleanupGameLoop(sessionID string) {
+// releaseHeadless stops counting a headless game against the concurrent
+// game limit
+func (s *gameService) releaseHeadless(sessionID string) {
 	s.gameLoopMu.Lock()
 	defer s.gameLoopMu.Unlock()
 
-	delete(s.gameLoops, sessionID)
+	delete(s.headlessGames, sessionID)
+}
+
+// stopGameLoop stops the game loop for a session
+func (s *gameService) stopGameLoop(sessionID string) {
+	s.gameLoopMu.Lock()
+	defer s.gameLoopMu.Unlock()
+
+	if s.gameLoops[sessionID] {
+		s.ticks.Remove(sessionID)
+		delete(s.gameLoops, sessionID)
+		s.logger.Info("game loop stopped", "session_id", sessionID)
+	}
 }
 
-// abs returns absolute value of an integer
-func abs(x int) int {
-	if x < 0 {
-		return -x
+// newPlayerToken generates a random token that authenticates a player
+func newPlayerToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate player token: %w", err)
 	}
-	return x
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/siddarth/go-app/internal/autopilot"
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/repository/memory"
+)
+
+// newTestService creates a game service on an in-memory repository with
+// the default configuration, changed by each of tune
+func newTestService(tb testing.TB, tune ...func(cfg *config.Config)) domain.GameService {
+	tb.Helper()
+	return newCheckpointedService(tb, nil, tune...)
+}
+
+// newCheckpointedService is newTestService with games checkpointed to
+// checkpoints
+func newCheckpointedService(tb testing.TB, checkpoints domain.CheckpointStore, tune ...func(cfg *config.Config)) domain.GameService {
+	tb.Helper()
+
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		tb.Fatalf("failed to load configuration: %v", err)
+	}
+	for _, fn := range tune {
+		fn(cfg)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewGameService(memory.NewGameRepository(0, 0), checkpoints, cfg.Game, cfg.Scheduler, logger)
+}
+
+func TestRestartGameRequiresHost(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	game, err := svc.CreateGame(ctx, "a", domain.GameOptions{MaxPlayers: 2})
+	if err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+	host := game.Host().Token
+	guest, err := svc.JoinGame(ctx, "a", "guest")
+	if err != nil {
+		t.Fatalf("JoinGame: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"no token", "", domain.ErrInvalidPlayerToken},
+		{"unknown token", "nope", domain.ErrInvalidPlayerToken},
+		{"guest", guest.Token, domain.ErrNotGameHost},
+	} {
+		if _, err := svc.RestartGame(ctx, "a", tc.token); !errors.Is(err, tc.want) {
+			t.Errorf("RestartGame by %s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	restarted, err := svc.RestartGame(ctx, "a", host)
+	if err != nil {
+		t.Fatalf("RestartGame by the host: %v", err)
+	}
+	if len(restarted.Players) != 2 || restarted.Host().Token != host {
+		t.Fatalf("restarted game lost its players: %+v", restarted.Players)
+	}
+}
+
+func TestDeleteGameAsHostRequiresHost(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	game, err := svc.CreateGame(ctx, "a", domain.GameOptions{MaxPlayers: 2})
+	if err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+	guest, err := svc.JoinGame(ctx, "a", "guest")
+	if err != nil {
+		t.Fatalf("JoinGame: %v", err)
+	}
+
+	if err := svc.DeleteGameAsHost(ctx, "a", ""); !errors.Is(err, domain.ErrInvalidPlayerToken) {
+		t.Errorf("DeleteGameAsHost without a token: got %v, want %v", err, domain.ErrInvalidPlayerToken)
+	}
+	if err := svc.DeleteGameAsHost(ctx, "a", guest.Token); !errors.Is(err, domain.ErrNotGameHost) {
+		t.Errorf("DeleteGameAsHost by a guest: got %v, want %v", err, domain.ErrNotGameHost)
+	}
+	if _, err := svc.GetGameState(ctx, "a"); err != nil {
+		t.Fatalf("game gone after refused deletes: %v", err)
+	}
+
+	if err := svc.DeleteGameAsHost(ctx, "a", game.Host().Token); err != nil {
+		t.Fatalf("DeleteGameAsHost by the host: %v", err)
+	}
+	if _, err := svc.GetGameState(ctx, "a"); !errors.Is(err, domain.ErrGameNotFound) {
+		t.Fatalf("GetGameState after delete: got %v, want %v", err, domain.ErrGameNotFound)
+	}
+	if err := svc.DeleteGameAsHost(ctx, "a", game.Host().Token); !errors.Is(err, domain.ErrGameNotFound) {
+		t.Fatalf("deleting a deleted game: got %v, want %v", err, domain.ErrGameNotFound)
+	}
+}
+
+func TestVersusGamesRequirePlayerToken(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	solo, err := svc.CreateGame(ctx, "solo", domain.GameOptions{})
+	if err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+	if err := svc.SetPlayerDirection(ctx, solo.ID, "", domain.DirectionLeft); err != nil {
+		t.Fatalf("moving the host of a single-player game without a token: %v", err)
+	}
+
+	// The lobby publishes the session IDs of versus games, so knowing one
+	// must not be enough to steer their players
+	versus, err := svc.CreateGame(ctx, "versus", domain.GameOptions{Versus: true})
+	if err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+	if err := svc.SetPlayerDirection(ctx, versus.ID, "", domain.DirectionLeft); !errors.Is(err, domain.ErrInvalidPlayerToken) {
+		t.Fatalf("moving a versus player without a token: got %v, want %v", err, domain.ErrInvalidPlayerToken)
+	}
+	if err := svc.SetAutopilot(ctx, versus.ID, "", ""); !errors.Is(err, domain.ErrInvalidPlayerToken) {
+		t.Fatalf("changing a versus player's autopilot without a token: got %v, want %v", err, domain.ErrInvalidPlayerToken)
+	}
+	if err := svc.SetPlayerDirection(ctx, versus.ID, versus.Host().Token, domain.DirectionLeft); err != nil {
+		t.Fatalf("moving a versus player with its token: %v", err)
+	}
+}
+
+func TestHeadlessGamesCountAgainstLimit(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t, func(cfg *config.Config) {
+		cfg.Game.MaxConcurrentGames = 2
+	})
+
+	headless := domain.GameOptions{Headless: true}
+	for _, id := range []string{"a", "b"} {
+		if _, err := svc.CreateGame(ctx, id, headless); err != nil {
+			t.Fatalf("CreateGame(%s): %v", id, err)
+		}
+	}
+	if _, err := svc.CreateGame(ctx, "c", headless); !errors.Is(err, domain.ErrTooManyGames) {
+		t.Fatalf("headless game over the limit: got %v, want %v", err, domain.ErrTooManyGames)
+	}
+	if _, err := svc.CreateGame(ctx, "c", domain.GameOptions{}); !errors.Is(err, domain.ErrTooManyGames) {
+		t.Fatalf("game over the limit: got %v, want %v", err, domain.ErrTooManyGames)
+	}
+
+	// Deleting one makes room
+	if err := svc.DeleteGame(ctx, "a"); err != nil {
+		t.Fatalf("DeleteGame: %v", err)
+	}
+	if _, err := svc.CreateGame(ctx, "c", headless); err != nil {
+		t.Fatalf("CreateGame after deleting a game: %v", err)
+	}
+}
+
+func TestStepManyKeepsOrderPerGame(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService(t)
+
+	var actions []domain.StepAction
+	for _, id := range []string{"a", "b", "c"} {
+		game, err := svc.CreateGame(ctx, id, domain.GameOptions{Headless: true})
+		if err != nil {
+			t.Fatalf("CreateGame: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			actions = append(actions, domain.StepAction{
+				SessionID:   id,
+				PlayerToken: game.Host().Token,
+				Direction:   domain.DirectionNone,
+			})
+		}
+	}
+	actions = append(actions, domain.StepAction{SessionID: "missing"})
+
+	outcomes := svc.StepMany(ctx, actions)
+	if len(outcomes) != len(actions) {
+		t.Fatalf("got %d outcomes for %d actions", len(outcomes), len(actions))
+	}
+	if !errors.Is(outcomes[len(outcomes)-1].Err, domain.ErrGameNotFound) {
+		t.Fatalf("step of a missing game: got %v, want %v", outcomes[len(outcomes)-1].Err, domain.ErrGameNotFound)
+	}
+
+	// The last action on each game ran last, leaving the state it returned
+	last := make(map[string]*domain.StepResult)
+	for i, a := range actions[:len(actions)-1] {
+		if err := outcomes[i].Err; err != nil {
+			t.Fatalf("step %d of %s: %v", i, a.SessionID, err)
+		}
+		last[a.SessionID] = outcomes[i].Result
+	}
+	for id, result := range last {
+		state, err := svc.GetGameState(ctx, id)
+		if err != nil {
+			t.Fatalf("GetGameState: %v", err)
+		}
+		want, _ := json.Marshal(result.State)
+		got, _ := json.Marshal(state)
+		if string(got) != string(want) {
+			t.Fatalf("%s ended in a state other than that of its last step", id)
+		}
+	}
+}
+
+// BenchmarkGetGameState measures building a game's state and encoding it
+// as JSON, after the greedy autopilot has eaten some of its dots
+func BenchmarkGetGameState(b *testing.B) {
+	const sessionID = "bench"
+
+	ctx := context.Background()
+	svc := newTestService(b)
+	game, err := svc.CreateGame(ctx, sessionID, domain.GameOptions{
+		Headless:  true,
+		Autopilot: autopilot.StrategyGreedy,
+	})
+	if err != nil {
+		b.Fatalf("CreateGame: %v", err)
+	}
+	token := game.Players[0].Token
+	for i := 0; i < 40; i++ {
+		result, err := svc.Step(ctx, sessionID, token, domain.DirectionNone)
+		if err != nil {
+			b.Fatalf("Step: %v", err)
+		}
+		if result.Done {
+			break
+		}
+	}
+	if game, err = svc.GetGame(ctx, sessionID); err != nil {
+		b.Fatalf("GetGame: %v", err)
+	}
+
+	b.Run("state", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = game.ToGameState()
+		}
+	})
+	b.Run("state+json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(game.ToGameState()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("GetGameState+json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			state, err := svc.GetGameState(ctx, sessionID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := json.Marshal(state); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("board/json", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 4096)
+		for i := 0; i < b.N; i++ {
+			buf = game.Board.AppendJSON(buf[:0])
+		}
+	})
+	b.Run("board/binary", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 4096)
+		for i := 0; i < b.N; i++ {
+			buf = game.Board.AppendBinary(buf[:0])
+		}
+	})
+}
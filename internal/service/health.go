@@ -0,0 +1,38 @@
+package service
+
+import "time"
+
+// HealthReporter is implemented by services that report on their own
+// health
+type HealthReporter interface {
+	// Draining reports whether new games are refused
+	Draining() bool
+
+	// TickLag returns the longest delay of a recent game tick behind its
+	// schedule
+	TickLag() time.Duration
+
+	// TickStall returns how long game ticks have made no progress
+	TickStall() time.Duration
+}
+
+// Draining reports whether new games are refused, either because
+// operators drain the server or because it is shutting down
+func (s *gameService) Draining() bool {
+	s.gameLoopMu.RLock()
+	defer s.gameLoopMu.RUnlock()
+
+	return s.draining || s.shuttingDown
+}
+
+// TickLag returns the longest delay of a game tick behind its schedule in
+// the last ten to twenty seconds
+func (s *gameService) TickLag() time.Duration {
+	return s.ticks.Lag()
+}
+
+// TickStall returns how long the slowest scheduler shard has gone without
+// running its due ticks
+func (s *gameService) TickStall() time.Duration {
+	return s.ticks.Stall()
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/siddarth/go-app/internal/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Lifecycle is implemented by services that keep their games across
+// server restarts
+type Lifecycle interface {
+	// Resume restores the games checkpointed by the last shutdown
+	Resume(ctx context.Context) error
+
+	// Shutdown stops taking games, tells clients, stops every game loop
+	// and checkpoints the games that have not finished. It gives up
+	// waiting for game loops when ctx is done.
+	Shutdown(ctx context.Context) error
+}
+
+// Shutdown stops the service's games, checkpointing them when the service
+// has a checkpoint store
+func (s *gameService) Shutdown(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "Shutdown")
+	defer span.End()
+
+	s.gameLoopMu.Lock()
+	s.shuttingDown = true
+	s.gameLoopMu.Unlock()
+
+	notice := "The server is restarting. Your game will continue shortly."
+	if s.checkpoints == nil {
+		notice = "The server is shutting down."
+	}
+	s.announcementMu.Lock()
+	s.announcement = domain.Announcement{Message: notice, ExpiresAt: time.Now().Add(shutdownNoticeTTL)}
+	s.announcementMu.Unlock()
+
+	// No loops start once shuttingDown is set, so every loop is stopped
+	s.gameLoopMu.Lock()
+	running := make(map[string]bool, len(s.gameLoops))
+	for id := range s.gameLoops {
+		running[id] = true
+		s.ticks.Remove(id)
+		delete(s.gameLoops, id)
+	}
+	s.gameLoopMu.Unlock()
+
+	s.logger.InfoContext(ctx, "stopping game loops", "running_loops", len(running))
+	span.SetAttributes(attribute.Int("shutdown.running_loops", len(running)))
+
+	var errs []error
+	if err := s.ticks.Stop(ctx); err != nil {
+		// Checkpoint anyway: the repository only hands out whole saved
+		// games, and no tick starts after its loop was removed
+		errs = append(errs, fmt.Errorf("game loops did not stop in time: %w", err))
+	}
+
+	if s.checkpoints == nil {
+		s.logger.WarnContext(ctx, "no checkpoint store configured, unfinished games are lost")
+		return errors.Join(errs...)
+	}
+
+	saved, err := s.checkpoint(ctx, running)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		s.logger.InfoContext(ctx, "games checkpointed", "games", saved)
+	}
+	span.SetAttributes(attribute.Int("shutdown.checkpointed_games", saved))
+
+	if err := errors.Join(errs...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "shutdown incomplete")
+		return err
+	}
+	return nil
+}
+
+// checkpoint saves every game that has not finished, marking those in
+// running to have their loops started again, and returns how many it saved
+func (s *gameService) checkpoint(ctx context.Context, running map[string]bool) (int, error) {
+	games, err := s.repo.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list games to checkpoint: %w", err)
+	}
+
+	checkpointed := make([]domain.CheckpointedGame, 0, len(games))
+	for _, game := range games {
+		if game.IsFinished() {
+			continue
+		}
+		checkpointed = append(checkpointed, domain.CheckpointedGame{
+			Game:    game,
+			Running: running[game.ID],
+		})
+	}
+
+	if err := s.checkpoints.Save(ctx, checkpointed); err != nil {
+		return 0, fmt.Errorf("failed to checkpoint games: %w", err)
+	}
+	return len(checkpointed), nil
+}
+
+// Resume restores checkpointed games, starting the loops of those that
+// were running, and clears the checkpoint so they are only resumed once
+func (s *gameService) Resume(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "Resume")
+	defer span.End()
+
+	if s.checkpoints == nil {
+		return nil
+	}
+
+	checkpointed, err := s.checkpoints.Load(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load checkpoint")
+		return fmt.Errorf("failed to resume games: %w", err)
+	}
+
+	var resumed, loops int
+	for _, cp := range checkpointed {
+		game := cp.Game
+		if game == nil || game.ID == "" || game.IsFinished() {
+			continue
+		}
+
+		if err := s.repo.Save(ctx, game); err != nil {
+			s.logger.ErrorContext(ctx, "failed to resume game",
+				"session_id", game.ID,
+				"error", err,
+			)
+			continue
+		}
+		s.indexGame(game)
+		resumed++
+
+		if game.Headless {
+			// Resumed games were within the limit when checkpointed
+			s.gameLoopMu.Lock()
+			s.headlessGames[game.ID] = true
+			s.gameLoopMu.Unlock()
+			continue
+		}
+		if !cp.Running {
+			continue
+		}
+		if err := s.StartGameLoop(ctx, game.ID); err != nil {
+			s.logger.ErrorContext(ctx, "failed to resume game loop",
+				"session_id", game.ID,
+				"error", err,
+			)
+			continue
+		}
+		loops++
+	}
+
+	span.SetAttributes(
+		attribute.Int("resume.games", resumed),
+		attribute.Int("resume.loops", loops),
+	)
+	if len(checkpointed) > 0 {
+		s.logger.InfoContext(ctx, "games resumed from checkpoint",
+			"games", resumed,
+			"running_loops", loops,
+		)
+	}
+
+	if err := s.checkpoints.Clear(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to clear checkpoint")
+		return fmt.Errorf("failed to clear resumed checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/repository/file"
+)
+
+// loopRunning reports whether the game loop of a session is running
+func loopRunning(t *testing.T, svc domain.GameService, sessionID string) bool {
+	t.Helper()
+	detail, err := svc.(domain.GameAdmin).InspectSession(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("InspectSession %s: %v", sessionID, err)
+	}
+	return detail.LoopRunning
+}
+
+func TestShutdownAndResume(t *testing.T) {
+	ctx := context.Background()
+	store := file.NewCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	before := newCheckpointedService(t, store)
+	running, err := before.CreateGame(ctx, "running", domain.GameOptions{MaxPlayers: 2})
+	if err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+	guest, err := before.JoinGame(ctx, "running", "guest")
+	if err != nil {
+		t.Fatalf("JoinGame: %v", err)
+	}
+	if err := before.StartGameLoop(ctx, "running"); err != nil {
+		t.Fatalf("StartGameLoop: %v", err)
+	}
+	if _, err := before.CreateGame(ctx, "paused", domain.GameOptions{}); err != nil {
+		t.Fatalf("CreateGame: %v", err)
+	}
+
+	if err := before.(Lifecycle).Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if loopRunning(t, before, "running") {
+		t.Fatal("game loop still running after Shutdown")
+	}
+
+	after := newCheckpointedService(t, store)
+	if err := after.(Lifecycle).Resume(ctx); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	t.Cleanup(func() { after.(Lifecycle).Shutdown(ctx) })
+
+	if !loopRunning(t, after, "running") {
+		t.Error("running game was resumed without its game loop")
+	}
+	if !after.(*gameService).ticks.Has("running") {
+		t.Error("resumed game loop has no scheduler slot")
+	}
+	if loopRunning(t, after, "paused") {
+		t.Error("paused game was resumed with a game loop")
+	}
+
+	// Players keep their tokens across the restart
+	if err := after.SetPlayerDirection(ctx, "running", guest.Token, domain.DirectionLeft); err != nil {
+		t.Errorf("guest move after Resume: %v", err)
+	}
+	if _, err := after.RestartGame(ctx, "running", running.Host().Token); err != nil {
+		t.Errorf("host restart after Resume: %v", err)
+	}
+
+	// Games are only resumed once
+	if games, err := store.Load(ctx); err != nil || games != nil {
+		t.Errorf("checkpoint after Resume: got %v and %v, want nothing", games, err)
+	}
+}
+
+func TestResumeLeavesCorruptCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	corrupt := []byte(`{"version": 2, "games": [`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+
+	svc := newCheckpointedService(t, file.NewCheckpointStore(path))
+	if err := svc.(Lifecycle).Resume(ctx); err == nil {
+		t.Fatal("Resume accepted a corrupt checkpoint")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(corrupt) {
+		t.Errorf("corrupt checkpoint was changed: %q %v", data, err)
+	}
+
+	// The service starts empty instead
+	if _, err := svc.CreateGame(ctx, "a", domain.GameOptions{}); err != nil {
+		t.Errorf("CreateGame after a failed Resume: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// DefaultMaze is the maze used when a game does not pick one
+const DefaultMaze = "classic"
+
+// mazes holds the built-in layouts by name. Every layout is GameWidth by
+// GameHeight and keeps the player and ghost spawn points open. Tiles are
+// # for walls, . for dots, spaces for empty floor, = for tunnels and * for
+// the bonus fruit spawn; the tunnels on row 7 lead around between the left
+// and right edges.
+var mazes = map[string][]string{
+	"classic": {
+		"####################",
+		"#..................#",
+		"#.##.##.##.##.##.###",
+		"#..................#",
+		"#.##.##....##.##.###",
+		"#......##.##......##",
+		"#.##.##....##.##.###",
+		"=..................=",
+		"#.##.##.##.##.##.###",
+		"#........*.........#",
+		"#.##....##....##.###",
+		"#......##.##......##",
+		"#.##....##....##.###",
+		"#..................#",
+		"####################",
+	},
+	"arena": {
+		"####################",
+		"#..................#",
+		"#.####.######.####.#",
+		"#..................#",
+		"#.#.####....####.#.#",
+		"#.#..............#.#",
+		"#.#.##.######.##.#.#",
+		"=..................=",
+		"#.#.##.######.##.#.#",
+		"#.#......*.......#.#",
+		"#.#.####....####.#.#",
+		"#..................#",
+		"#.####.######.####.#",
+		"#..................#",
+		"####################",
+	},
+}
+
+// MazeNames returns the names of the built-in mazes in sorted order
+func MazeNames() []string {
+	names := make([]string, 0, len(mazes))
+	for name := range mazes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mazeFor resolves a maze name to its layout, using DefaultMaze when name
+// is empty
+func mazeFor(name string) (string, []string, error) {
+	if name == "" {
+		name = DefaultMaze
+	}
+
+	maze, ok := mazes[name]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", domain.ErrUnknownMaze, name)
+	}
+	return name, maze, nil
+}
@@ -0,0 +1,739 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// roomCleanupInterval is how often rooms are checked for finished games
+// and idleness
+const roomCleanupInterval = time.Second
+
+// roomService implements domain.RoomService
+type roomService struct {
+	repo   domain.RoomRepository
+	games  domain.GameService
+	cfg    config.RoomsConfig
+	logger *slog.Logger
+	tracer trace.Tracer
+	// mu serializes changes to rooms
+	mu sync.Mutex
+	// subscribers holds the channels of clients following each room
+	subscribers map[string]map[chan domain.Room]struct{}
+	subMu       sync.Mutex
+}
+
+// NewRoomService creates a new room service that starts games through games
+func NewRoomService(repo domain.RoomRepository, games domain.GameService, cfg config.RoomsConfig, logger *slog.Logger) domain.RoomService {
+	return &roomService{
+		repo:        repo,
+		games:       games,
+		cfg:         cfg,
+		logger:      logger,
+		tracer:      otel.Tracer("room-service"),
+		subscribers: make(map[string]map[chan domain.Room]struct{}),
+	}
+}
+
+// RoomCleaner is implemented by services that tidy up rooms in the
+// background until ctx is done
+type RoomCleaner interface {
+	RunCleanup(ctx context.Context)
+}
+
+// CreateRoom opens a room and returns it with its host member
+func (s *roomService) CreateRoom(ctx context.Context, opts domain.RoomOptions) (*domain.Room, *domain.RoomMember, error) {
+	ctx, span := s.tracer.Start(ctx, "CreateRoom")
+	defer span.End()
+
+	if err := validateRoomSettings(opts.Settings, 1); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid room settings")
+		return nil, nil, err
+	}
+
+	id, err := newPlayerToken()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create room ID")
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms, err := s.repo.List(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list rooms")
+		return nil, nil, fmt.Errorf("failed to create room: %w", err)
+	}
+	if len(rooms) >= s.cfg.MaxRooms {
+		err := domain.ErrTooManyRooms
+		s.logger.WarnContext(ctx, "room limit reached", "max_rooms", s.cfg.MaxRooms)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	room := &domain.Room{
+		ID:        "room-" + id[:16],
+		Name:      opts.Name,
+		Settings:  opts.Settings,
+		Status:    domain.RoomWaiting,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	host, err := addRoomMember(room, opts.HostName)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create member token")
+		return nil, nil, err
+	}
+	room.HostID = host.ID
+
+	span.SetAttributes(
+		attribute.String("room.id", room.ID),
+		attribute.Int("room.capacity", room.Settings.Capacity),
+	)
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return nil, nil, err
+	}
+
+	s.logger.InfoContext(ctx, "room created",
+		"room_id", room.ID,
+		"capacity", room.Settings.Capacity,
+	)
+
+	return room.Clone(), host, nil
+}
+
+// ListRooms lists open rooms, oldest first
+func (s *roomService) ListRooms(ctx context.Context) ([]domain.Room, error) {
+	ctx, span := s.tracer.Start(ctx, "ListRooms")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms, err := s.repo.List(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to list rooms")
+		return nil, fmt.Errorf("failed to list rooms: %w", err)
+	}
+
+	list := make([]domain.Room, len(rooms))
+	for i, room := range rooms {
+		list[i] = *room.Clone()
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	span.SetAttributes(attribute.Int("rooms.count", len(list)))
+	return list, nil
+}
+
+// GetRoom retrieves a room by ID
+func (s *roomService) GetRoom(ctx context.Context, roomID string) (*domain.Room, error) {
+	ctx, span := s.tracer.Start(ctx, "GetRoom")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.repo.FindByID(ctx, roomID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room not found")
+		return nil, fmt.Errorf("failed to get room: %w", err)
+	}
+
+	return room.Clone(), nil
+}
+
+// JoinRoom adds a member to a waiting room
+func (s *roomService) JoinRoom(ctx context.Context, roomID string, name string) (*domain.Room, *domain.RoomMember, error) {
+	ctx, span := s.tracer.Start(ctx, "JoinRoom")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.repo.FindByID(ctx, roomID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room not found")
+		return nil, nil, fmt.Errorf("failed to join room: %w", err)
+	}
+
+	if room.Status != domain.RoomWaiting {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomStarted, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room started")
+		return nil, nil, err
+	}
+	if room.IsFull() {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomFull, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room full")
+		return nil, nil, err
+	}
+
+	member, err := addRoomMember(room, name)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create member token")
+		return nil, nil, err
+	}
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return nil, nil, err
+	}
+
+	s.logger.InfoContext(ctx, "member joined room",
+		"room_id", roomID,
+		"member_id", member.ID,
+		"members", len(room.Members),
+	)
+
+	return room.Clone(), member, nil
+}
+
+// LeaveRoom removes a member, handing the host role on and closing the
+// room once it is empty
+func (s *roomService) LeaveRoom(ctx context.Context, roomID string, memberToken string) error {
+	ctx, span := s.tracer.Start(ctx, "LeaveRoom")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, member, err := s.authenticate(ctx, roomID, memberToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to authenticate member")
+		return err
+	}
+
+	memberID := member.ID
+	for i := range room.Members {
+		if room.Members[i].ID == memberID {
+			room.Members = append(room.Members[:i], room.Members[i+1:]...)
+			break
+		}
+	}
+
+	s.logger.InfoContext(ctx, "member left room",
+		"room_id", roomID,
+		"member_id", memberID,
+		"members", len(room.Members),
+	)
+
+	if len(room.Members) == 0 {
+		s.closeRoom(ctx, room, "empty")
+		return nil
+	}
+
+	if room.HostID == memberID {
+		room.HostID = room.Members[0].ID
+		s.logger.InfoContext(ctx, "room host changed",
+			"room_id", roomID,
+			"host_id", room.HostID,
+		)
+	}
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return err
+	}
+	return nil
+}
+
+// SetReady marks a member as ready or not
+func (s *roomService) SetReady(ctx context.Context, roomID string, memberToken string, ready bool) (*domain.Room, error) {
+	ctx, span := s.tracer.Start(ctx, "SetReady")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("room.id", roomID),
+		attribute.Bool("member.ready", ready),
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, member, err := s.authenticate(ctx, roomID, memberToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to authenticate member")
+		return nil, err
+	}
+
+	if room.Status != domain.RoomWaiting {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomStarted, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room started")
+		return nil, err
+	}
+
+	member.Ready = ready
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return nil, err
+	}
+
+	return room.Clone(), nil
+}
+
+// UpdateSettings changes the game settings of a waiting room. Members have
+// to confirm they are ready again afterwards.
+func (s *roomService) UpdateSettings(ctx context.Context, roomID string, memberToken string, settings domain.RoomSettings) (*domain.Room, error) {
+	ctx, span := s.tracer.Start(ctx, "UpdateSettings")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.authenticateHost(ctx, roomID, memberToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to authenticate host")
+		return nil, err
+	}
+
+	if room.Status != domain.RoomWaiting {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomStarted, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room started")
+		return nil, err
+	}
+
+	if err := validateRoomSettings(settings, len(room.Members)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid room settings")
+		return nil, err
+	}
+
+	room.Settings = settings
+	for i := range room.Members {
+		room.Members[i].Ready = false
+	}
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "room settings updated",
+		"room_id", roomID,
+		"preset", settings.Preset,
+		"maze", settings.Maze,
+		"capacity", settings.Capacity,
+	)
+
+	return room.Clone(), nil
+}
+
+// StartRoom creates the room's game, seats every member and schedules the
+// game loop to start once the countdown ends
+func (s *roomService) StartRoom(ctx context.Context, roomID string, memberToken string) (*domain.Room, error) {
+	ctx, span := s.tracer.Start(ctx, "StartRoom")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.authenticateHost(ctx, roomID, memberToken)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to authenticate host")
+		return nil, err
+	}
+
+	if room.Status != domain.RoomWaiting {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomStarted, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room started")
+		return nil, err
+	}
+	if !room.AllReady() {
+		err := fmt.Errorf("%w: %s", domain.ErrRoomNotReady, roomID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "room not ready")
+		return nil, err
+	}
+
+	if err := s.seatMembers(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create game")
+		return nil, err
+	}
+
+	room.Status = domain.RoomCountdown
+	room.StartsAt = time.Now().Add(s.cfg.Countdown)
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+		return nil, err
+	}
+
+	startsAt := room.StartsAt
+	time.AfterFunc(s.cfg.Countdown, func() {
+		s.launch(roomID, startsAt)
+	})
+
+	s.logger.InfoContext(ctx, "room countdown started",
+		"room_id", roomID,
+		"members", len(room.Members),
+		"countdown", s.cfg.Countdown,
+	)
+
+	return room.Clone(), nil
+}
+
+// seatMembers creates the room's game with the host as its first player
+// and joins every other member to it. Callers must hold s.mu.
+func (s *roomService) seatMembers(ctx context.Context, room *domain.Room) error {
+	host, _ := room.Member(room.HostID)
+	game, err := s.games.CreateGame(ctx, room.ID, domain.GameOptions{
+		Preset:     room.Settings.Preset,
+		Maze:       room.Settings.Maze,
+		MaxPlayers: room.Settings.Capacity,
+		PlayerName: host.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create room game: %w", err)
+	}
+	host.PlayerToken = game.Host().Token
+
+	for i := range room.Members {
+		member := &room.Members[i]
+		if member.ID == room.HostID {
+			continue
+		}
+		player, err := s.games.JoinGame(ctx, room.ID, member.Name)
+		if err != nil {
+			s.deleteGame(ctx, room.ID)
+			return fmt.Errorf("failed to seat room member: %w", err)
+		}
+		member.PlayerToken = player.Token
+	}
+
+	return nil
+}
+
+// launch starts the game loop of a room whose countdown ended at startsAt.
+// Rooms that were closed or restarted in the meantime are left alone.
+func (s *roomService) launch(roomID string, startsAt time.Time) {
+	ctx, span := s.tracer.Start(context.Background(), "LaunchRoom")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("room.id", roomID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.repo.FindByID(ctx, roomID)
+	if err != nil || room.Status != domain.RoomCountdown || !room.StartsAt.Equal(startsAt) {
+		return
+	}
+
+	if err := s.games.StartGameLoop(ctx, roomID); err != nil {
+		s.logger.ErrorContext(ctx, "failed to start room game loop",
+			"room_id", roomID,
+			"error", err,
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to start game loop")
+		s.deleteGame(ctx, roomID)
+		resetRoom(room)
+	} else {
+		room.Status = domain.RoomPlaying
+		s.logger.InfoContext(ctx, "room game started", "room_id", roomID)
+	}
+
+	if err := s.save(ctx, room); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to save room")
+	}
+}
+
+// Subscribe returns a channel that receives the room after every change
+func (s *roomService) Subscribe(ctx context.Context, roomID string) (<-chan domain.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.repo.FindByID(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to room: %w", err)
+	}
+
+	// Updates are coalesced so a slow client only ever sees the latest room
+	ch := make(chan domain.Room, 1)
+	ch <- *room.Clone()
+
+	s.subMu.Lock()
+	if s.subscribers[roomID] == nil {
+		s.subscribers[roomID] = make(map[chan domain.Room]struct{})
+	}
+	s.subscribers[roomID][ch] = struct{}{}
+	s.subMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.unsubscribe(roomID, ch)
+	}()
+
+	return ch, nil
+}
+
+// RunCleanup returns rooms whose game has finished to the waiting state
+// and closes idle rooms until ctx is done. Subscriptions end with it.
+func (s *roomService) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(roomCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.subMu.Lock()
+			for roomID, subscribers := range s.subscribers {
+				for ch := range subscribers {
+					close(ch)
+				}
+				delete(s.subscribers, roomID)
+			}
+			s.subMu.Unlock()
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup runs one pass of RunCleanup
+func (s *roomService) cleanup(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to list rooms", "error", err)
+		return
+	}
+
+	idleSince := time.Now().Add(-s.cfg.IdleTimeout)
+	for _, room := range rooms {
+		switch room.Status {
+		case domain.RoomWaiting:
+			if room.UpdatedAt.Before(idleSince) {
+				s.closeRoom(ctx, room, "idle")
+			}
+		case domain.RoomPlaying:
+			game, err := s.games.GetGame(ctx, room.ID)
+			if err == nil && !game.IsFinished() {
+				continue
+			}
+			resetRoom(room)
+			if err := s.save(ctx, room); err != nil {
+				s.logger.ErrorContext(ctx, "failed to save room",
+					"room_id", room.ID,
+					"error", err,
+				)
+				continue
+			}
+			s.logger.InfoContext(ctx, "room game finished", "room_id", room.ID)
+		}
+	}
+}
+
+// authenticate finds a room and the member authenticated by memberToken.
+// Callers must hold s.mu.
+func (s *roomService) authenticate(ctx context.Context, roomID, memberToken string) (*domain.Room, *domain.RoomMember, error) {
+	room, err := s.repo.FindByID(ctx, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member, ok := room.MemberByToken(memberToken)
+	if !ok {
+		return nil, nil, domain.ErrInvalidMemberToken
+	}
+	return room, member, nil
+}
+
+// authenticateHost finds a room whose host is authenticated by
+// memberToken. Callers must hold s.mu.
+func (s *roomService) authenticateHost(ctx context.Context, roomID, memberToken string) (*domain.Room, error) {
+	room, member, err := s.authenticate(ctx, roomID, memberToken)
+	if err != nil {
+		return nil, err
+	}
+	if member.ID != room.HostID {
+		return nil, domain.ErrNotRoomHost
+	}
+	return room, nil
+}
+
+// save stamps and persists a room, then pushes it to subscribers. Callers
+// must hold s.mu.
+func (s *roomService) save(ctx context.Context, room *domain.Room) error {
+	room.UpdatedAt = time.Now()
+	if err := s.repo.Save(ctx, room); err != nil {
+		s.logger.ErrorContext(ctx, "failed to save room",
+			"room_id", room.ID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to save room: %w", err)
+	}
+
+	s.publish(room)
+	return nil
+}
+
+// closeRoom deletes a room along with any game it started and disconnects
+// its subscribers. Callers must hold s.mu.
+func (s *roomService) closeRoom(ctx context.Context, room *domain.Room, reason string) {
+	if err := s.repo.Delete(ctx, room.ID); err != nil {
+		s.logger.ErrorContext(ctx, "failed to delete room",
+			"room_id", room.ID,
+			"error", err,
+		)
+		return
+	}
+	if room.Status != domain.RoomWaiting {
+		s.deleteGame(ctx, room.ID)
+	}
+
+	s.subMu.Lock()
+	for ch := range s.subscribers[room.ID] {
+		close(ch)
+	}
+	delete(s.subscribers, room.ID)
+	s.subMu.Unlock()
+
+	s.logger.InfoContext(ctx, "room closed",
+		"room_id", room.ID,
+		"reason", reason,
+	)
+}
+
+// deleteGame removes a room's game, logging failures
+func (s *roomService) deleteGame(ctx context.Context, roomID string) {
+	if err := s.games.DeleteGame(ctx, roomID); err != nil {
+		s.logger.WarnContext(ctx, "failed to delete room game",
+			"room_id", roomID,
+			"error", err,
+		)
+	}
+}
+
+// publish sends a copy of room to its subscribers, replacing any update
+// they have not received yet
+func (s *roomService) publish(room *domain.Room) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for ch := range s.subscribers[room.ID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *room.Clone()
+	}
+}
+
+// unsubscribe removes and closes a subscriber channel unless the room has
+// already been closed
+func (s *roomService) unsubscribe(roomID string, ch chan domain.Room) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if _, ok := s.subscribers[roomID][ch]; !ok {
+		return
+	}
+	delete(s.subscribers[roomID], ch)
+	if len(s.subscribers[roomID]) == 0 {
+		delete(s.subscribers, roomID)
+	}
+	close(ch)
+}
+
+// validateRoomSettings checks settings for a room with the given number of
+// members
+func validateRoomSettings(settings domain.RoomSettings, members int) error {
+	if settings.Capacity < domain.MinRoomCapacity || settings.Capacity > domain.MaxPlayers {
+		return fmt.Errorf("%w: capacity must be between %d and %d", domain.ErrInvalidGameOptions, domain.MinRoomCapacity, domain.MaxPlayers)
+	}
+	if settings.Capacity < members {
+		return fmt.Errorf("%w: capacity cannot be below the %d members already in the room", domain.ErrInvalidGameOptions, members)
+	}
+	if _, _, err := mazeFor(settings.Maze); err != nil {
+		return err
+	}
+	return nil
+}
+
+// addRoomMember seats a new member with a fresh token in room
+func addRoomMember(room *domain.Room, name string) (*domain.RoomMember, error) {
+	token, err := newPlayerToken()
+	if err != nil {
+		return nil, err
+	}
+
+	room.Joined++
+	room.Members = append(room.Members, domain.RoomMember{
+		ID:       fmt.Sprintf("m%d", room.Joined),
+		Name:     name,
+		Token:    token,
+		JoinedAt: time.Now(),
+	})
+
+	member := room.Members[len(room.Members)-1]
+	return &member, nil
+}
+
+// resetRoom returns a room to the waiting state for another round
+func resetRoom(room *domain.Room) {
+	room.Status = domain.RoomWaiting
+	room.StartsAt = time.Time{}
+	for i := range room.Members {
+		room.Members[i].Ready = false
+		room.Members[i].PlayerToken = ""
+	}
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siddarth/go-app/internal/config"
+	"github.com/siddarth/go-app/internal/domain"
+	"github.com/siddarth/go-app/internal/repository/memory"
+)
+
+// fakeGames is the part of a game service rooms use, keeping games in a
+// map so that tests can end them
+type fakeGames struct {
+	domain.GameService
+
+	mu    sync.Mutex
+	games map[string]*domain.Game
+	loops map[string]bool
+}
+
+func newFakeGames() *fakeGames {
+	return &fakeGames{games: make(map[string]*domain.Game), loops: make(map[string]bool)}
+}
+
+func (f *fakeGames) CreateGame(ctx context.Context, sessionID string, opts domain.GameOptions) (*domain.Game, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.games[sessionID]; ok {
+		return nil, domain.ErrSessionConflict
+	}
+	// One dot keeps the game going until the test ends it
+	game := &domain.Game{ID: sessionID, MaxPlayers: opts.MaxPlayers, Board: domain.NewBoard([]string{"."}, 1, 1)}
+	game.Players = append(game.Players, domain.Player{ID: "p1", Name: opts.PlayerName, Token: sessionID + "-p1"})
+	f.games[sessionID] = game
+	return game, nil
+}
+
+func (f *fakeGames) JoinGame(ctx context.Context, sessionID string, name string) (*domain.Player, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	game, ok := f.games[sessionID]
+	if !ok {
+		return nil, domain.ErrGameNotFound
+	}
+	if len(game.Players) >= game.MaxPlayers {
+		return nil, domain.ErrGameFull
+	}
+	id := fmt.Sprintf("p%d", len(game.Players)+1)
+	game.Players = append(game.Players, domain.Player{ID: id, Name: name, Token: sessionID + "-" + id})
+	return &game.Players[len(game.Players)-1], nil
+}
+
+func (f *fakeGames) GetGame(ctx context.Context, sessionID string) (*domain.Game, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	game, ok := f.games[sessionID]
+	if !ok {
+		return nil, domain.ErrGameNotFound
+	}
+	clone := *game
+	return &clone, nil
+}
+
+func (f *fakeGames) StartGameLoop(ctx context.Context, sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.games[sessionID]; !ok {
+		return domain.ErrGameNotFound
+	}
+	f.loops[sessionID] = true
+	return nil
+}
+
+func (f *fakeGames) DeleteGame(ctx context.Context, sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.games, sessionID)
+	delete(f.loops, sessionID)
+	return nil
+}
+
+// end finishes the game of a session
+func (f *fakeGames) end(sessionID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.games[sessionID].GameOver = true
+}
+
+// running reports whether the game loop of a session was started
+func (f *fakeGames) running(sessionID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.loops[sessionID]
+}
+
+// newTestRooms creates a room service on games with the default room
+// configuration, changed by tune
+func newTestRooms(t *testing.T, games domain.GameService, tune func(cfg *config.RoomsConfig)) *roomService {
+	t.Helper()
+
+	cfg, err := config.Load(&config.Options{})
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	if tune != nil {
+		tune(&cfg.Rooms)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRoomService(memory.NewRoomRepository(), games, cfg.Rooms, logger).(*roomService)
+}
+
+// openRoom creates a room for capacity members and returns it with the
+// host's member token
+func openRoom(t *testing.T, rooms *roomService, capacity int) (*domain.Room, string) {
+	t.Helper()
+
+	room, host, err := rooms.CreateRoom(context.Background(), domain.RoomOptions{
+		HostName: "host",
+		Settings: domain.RoomSettings{Capacity: capacity},
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	return room, host.Token
+}
+
+// waitForStatus polls a room until it has status, failing the test after
+// a second
+func waitForStatus(t *testing.T, rooms *roomService, roomID string, status domain.RoomStatus) *domain.Room {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		room, err := rooms.GetRoom(context.Background(), roomID)
+		if err == nil && room.Status == status {
+			return room
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("room %s did not become %s: %+v %v", roomID, status, room, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRoomJoinReadyAndStart(t *testing.T) {
+	ctx := context.Background()
+	games := newFakeGames()
+	rooms := newTestRooms(t, games, func(cfg *config.RoomsConfig) { cfg.Countdown = 10 * time.Millisecond })
+
+	room, host := openRoom(t, rooms, 3)
+	_, alice, err := rooms.JoinRoom(ctx, room.ID, "alice")
+	if err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+	_, bob, err := rooms.JoinRoom(ctx, room.ID, "bob")
+	if err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+
+	if _, err := rooms.StartRoom(ctx, room.ID, host); !errors.Is(err, domain.ErrRoomNotReady) {
+		t.Fatalf("StartRoom before everyone is ready: got %v, want %v", err, domain.ErrRoomNotReady)
+	}
+	for _, token := range []string{alice.Token, bob.Token} {
+		if _, err := rooms.SetReady(ctx, room.ID, token, true); err != nil {
+			t.Fatalf("SetReady: %v", err)
+		}
+	}
+	if _, err := rooms.StartRoom(ctx, room.ID, alice.Token); !errors.Is(err, domain.ErrNotRoomHost) {
+		t.Fatalf("StartRoom by a guest: got %v, want %v", err, domain.ErrNotRoomHost)
+	}
+	if _, err := rooms.SetReady(ctx, room.ID, "nope", true); !errors.Is(err, domain.ErrInvalidMemberToken) {
+		t.Fatalf("SetReady with an unknown token: got %v, want %v", err, domain.ErrInvalidMemberToken)
+	}
+
+	started, err := rooms.StartRoom(ctx, room.ID, host)
+	if err != nil {
+		t.Fatalf("StartRoom: %v", err)
+	}
+	if started.Status != domain.RoomCountdown {
+		t.Fatalf("started room: got status %s, want %s", started.Status, domain.RoomCountdown)
+	}
+
+	// Every member has a seat in the room's game
+	game, err := games.GetGame(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("room game: %v", err)
+	}
+	if len(game.Players) != 3 {
+		t.Fatalf("room game: got %d players, want 3", len(game.Players))
+	}
+	for _, m := range started.Members {
+		if _, ok := game.PlayerByToken(m.PlayerToken); !ok {
+			t.Errorf("member %s has no player in the game", m.Name)
+		}
+	}
+
+	if _, _, err := rooms.JoinRoom(ctx, room.ID, "late"); !errors.Is(err, domain.ErrRoomStarted) {
+		t.Errorf("JoinRoom after the start: got %v, want %v", err, domain.ErrRoomStarted)
+	}
+	if _, err := rooms.SetReady(ctx, room.ID, alice.Token, false); !errors.Is(err, domain.ErrRoomStarted) {
+		t.Errorf("SetReady after the start: got %v, want %v", err, domain.ErrRoomStarted)
+	}
+
+	// The game loop starts once the countdown ends
+	waitForStatus(t, rooms, room.ID, domain.RoomPlaying)
+	if !games.running(room.ID) {
+		t.Error("room game loop was not started")
+	}
+}
+
+func TestRoomCapacity(t *testing.T) {
+	ctx := context.Background()
+	rooms := newTestRooms(t, newFakeGames(), func(cfg *config.RoomsConfig) { cfg.MaxRooms = 2 })
+
+	room, host := openRoom(t, rooms, 2)
+	if _, _, err := rooms.JoinRoom(ctx, room.ID, "alice"); err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+	if _, _, err := rooms.JoinRoom(ctx, room.ID, "bob"); !errors.Is(err, domain.ErrRoomFull) {
+		t.Fatalf("JoinRoom into a full room: got %v, want %v", err, domain.ErrRoomFull)
+	}
+	if _, _, err := rooms.JoinRoom(ctx, "room-missing", "bob"); !errors.Is(err, domain.ErrRoomNotFound) {
+		t.Fatalf("JoinRoom into a missing room: got %v, want %v", err, domain.ErrRoomNotFound)
+	}
+
+	// Capacity cannot drop below the members already waiting
+	_, err := rooms.UpdateSettings(ctx, room.ID, host, domain.RoomSettings{Capacity: domain.MinRoomCapacity - 1})
+	if !errors.Is(err, domain.ErrInvalidGameOptions) {
+		t.Fatalf("UpdateSettings below the minimum: got %v, want %v", err, domain.ErrInvalidGameOptions)
+	}
+	updated, err := rooms.UpdateSettings(ctx, room.ID, host, domain.RoomSettings{Capacity: 3})
+	if err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	if updated.IsFull() {
+		t.Fatal("room is still full after raising its capacity")
+	}
+
+	_, _, err = rooms.CreateRoom(ctx, domain.RoomOptions{Settings: domain.RoomSettings{Capacity: domain.MaxPlayers + 1}})
+	if !errors.Is(err, domain.ErrInvalidGameOptions) {
+		t.Fatalf("CreateRoom over the player limit: got %v, want %v", err, domain.ErrInvalidGameOptions)
+	}
+
+	openRoom(t, rooms, 2)
+	_, _, err = rooms.CreateRoom(ctx, domain.RoomOptions{Settings: domain.RoomSettings{Capacity: 2}})
+	if !errors.Is(err, domain.ErrTooManyRooms) {
+		t.Fatalf("CreateRoom over the room limit: got %v, want %v", err, domain.ErrTooManyRooms)
+	}
+}
+
+func TestRoomHostHandover(t *testing.T) {
+	ctx := context.Background()
+	rooms := newTestRooms(t, newFakeGames(), nil)
+
+	room, host := openRoom(t, rooms, 4)
+	_, alice, err := rooms.JoinRoom(ctx, room.ID, "alice")
+	if err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+	_, bob, err := rooms.JoinRoom(ctx, room.ID, "bob")
+	if err != nil {
+		t.Fatalf("JoinRoom: %v", err)
+	}
+
+	// The longest-waiting member takes over from a leaving host
+	if err := rooms.LeaveRoom(ctx, room.ID, host); err != nil {
+		t.Fatalf("LeaveRoom: %v", err)
+	}
+	got, err := rooms.GetRoom(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+	if got.HostID != alice.ID || len(got.Members) != 2 {
+		t.Fatalf("after the host left: got host %s and %d members, want %s and 2", got.HostID, len(got.Members), alice.ID)
+	}
+	if _, err := rooms.UpdateSettings(ctx, room.ID, alice.Token, domain.RoomSettings{Capacity: 2}); err != nil {
+		t.Fatalf("UpdateSettings by the new host: %v", err)
+	}
+	if err := rooms.LeaveRoom(ctx, room.ID, host); !errors.Is(err, domain.ErrInvalidMemberToken) {
+		t.Fatalf("LeaveRoom twice: got %v, want %v", err, domain.ErrInvalidMemberToken)
+	}
+
+	// The last member to leave closes the room
+	updates, err := rooms.Subscribe(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	for _, token := range []string{bob.Token, alice.Token} {
+		if err := rooms.LeaveRoom(ctx, room.ID, token); err != nil {
+			t.Fatalf("LeaveRoom: %v", err)
+		}
+	}
+	if _, err := rooms.GetRoom(ctx, room.ID); !errors.Is(err, domain.ErrRoomNotFound) {
+		t.Fatalf("GetRoom after everyone left: got %v, want %v", err, domain.ErrRoomNotFound)
+	}
+	for range updates {
+	}
+}
+
+func TestRoomCleanup(t *testing.T) {
+	ctx := context.Background()
+	games := newFakeGames()
+	rooms := newTestRooms(t, games, func(cfg *config.RoomsConfig) {
+		cfg.Countdown = 0
+		cfg.IdleTimeout = time.Hour
+	})
+
+	// start plays a game in a fresh room and returns the room
+	start := func() *domain.Room {
+		room, host := openRoom(t, rooms, 2)
+		_, guest, err := rooms.JoinRoom(ctx, room.ID, "guest")
+		if err != nil {
+			t.Fatalf("JoinRoom: %v", err)
+		}
+		if _, err := rooms.SetReady(ctx, room.ID, guest.Token, true); err != nil {
+			t.Fatalf("SetReady: %v", err)
+		}
+		if _, err := rooms.StartRoom(ctx, room.ID, host); err != nil {
+			t.Fatalf("StartRoom: %v", err)
+		}
+		return waitForStatus(t, rooms, room.ID, domain.RoomPlaying)
+	}
+	finished, playing, gone := start(), start(), start()
+	games.end(finished.ID)
+	games.DeleteGame(ctx, gone.ID)
+
+	rooms.cleanup(ctx)
+
+	// Rooms whose game ended, or disappeared, wait for another round
+	for _, id := range []string{finished.ID, gone.ID} {
+		room, err := rooms.GetRoom(ctx, id)
+		if err != nil {
+			t.Fatalf("GetRoom: %v", err)
+		}
+		if room.Status != domain.RoomWaiting || !room.StartsAt.IsZero() {
+			t.Errorf("room %s: got status %s, want %s", id, room.Status, domain.RoomWaiting)
+		}
+		for _, m := range room.Members {
+			if m.Ready || m.PlayerToken != "" {
+				t.Errorf("room %s: member %s kept ready %t and player token %q", id, m.Name, m.Ready, m.PlayerToken)
+			}
+		}
+	}
+	if room, _ := rooms.GetRoom(ctx, playing.ID); room.Status != domain.RoomPlaying {
+		t.Errorf("room with a running game: got status %s, want %s", room.Status, domain.RoomPlaying)
+	}
+
+	// Waiting rooms close once idle, along with their subscriptions
+	updates, err := rooms.Subscribe(ctx, finished.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	rooms.cfg.IdleTimeout = -time.Second
+	rooms.cleanup(ctx)
+
+	for _, id := range []string{finished.ID, gone.ID} {
+		if _, err := rooms.GetRoom(ctx, id); !errors.Is(err, domain.ErrRoomNotFound) {
+			t.Errorf("idle room %s: got %v, want %v", id, err, domain.ErrRoomNotFound)
+		}
+	}
+	if _, err := rooms.GetRoom(ctx, playing.ID); err != nil {
+		t.Errorf("playing room was closed: %v", err)
+	}
+	for range updates {
+	}
+}
+
+func TestRoomSubscriptionsEndWithCleanup(t *testing.T) {
+	ctx := context.Background()
+	rooms := newTestRooms(t, newFakeGames(), nil)
+	room, host := openRoom(t, rooms, 2)
+
+	updates, err := rooms.Subscribe(ctx, room.ID)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if got := <-updates; got.ID != room.ID {
+		t.Fatalf("first update: got room %s, want %s", got.ID, room.ID)
+	}
+
+	// Slow subscribers only see the latest change
+	rooms.UpdateSettings(ctx, room.ID, host, domain.RoomSettings{Capacity: 3})
+	rooms.UpdateSettings(ctx, room.ID, host, domain.RoomSettings{Capacity: 4})
+	if got := <-updates; got.Settings.Capacity != 4 {
+		t.Fatalf("coalesced update: got capacity %d, want 4", got.Settings.Capacity)
+	}
+
+	// Stopping cleanup ends every stream, so that servers can shut down
+	cleanupCtx, stopCleanup := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		rooms.RunCleanup(cleanupCtx)
+		close(done)
+	}()
+	stopCleanup()
+
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Fatal("got an update instead of the end of the stream")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription outlived cleanup")
+	}
+	<-done
+}
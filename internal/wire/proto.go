@@ -0,0 +1,91 @@
+package wire
+
+import (
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// ToProto converts a game state to its protobuf form, the schema of
+// protobuf responses and gRPC streams
+func ToProto(s *domain.GameState) *gamev1.GameState {
+	board := make([]string, s.Board.Height())
+	row := make([]byte, 0, s.Board.Width())
+	for y := range board {
+		row = s.Board.AppendRow(row[:0], y)
+		board[y] = string(row)
+	}
+
+	players := make([]*gamev1.PlayerState, len(s.Players))
+	for i, p := range s.Players {
+		players[i] = &gamev1.PlayerState{
+			Id:        p.ID,
+			Name:      p.Name,
+			Position:  toPosition(p.Position),
+			Score:     int32(p.Score),
+			Lives:     int32(p.Lives),
+			Autopilot: p.Autopilot,
+		}
+	}
+
+	ghosts := make([]*gamev1.Position, len(s.Ghosts))
+	for i, g := range s.Ghosts {
+		ghosts[i] = toPosition(g)
+	}
+
+	seats := make([]*gamev1.GhostSeat, len(s.GhostSeats))
+	for i, g := range s.GhostSeats {
+		seats[i] = &gamev1.GhostSeat{
+			Id:       g.ID,
+			Name:     g.Name,
+			Position: toPosition(g.Position),
+			Human:    g.Human,
+			Score:    int32(g.Score),
+		}
+	}
+
+	var fruit *gamev1.Fruit
+	if s.Fruit != nil {
+		fruit = &gamev1.Fruit{
+			Type:           s.Fruit.Type,
+			Position:       toPosition(s.Fruit.Position),
+			Points:         int32(s.Fruit.Points),
+			RemainingTicks: int32(s.Fruit.RemainingTicks),
+		}
+	}
+
+	collected := make([]*gamev1.CollectedFruit, len(s.FruitCollected))
+	for i, f := range s.FruitCollected {
+		collected[i] = &gamev1.CollectedFruit{
+			Type:     f.Type,
+			Points:   int32(f.Points),
+			PlayerId: f.PlayerID,
+			DotsLeft: int32(f.DotsLeft),
+		}
+	}
+
+	return &gamev1.GameState{
+		Board:          board,
+		Players:        players,
+		MaxPlayers:     int32(s.MaxPlayers),
+		Ghosts:         ghosts,
+		GhostSeats:     seats,
+		Score:          int32(s.Score),
+		DotsLeft:       int32(s.DotsLeft),
+		GameOver:       s.GameOver,
+		Won:            s.Won,
+		Winners:        s.Winners,
+		WinningSide:    s.WinningSide,
+		Preset:         s.Preset,
+		Maze:           s.Maze,
+		Headless:       s.Headless,
+		Spectators:     int32(s.Spectators),
+		Announcement:   s.Announcement,
+		Fruit:          fruit,
+		FruitCollected: collected,
+	}
+}
+
+// toPosition converts a domain position to its protobuf form
+func toPosition(p domain.Position) *gamev1.Position {
+	return &gamev1.Position{X: int32(p.X), Y: int32(p.Y)}
+}
@@ -0,0 +1,121 @@
+// Package wire encodes game states in the formats clients can ask for, so
+// that the HTTP API and streaming transports send the same schema in each
+package wire
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// Media types of the supported formats
+const (
+	MediaTypeJSON     = "application/json"
+	MediaTypeMsgPack  = "application/msgpack"
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// Format is an encoding of game states
+type Format int
+
+const (
+	// FormatJSON encodes states as the JSON documented by the OpenAPI spec
+	FormatJSON Format = iota
+	// FormatMsgPack encodes states as MessagePack maps with the JSON field
+	// names, the board being the binary form of domain.Board
+	FormatMsgPack
+	// FormatProtobuf encodes states as game.v1.GameState messages
+	FormatProtobuf
+)
+
+// formats maps the media types clients may accept to formats. Some
+// clients send the unregistered MessagePack types.
+var formats = map[string]Format{
+	MediaTypeJSON:             FormatJSON,
+	MediaTypeMsgPack:          FormatMsgPack,
+	"application/x-msgpack":   FormatMsgPack,
+	"application/vnd.msgpack": FormatMsgPack,
+	MediaTypeProtobuf:         FormatProtobuf,
+	"application/protobuf":    FormatProtobuf,
+}
+
+// msgpackHandle writes the MessagePack spec that has a binary type
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case FormatMsgPack:
+		return "msgpack"
+	case FormatProtobuf:
+		return "protobuf"
+	default:
+		return "json"
+	}
+}
+
+// ContentType returns the Content-Type of responses in the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMsgPack:
+		return MediaTypeMsgPack
+	case FormatProtobuf:
+		return MediaTypeProtobuf
+	default:
+		return MediaTypeJSON + "; charset=utf-8"
+	}
+}
+
+// Negotiate picks the format a client prefers from its Accept header.
+// Among media types of equal quality the first listed wins. Clients that
+// accept none of the formats, or send no header, get JSON.
+func Negotiate(accept string) Format {
+	best, bestQ := FormatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := formats[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// EncodeState encodes state in format
+func EncodeState(format Format, state *domain.GameState) ([]byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	switch format {
+	case FormatMsgPack:
+		err = codec.NewEncoderBytes(&body, msgpackHandle).Encode(state)
+	case FormatProtobuf:
+		body, err = proto.Marshal(ToProto(state))
+	default:
+		body, err = json.Marshal(state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode state as %s: %w", format, err)
+	}
+	return body, nil
+}
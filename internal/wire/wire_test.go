@@ -0,0 +1,150 @@
+package wire
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+
+	gamev1 "github.com/siddarth/go-app/api/proto/game/v1"
+	"github.com/siddarth/go-app/internal/domain"
+)
+
+// testState returns a game state with every kind of field set
+func testState() *domain.GameState {
+	board := domain.NewBoard([]string{
+		"#####",
+		"#...#",
+		"#. .#",
+		"#####",
+	}, 5, 4)
+	return &domain.GameState{
+		Board:  board,
+		Player: domain.Position{X: 2, Y: 2},
+		Players: []domain.PlayerState{
+			{ID: "p1", Name: "pac", Position: domain.Position{X: 2, Y: 2}, Score: 30, Lives: 2},
+			{ID: "p2", Name: "bot", Position: domain.Position{X: 1, Y: 1}, Autopilot: "greedy"},
+		},
+		MaxPlayers: 2,
+		Ghosts:     []domain.Position{{X: 3, Y: 1}},
+		GhostSeats: []domain.GhostState{{ID: "g1", Name: "blinky", Position: domain.Position{X: 3, Y: 1}, Human: true, Score: 200}},
+		Score:      30,
+		DotsLeft:   board.Dots(),
+		Preset:     "normal",
+		Maze:       "classic",
+		Spectators: 3,
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tc := range []struct {
+		accept string
+		want   Format
+	}{
+		{"", FormatJSON},
+		{"*/*", FormatJSON},
+		{"text/html", FormatJSON},
+		{"not a media type", FormatJSON},
+		{"application/json", FormatJSON},
+		{"application/msgpack", FormatMsgPack},
+		{"application/x-msgpack", FormatMsgPack},
+		{"application/vnd.msgpack", FormatMsgPack},
+		{"application/x-protobuf", FormatProtobuf},
+		{"application/protobuf", FormatProtobuf},
+		{"text/html, application/msgpack", FormatMsgPack},
+		{"application/msgpack, application/x-protobuf", FormatMsgPack},
+		{"application/msgpack;q=0.5, application/x-protobuf", FormatProtobuf},
+		{"application/json;q=0.9, application/x-protobuf;q=0.8", FormatJSON},
+		{"application/x-protobuf;q=0.3, text/html;q=1", FormatProtobuf},
+		{"application/x-protobuf;q=0, application/msgpack;q=0", FormatJSON},
+		{"application/x-protobuf;q=high, application/msgpack;q=0.1", FormatMsgPack},
+	} {
+		if got := Negotiate(tc.accept); got != tc.want {
+			t.Errorf("Negotiate(%q): got %s, want %s", tc.accept, got, tc.want)
+		}
+	}
+}
+
+func TestEncodeStateJSON(t *testing.T) {
+	state := testState()
+	body, err := EncodeState(FormatJSON, state)
+	if err != nil {
+		t.Fatalf("EncodeState: %v", err)
+	}
+
+	var got domain.GameState
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	assertSameState(t, &got, state)
+}
+
+func TestEncodeStateMsgPack(t *testing.T) {
+	state := testState()
+	body, err := EncodeState(FormatMsgPack, state)
+	if err != nil {
+		t.Fatalf("EncodeState: %v", err)
+	}
+
+	var got domain.GameState
+	if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(&got); err != nil {
+		t.Fatalf("failed to decode MessagePack: %v", err)
+	}
+	assertSameState(t, &got, state)
+
+	// Maps are keyed by the JSON field names
+	var fields map[string]interface{}
+	if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(&fields); err != nil {
+		t.Fatalf("failed to decode MessagePack map: %v", err)
+	}
+	for _, name := range []string{"board", "players", "dotsLeft", "ghostSeats"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("MessagePack map has no %q field", name)
+		}
+	}
+}
+
+func TestEncodeStateProtobuf(t *testing.T) {
+	state := testState()
+	body, err := EncodeState(FormatProtobuf, state)
+	if err != nil {
+		t.Fatalf("EncodeState: %v", err)
+	}
+
+	var got gamev1.GameState
+	if err := proto.Unmarshal(body, &got); err != nil {
+		t.Fatalf("failed to decode protobuf: %v", err)
+	}
+	if !proto.Equal(&got, ToProto(state)) {
+		t.Fatalf("protobuf round trip: got %v, want %v", &got, ToProto(state))
+	}
+
+	board := got.GetBoard()
+	if len(board) != 4 || board[1] != "#...#" || board[2] != "#. .#" {
+		t.Errorf("board rows: got %q", board)
+	}
+	if p := got.GetPlayers()[1]; p.GetName() != "bot" || p.GetAutopilot() != "greedy" || p.GetPosition().GetX() != 1 {
+		t.Errorf("second player: got %v", p)
+	}
+	if got.GetDotsLeft() != int32(state.DotsLeft) || got.GetSpectators() != 3 || !got.GetGhostSeats()[0].GetHuman() {
+		t.Errorf("state: got %v", &got)
+	}
+}
+
+// assertSameState fails the test unless got and want encode to the same
+// JSON
+func assertSameState(t *testing.T, got, want *domain.GameState) {
+	t.Helper()
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("failed to encode state: %v", err)
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("failed to encode state: %v", err)
+	}
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("state changed in the round trip:\ngot  %s\nwant %s", gotJSON, wantJSON)
+	}
+}
@@ -0,0 +1,173 @@
+// Package client is a typed Go client for the HTTP API of the game server,
+// described by the OpenAPI document served at /api/openapi.json
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds requests made with the default HTTP client
+const defaultTimeout = 10 * time.Second
+
+// Client calls the game server API. It holds no game state, so one client
+// can be shared by goroutines playing different games.
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	clientID string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient makes the client send requests with hc
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithClientID identifies the client to the server, which lists the games
+// it creates through ListGames
+func WithClientID(id string) Option {
+	return func(c *Client) {
+		c.clientID = id
+	}
+}
+
+// New creates a client for the server at baseURL, e.g.
+// "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError is an error response from the server
+type APIError struct {
+	StatusCode int
+	// Code is the HTTP status text, e.g. "Not Found"
+	Code    string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error describes the error response
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("server returned %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Header names of the credentials the API accepts
+const (
+	headerSessionID   = "X-Session-ID"
+	headerPlayerToken = "X-Player-Token"
+	headerViewerID    = "X-Viewer-ID"
+	headerMemberToken = "X-Member-Token"
+	headerClientID    = "X-Client-ID"
+)
+
+// request describes one API call
+type request struct {
+	method  string
+	path    string
+	headers map[string]string
+	body    any
+}
+
+// do sends r and decodes the JSON response into out, turning error
+// responses into *APIError
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	var reader io.Reader
+	if r.body != nil {
+		data, err := json.Marshal(r.body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range r.headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	_, err = c.send(req, out)
+	return err
+}
+
+// send sends req and decodes a JSON response body into out, turning error
+// responses into *APIError. Responses without a body leave out untouched.
+func (c *Client) send(req *http.Request, out any) (*http.Response, error) {
+	if c.clientID != "" {
+		req.Header.Set(headerClientID, c.clientID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Code == "" {
+			apiErr.Code = http.StatusText(resp.StatusCode)
+		}
+		return nil, apiErr
+	}
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusNoContent, http.StatusNotModified:
+		return resp, nil
+	}
+	if out == nil {
+		return resp, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s response: %w", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+// statusResponse acknowledges a request that returns no data
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// Health checks that the server is up
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var resp HealthResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/health"}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
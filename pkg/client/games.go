@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// StartGame starts a game hosted by the caller. An empty sessionID lets the
+// server generate one.
+//
+// Deprecated: use CreateGame.
+func (c *Client) StartGame(ctx context.Context, sessionID string, req StartGameRequest) (*StartGameResponse, error) {
+	var resp StartGameResponse
+	err := c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    "/api/game/start",
+		headers: map[string]string{headerSessionID: sessionID},
+		body:    req,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GameState returns the state of a game
+//
+// Deprecated: use GetGame.
+func (c *Client) GameState(ctx context.Context, sessionID string) (*GameState, error) {
+	var resp GameState
+	err := c.do(ctx, request{
+		method:  http.MethodGet,
+		path:    "/api/game/state",
+		headers: map[string]string{headerSessionID: sessionID},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Move changes the direction of the player or ghost playerToken controls.
+// An empty playerToken moves the host of a single-player game that is not a
+// versus game.
+//
+// Deprecated: use CreateMove.
+func (c *Client) Move(ctx context.Context, sessionID, playerToken string, dir Direction) error {
+	return c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    "/api/game/move",
+		headers: map[string]string{headerSessionID: sessionID, headerPlayerToken: playerToken},
+		body:    map[string]Direction{"direction": dir},
+	}, &statusResponse{})
+}
+
+// RestartGame starts a game again, keeping its rules and players. Only the
+// host, identified by playerToken, can restart a game.
+func (c *Client) RestartGame(ctx context.Context, sessionID, playerToken string) (*StartGameResponse, error) {
+	var resp StartGameResponse
+	err := c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    "/api/game/restart",
+		headers: map[string]string{headerSessionID: sessionID, headerPlayerToken: playerToken},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetAutopilot hands a player to an autopilot strategy. An empty strategy
+// returns control to the player.
+func (c *Client) SetAutopilot(ctx context.Context, sessionID, playerToken, strategy string) error {
+	return c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    "/api/game/autopilot",
+		headers: map[string]string{headerSessionID: sessionID, headerPlayerToken: playerToken},
+		body:    map[string]string{"strategy": strategy},
+	}, &statusResponse{})
+}
+
+// JoinGame takes a seat in a multiplayer game
+func (c *Client) JoinGame(ctx context.Context, sessionID, name string) (*JoinGameResponse, error) {
+	var resp JoinGameResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/game/" + url.PathEscape(sessionID) + "/join",
+		body:   map[string]string{"name": name},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClaimGhost takes control of a ghost in a versus game
+func (c *Client) ClaimGhost(ctx context.Context, sessionID, name string) (*ClaimGhostResponse, error) {
+	var resp ClaimGhostResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/game/" + url.PathEscape(sessionID) + "/ghost",
+		body:   map[string]string{"name": name},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Lobby lists versus games with open ghost seats
+func (c *Client) Lobby(ctx context.Context) ([]GameSummary, error) {
+	var resp struct {
+		Games []GameSummary `json:"games"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/game/lobby"}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Games, nil
+}
+
+// LiveGames lists running games that can be watched
+func (c *Client) LiveGames(ctx context.Context) ([]LiveGame, error) {
+	var resp struct {
+		Games []LiveGame `json:"games"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/games/live"}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Games, nil
+}
+
+// WatchGame returns the state of a game read-only. viewerID identifies the
+// viewer in the spectator count; empty counts the client by IP.
+func (c *Client) WatchGame(ctx context.Context, spectatorToken, viewerID string) (*GameState, error) {
+	var resp GameState
+	err := c.do(ctx, request{
+		method:  http.MethodGet,
+		path:    "/api/watch/" + url.PathEscape(spectatorToken),
+		headers: map[string]string{headerViewerID: viewerID},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Step advances a headless game by one tick with the given action
+func (c *Client) Step(ctx context.Context, sessionID, playerToken string, dir Direction) (*StepResult, error) {
+	var resp StepResult
+	err := c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    "/api/game/step",
+		headers: map[string]string{headerSessionID: sessionID, headerPlayerToken: playerToken},
+		body:    map[string]Direction{"direction": dir},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// BatchStart starts several headless games
+func (c *Client) BatchStart(ctx context.Context, req BatchStartRequest) ([]StartGameResponse, error) {
+	var resp struct {
+		Episodes []StartGameResponse `json:"episodes"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/game/batch/start",
+		body:   req,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Episodes, nil
+}
+
+// BatchStep steps several headless games, returning one result per step in
+// order. Failed actions are reported in their result.
+func (c *Client) BatchStep(ctx context.Context, steps []BatchStep) ([]BatchStepResult, error) {
+	var resp struct {
+		Results []BatchStepResult `json:"results"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/game/batch/step",
+		body:   map[string][]BatchStep{"steps": steps},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
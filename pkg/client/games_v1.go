@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CreateGame starts a game hosted by the caller. An empty sessionID lets
+// the server generate one.
+func (c *Client) CreateGame(ctx context.Context, sessionID string, req StartGameRequest) (*StartGameResponse, error) {
+	body := struct {
+		SessionID string `json:"sessionId,omitempty"`
+		StartGameRequest
+	}{sessionID, req}
+
+	var resp StartGameResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/games",
+		body:   body,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListGames lists the games created with this client's ID, oldest first.
+// It needs a client created WithClientID.
+func (c *Client) ListGames(ctx context.Context) ([]GameSummary, error) {
+	var resp struct {
+		Games []GameSummary `json:"games"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/games"}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Games, nil
+}
+
+// GetGame returns the state of a game
+func (c *Client) GetGame(ctx context.Context, sessionID string) (*GameState, error) {
+	state, _, err := c.GetGameIfChanged(ctx, sessionID, "")
+	return state, err
+}
+
+// GetGameIfChanged returns the state of a game and its ETag, unless the
+// state still has the given ETag, in which case it returns a nil state.
+// Polling clients pass the ETag from their previous call.
+func (c *Client) GetGameIfChanged(ctx context.Context, sessionID, etag string) (*GameState, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+gamePath(sessionID, ""), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	var state GameState
+	resp, err := c.send(req, &state)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	return &state, resp.Header.Get("ETag"), nil
+}
+
+// DeleteGame stops a game and removes it. Only the host can delete a game,
+// with its player token.
+func (c *Client) DeleteGame(ctx context.Context, sessionID, playerToken string) error {
+	return c.do(ctx, request{
+		method:  http.MethodDelete,
+		path:    gamePath(sessionID, ""),
+		headers: map[string]string{headerPlayerToken: playerToken},
+	}, nil)
+}
+
+// CreateMove changes the direction of the player or ghost playerToken
+// controls on the game's next tick. An empty playerToken moves the host of a
+// single-player game that is not a versus game.
+func (c *Client) CreateMove(ctx context.Context, sessionID, playerToken string, dir Direction) error {
+	return c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    gamePath(sessionID, "/moves"),
+		headers: map[string]string{headerPlayerToken: playerToken},
+		body:    map[string]Direction{"direction": dir},
+	}, nil)
+}
+
+// gamePath returns the path of a game resource
+func gamePath(sessionID, suffix string) string {
+	return "/api/v1/games/" + url.PathEscape(sessionID) + suffix
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateRoom opens a room hosted by the caller
+func (c *Client) CreateRoom(ctx context.Context, req CreateRoomRequest) (*RoomMemberResponse, error) {
+	var resp RoomMemberResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/rooms",
+		body:   req,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListRooms lists open rooms
+func (c *Client) ListRooms(ctx context.Context) ([]RoomState, error) {
+	var resp struct {
+		Rooms []RoomState `json:"rooms"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/rooms"}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Rooms, nil
+}
+
+// GetRoom returns a room. A member token adds that member's player token.
+func (c *Client) GetRoom(ctx context.Context, roomID, memberToken string) (*RoomState, error) {
+	return c.roomState(ctx, http.MethodGet, roomID, "", memberToken, nil)
+}
+
+// JoinRoom enters a room
+func (c *Client) JoinRoom(ctx context.Context, roomID, name string) (*RoomMemberResponse, error) {
+	var resp RoomMemberResponse
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   roomPath(roomID, "/join"),
+		body:   map[string]string{"name": name},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LeaveRoom leaves a room
+func (c *Client) LeaveRoom(ctx context.Context, roomID, memberToken string) error {
+	return c.do(ctx, request{
+		method:  http.MethodPost,
+		path:    roomPath(roomID, "/leave"),
+		headers: map[string]string{headerMemberToken: memberToken},
+	}, &statusResponse{})
+}
+
+// SetReady answers the ready check of a room
+func (c *Client) SetReady(ctx context.Context, roomID, memberToken string, ready bool) (*RoomState, error) {
+	return c.roomState(ctx, http.MethodPost, roomID, "/ready", memberToken, map[string]bool{"ready": ready})
+}
+
+// UpdateRoomSettings changes the game settings of a room. Only the host
+// may change them.
+func (c *Client) UpdateRoomSettings(ctx context.Context, roomID, memberToken string, req UpdateRoomSettingsRequest) (*RoomState, error) {
+	return c.roomState(ctx, http.MethodPut, roomID, "/settings", memberToken, req)
+}
+
+// StartRoom starts the countdown to the game of a room. Only the host may
+// start it.
+func (c *Client) StartRoom(ctx context.Context, roomID, memberToken string) (*RoomState, error) {
+	return c.roomState(ctx, http.MethodPost, roomID, "/start", memberToken, nil)
+}
+
+// roomState calls a room endpoint that responds with the room's state
+func (c *Client) roomState(ctx context.Context, method, roomID, suffix, memberToken string, body any) (*RoomState, error) {
+	var resp RoomState
+	err := c.do(ctx, request{
+		method:  method,
+		path:    roomPath(roomID, suffix),
+		headers: map[string]string{headerMemberToken: memberToken},
+		body:    body,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// roomPath returns the path of a room endpoint
+func roomPath(roomID, suffix string) string {
+	return "/api/rooms/" + url.PathEscape(roomID) + suffix
+}
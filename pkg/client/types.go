@@ -0,0 +1,247 @@
+package client
+
+import "time"
+
+// Direction is a way a player or ghost can move
+type Direction string
+
+// Directions accepted by Move and Step. DirectionNone is only valid for
+// Step, where it keeps going the current way.
+const (
+	DirectionUp    Direction = "up"
+	DirectionDown  Direction = "down"
+	DirectionLeft  Direction = "left"
+	DirectionRight Direction = "right"
+	DirectionNone  Direction = "none"
+)
+
+// Position is a cell of the board
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// PlayerState is a player as seen in a game state
+type PlayerState struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	Position Position `json:"position"`
+	Score    int      `json:"score"`
+	Lives    int      `json:"lives"`
+	// Autopilot names the strategy steering the player, if any
+	Autopilot string `json:"autopilot,omitempty"`
+}
+
+// GhostState is a ghost seat of a versus game
+type GhostState struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	Position Position `json:"position"`
+	Human    bool     `json:"human"`
+	Score    int      `json:"score"`
+}
+
+// Fruit is a bonus item waiting on the board to be collected
+type Fruit struct {
+	Type     string   `json:"type"`
+	Position Position `json:"position"`
+	Points   int      `json:"points"`
+	// RemainingTicks is how many more ticks the fruit stays on the board
+	RemainingTicks int `json:"remainingTicks"`
+}
+
+// CollectedFruit is a bonus item a player collected
+type CollectedFruit struct {
+	Type     string `json:"type"`
+	Points   int    `json:"points"`
+	PlayerID string `json:"playerId"`
+	// DotsLeft is the number of dots that were left when it was collected
+	DotsLeft int `json:"dotsLeft"`
+}
+
+// GameState is a snapshot of a game
+type GameState struct {
+	// Board holds rows of cells: # wall, . dot, space empty, = tunnel,
+	// * fruit spawn
+	Board [][]string `json:"board"`
+	// Player is the host's position
+	Player     Position      `json:"player"`
+	Players    []PlayerState `json:"players"`
+	MaxPlayers int           `json:"maxPlayers"`
+	Ghosts     []Position    `json:"ghosts"`
+	GhostSeats []GhostState  `json:"ghostSeats,omitempty"`
+	// Score is the combined score of all players
+	Score       int      `json:"score"`
+	DotsLeft    int      `json:"dotsLeft"`
+	GameOver    bool     `json:"gameOver"`
+	Won         bool     `json:"won"`
+	Winners     []string `json:"winners,omitempty"`
+	WinningSide string   `json:"winningSide,omitempty"`
+	Preset      string   `json:"preset"`
+	Maze        string   `json:"maze"`
+	Headless    bool     `json:"headless,omitempty"`
+	Spectators  int      `json:"spectators"`
+	// Announcement is a message from the server operators, if any
+	Announcement string `json:"announcement,omitempty"`
+	// Fruit is the bonus fruit on the board, if any
+	Fruit *Fruit `json:"fruit,omitempty"`
+	// FruitCollected lists every fruit collected so far, in order
+	FruitCollected []CollectedFruit `json:"fruitCollected,omitempty"`
+}
+
+// StartGameRequest holds the settings of a new game. Zero values select
+// the server's defaults.
+type StartGameRequest struct {
+	Preset     string `json:"preset,omitempty"`
+	MaxPlayers int    `json:"maxPlayers,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Versus     bool   `json:"versus,omitempty"`
+	Maze       string `json:"maze,omitempty"`
+	// Headless games only advance through Step
+	Headless bool `json:"headless,omitempty"`
+	// Autopilot names a strategy that plays for the host
+	Autopilot string `json:"autopilot,omitempty"`
+}
+
+// StartGameResponse describes a started or restarted game
+type StartGameResponse struct {
+	SessionID   string `json:"sessionId"`
+	PlayerID    string `json:"playerId,omitempty"`
+	PlayerToken string `json:"playerToken,omitempty"`
+	// SpectatorToken grants read-only access through WatchGame
+	SpectatorToken string    `json:"spectatorToken,omitempty"`
+	State          GameState `json:"state"`
+}
+
+// JoinGameResponse describes the seat taken in a multiplayer game
+type JoinGameResponse struct {
+	SessionID   string    `json:"sessionId"`
+	PlayerID    string    `json:"playerId"`
+	PlayerToken string    `json:"playerToken"`
+	State       GameState `json:"state"`
+}
+
+// ClaimGhostResponse describes the ghost claimed in a versus game
+type ClaimGhostResponse struct {
+	SessionID   string    `json:"sessionId"`
+	GhostID     string    `json:"ghostId"`
+	PlayerToken string    `json:"playerToken"`
+	State       GameState `json:"state"`
+}
+
+// GameSummary describes a versus game with open ghost seats
+type GameSummary struct {
+	SessionID      string    `json:"sessionId"`
+	Preset         string    `json:"preset"`
+	Players        int       `json:"players"`
+	MaxPlayers     int       `json:"maxPlayers"`
+	OpenGhostSeats int       `json:"openGhostSeats"`
+	Score          int       `json:"score"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// LiveGame describes a running game that can be watched
+type LiveGame struct {
+	SpectatorToken string    `json:"spectatorToken"`
+	Preset         string    `json:"preset"`
+	Players        []string  `json:"players"`
+	Score          int       `json:"score"`
+	DotsLeft       int       `json:"dotsLeft"`
+	Spectators     int       `json:"spectators"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// StepResult is what a bot observes after a headless game step
+type StepResult struct {
+	State GameState `json:"state"`
+	// Reward is the change in the acting player's score during the step
+	Reward int `json:"reward"`
+	// Done is set once the game has finished or the acting player has been
+	// eliminated
+	Done bool `json:"done"`
+}
+
+// BatchStartRequest holds the settings of headless games started together
+type BatchStartRequest struct {
+	Count  int    `json:"count"`
+	Preset string `json:"preset,omitempty"`
+	Maze   string `json:"maze,omitempty"`
+}
+
+// BatchStep is one action of a batch step
+type BatchStep struct {
+	SessionID   string    `json:"sessionId"`
+	PlayerToken string    `json:"playerToken,omitempty"`
+	Direction   Direction `json:"direction,omitempty"`
+}
+
+// BatchStepResult is the outcome of one action of a batch step. Error is
+// set instead of the step fields when the action failed.
+type BatchStepResult struct {
+	SessionID string `json:"sessionId"`
+	*StepResult
+	Error string `json:"error,omitempty"`
+}
+
+// HealthResponse reports that the server is up
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+	Time    string `json:"time"`
+}
+
+// CreateRoomRequest holds the settings of a new room
+type CreateRoomRequest struct {
+	Name     string `json:"name,omitempty"`
+	HostName string `json:"hostName,omitempty"`
+	Capacity int    `json:"capacity"`
+	Preset   string `json:"preset,omitempty"`
+	Maze     string `json:"maze,omitempty"`
+}
+
+// RoomSettings are the game settings of a room
+type RoomSettings struct {
+	Preset   string `json:"preset,omitempty"`
+	Maze     string `json:"maze,omitempty"`
+	Capacity int    `json:"capacity"`
+}
+
+// UpdateRoomSettingsRequest changes the settings given and keeps the rest
+type UpdateRoomSettingsRequest struct {
+	Preset   *string `json:"preset,omitempty"`
+	Maze     *string `json:"maze,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// RoomMemberState is a member as seen in a room state
+type RoomMemberState struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Ready bool   `json:"ready"`
+	Host  bool   `json:"host"`
+}
+
+// RoomState is a snapshot of a room
+type RoomState struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	HostID   string            `json:"hostId"`
+	Settings RoomSettings      `json:"settings"`
+	Members  []RoomMemberState `json:"members"`
+	// Status is waiting, countdown or playing
+	Status   string     `json:"status"`
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	// SessionID is the session of the room's game once the countdown begins
+	SessionID string `json:"sessionId,omitempty"`
+	// PlayerToken is only included for the member the state is built for
+	PlayerToken string    `json:"playerToken,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// RoomMemberResponse is returned to a participant entering a room
+type RoomMemberResponse struct {
+	RoomID      string    `json:"roomId"`
+	MemberID    string    `json:"memberId"`
+	MemberToken string    `json:"memberToken"`
+	Room        RoomState `json:"room"`
+}
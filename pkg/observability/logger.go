@@ -7,24 +7,14 @@ import (
 	"github.com/siddarth/go-app/internal/config"
 )
 
-// NewLogger creates a new structured logger
-func NewLogger(cfg config.LoggingConfig) *slog.Logger {
+// NewLogger creates a new structured logger. The returned LevelVar can be
+// used to change the log level while the logger is in use.
+func NewLogger(cfg config.LoggingConfig) (*slog.Logger, *slog.LevelVar) {
 	var handler slog.Handler
 
 	// Configure log level
-	var level slog.Level
-	switch cfg.Level {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		level = slog.LevelInfo
-	}
+	level := new(slog.LevelVar)
+	level.Set(ParseLevel(cfg.Level))
 
 	opts := &slog.HandlerOptions{
 		Level: level,
@@ -37,6 +27,22 @@ func NewLogger(cfg config.LoggingConfig) *slog.Logger {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	return slog.New(handler)
+	return slog.New(handler), level
 }
 
+// ParseLevel converts a configured log level name to an slog.Level,
+// defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}